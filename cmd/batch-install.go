@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/CaioDGallo/easy-cli/internal/config"
+	"github.com/CaioDGallo/easy-cli/internal/logger"
+	"github.com/CaioDGallo/easy-cli/internal/manifest"
+	"github.com/CaioDGallo/easy-cli/internal/resources"
+	"github.com/CaioDGallo/easy-cli/internal/state"
+	"github.com/CaioDGallo/easy-cli/internal/types"
+	"github.com/CaioDGallo/easy-cli/internal/validation"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type batchResult struct {
+	ClientName  string
+	Status      string
+	BackendURL  string
+	FrontendURL string
+	Duration    time.Duration
+	Err         error
+}
+
+var batchInstallCmd = &cobra.Command{
+	Use:   "batch-install",
+	Short: "Command used to install many clients from a fleet file",
+	Long:  `This command provisions every client listed in a YAML or CSV fleet file concurrently. A failing client is rolled back on its own and does not affect the others.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load()
+		if err != nil {
+			logger.Fatalf("Failed to load configuration: %v", err)
+		}
+
+		fleetPath := cmd.Flag("file").Value.String()
+		parallelism, _ := cmd.Flags().GetInt("parallel")
+		if parallelism < 1 {
+			logger.Fatalf("--parallel must be at least 1")
+		}
+
+		log := logger.WithFields(logrus.Fields{
+			"command": "batch-install",
+			"fleet":   fleetPath,
+		})
+
+		manifests, err := manifest.LoadFleet(fleetPath)
+		if err != nil {
+			logger.Fatalf("Failed to load fleet file: %v", err)
+		}
+
+		clients := make([]types.Client, len(manifests))
+		configs := make([]*config.Config, len(manifests))
+		for i, clientManifest := range manifests {
+			// Every client gets its own copy of the configuration since manifests may override it.
+			clientCfg := *cfg
+			applyManifestProviders(&clientCfg, clientManifest)
+
+			clients[i] = resolveClient(&clientCfg, clientManifest, manifestWithDefaults())
+			configs[i] = &clientCfg
+
+			if err := validation.ValidateClient(clients[i]); err != nil {
+				logger.Fatalf("Client %q failed validation: %v", clients[i].Name, err)
+			}
+			if _, err := resources.ResolveAppSpec(&clientCfg, clients[i].App); err != nil {
+				logger.Fatalf("Client %q failed validation: %v", clients[i].Name, err)
+			}
+			if err := clientCfg.Repository.Validate(); err != nil {
+				logger.Fatalf("Client %q failed validation: %v", clients[i].Name, err)
+			}
+		}
+
+		log.WithFields(logrus.Fields{
+			"clients":  len(clients),
+			"parallel": parallelism,
+		}).Info("Starting batch install")
+
+		results := batchInstall(clients, configs, state.NewStore(cfg.State.Dir), parallelism)
+		printBatchSummary(results)
+
+		failed := 0
+		for _, result := range results {
+			if result.Err != nil {
+				failed++
+			}
+		}
+		if failed > 0 {
+			logger.Fatalf("Batch install finished with %d failed clients", failed)
+		}
+		log.Info("Batch install completed successfully")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(batchInstallCmd)
+
+	batchInstallCmd.Flags().StringP("file", "f", "", "Path to a YAML or CSV fleet file")
+	batchInstallCmd.MarkFlagRequired("file")
+	batchInstallCmd.Flags().IntP("parallel", "j", 2, "Maximum number of clients provisioned at the same time")
+}
+
+// batchInstall runs fresh-install for every client with at most parallelism installs in flight.
+// Each install owns its rollback manager, so a failure only rolls back that client.
+func batchInstall(clients []types.Client, configs []*config.Config, store *state.Store, parallelism int) []batchResult {
+	results := make([]batchResult, len(clients))
+	semaphore := make(chan struct{}, parallelism)
+
+	var wg sync.WaitGroup
+	for i := range clients {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			client := clients[i]
+			log := logger.WithFields(logrus.Fields{
+				"client":  client.Name,
+				"command": "batch-install",
+			})
+			log.Info("Starting client install")
+
+			start := time.Now()
+			deploymentState, err := freshInstall(client, configs[i], store, freshInstallOptions{})
+
+			result := batchResult{
+				ClientName: client.Name,
+				Status:     "succeeded",
+				Duration:   time.Since(start).Round(time.Second),
+				Err:        err,
+			}
+			if deploymentState != nil {
+				result.BackendURL = deploymentState.BackendURL
+				result.FrontendURL = deploymentState.FrontendURL
+			}
+			if err != nil {
+				result.Status = "failed"
+				log.WithError(err).Error("Client install failed")
+			} else {
+				log.Info("Client install completed")
+			}
+
+			results[i] = result
+		}(i)
+	}
+	wg.Wait()
+
+	return results
+}
+
+func printBatchSummary(results []batchResult) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CLIENT\tSTATUS\tDURATION\tBACKEND URL\tFRONTEND URL\tERROR")
+	for _, result := range results {
+		errMsg := ""
+		if result.Err != nil {
+			errMsg = result.Err.Error()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", result.ClientName, result.Status, result.Duration, result.BackendURL, result.FrontendURL, errMsg)
+	}
+	w.Flush()
+}
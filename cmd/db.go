@@ -0,0 +1,322 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/CaioDGallo/easy-cli/internal/aws"
+	"github.com/CaioDGallo/easy-cli/internal/config"
+	"github.com/CaioDGallo/easy-cli/internal/database"
+	"github.com/CaioDGallo/easy-cli/internal/logger"
+	"github.com/CaioDGallo/easy-cli/internal/resources"
+	"github.com/CaioDGallo/easy-cli/internal/state"
+	"github.com/CaioDGallo/easy-cli/internal/types"
+	"github.com/CaioDGallo/easy-cli/internal/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Commands used to manage the shared Postgres cluster",
+}
+
+var dbTemplateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Commands used to manage the template databases clients are cloned from",
+}
+
+var dbTemplateRefreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Command used to rebuild a template set from a source database",
+	Long:  `This command copies a source database and its hangfire database (<source>-hf) into a template set with pg_dump, without disconnecting the source's users. The new template replaces the existing one only once it is complete, and refuses connections so installs can clone it at any time.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load()
+		if err != nil {
+			logger.Fatalf("Failed to load configuration: %v", err)
+		}
+
+		source := cmd.Flag("source").Value.String()
+		template := cmd.Flag("template").Value.String()
+		if template == "" {
+			template = cfg.Database.Template
+		}
+
+		log := logger.WithFields(logrus.Fields{
+			"command":  "db template refresh",
+			"source":   source,
+			"template": template,
+		})
+
+		log.Info("Refreshing template databases")
+		if err := database.NewPostgresService(cfg.Database).RefreshTemplate(source, template); err != nil {
+			logger.Fatalf("Failed to refresh template: %v", err)
+		}
+		log.Info("Template refreshed successfully")
+	},
+}
+
+// backupPrefix is where backups are kept in a client's bucket when there is no backup bucket.
+// Only public/ is readable by everyone, so backups stay private.
+const backupPrefix = "backups/"
+
+var dbBackupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Command used to back up the databases of a client",
+	Long:  `This command exports the main and hangfire databases of a client with pg_dump into compressed archives, named <database>-<timestamp>.tar.gz. Each archive carries a manifest with the dump's checksum and where it was taken from. Archives are written to --output-dir, or uploaded with --s3 to <client>/<timestamp>/ in S3_BACKUP_BUCKET. Without S3_BACKUP_BUCKET they go to backups/<timestamp>/ in the client's own bucket, which destroy deletes along with them when given --delete-backups.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load()
+		if err != nil {
+			logger.Fatalf("Failed to load configuration: %v", err)
+		}
+
+		clientName := cmd.Flag("client-name").Value.String()
+		outputDir := cmd.Flag("output-dir").Value.String()
+		toS3, _ := cmd.Flags().GetBool("s3")
+		sanitizedClientName := utils.SanitizeClientName(clientName)
+
+		log := logger.WithFields(logrus.Fields{
+			"client":  clientName,
+			"command": "db backup",
+		})
+
+		deploymentState, err := loadBackupState(cfg, clientName, sanitizedClientName)
+		if err != nil {
+			logger.Fatalf("Failed to load deployment state: %v", err)
+		}
+		names := deploymentState.ResourceNames
+
+		bucket, prefix, region := names.S3Bucket, backupPrefix, deploymentState.S3Region
+		if cfg.AWS.BackupBucket != "" {
+			bucket, prefix, region = cfg.AWS.BackupBucket, sanitizedClientName+"/", ""
+		}
+
+		var s3Service *aws.S3Service
+		if toS3 {
+			if cfg.AWS.BackupBucket == "" {
+				log.Warn("S3_BACKUP_BUCKET is not set, so the backups are stored in the client's bucket and are lost if it is destroyed")
+			}
+			s3Service, err = aws.NewS3Service(cfg.AWS.BucketRegion(region), cfg.AWS.AccessKeyID, cfg.AWS.SecretAccessKey, cfg.AWS.S3Endpoint, cfg.AWS.S3UsePathStyle)
+			if err != nil {
+				logger.Fatalf("Failed to create S3 service: %v", err)
+			}
+		} else if err := os.MkdirAll(outputDir, 0755); err != nil {
+			logger.Fatalf("Failed to create output directory: %v", err)
+		}
+
+		postgresService := database.NewPostgresService(cfg.Database)
+		timestamp := time.Now().UTC().Format("20060102T150405Z")
+
+		for _, dbName := range []string{names.DatabaseMain, names.DatabaseHangfire} {
+			source := database.BackupSource{
+				Client:   sanitizedClientName,
+				Database: dbName,
+				Role:     names.DatabaseRole,
+			}
+
+			// Both helpers remove their temporary files before returning, so failing here
+			// leaves nothing behind.
+			var location string
+			if toS3 {
+				key := fmt.Sprintf("%s%s/%s.tar.gz", prefix, timestamp, dbName)
+				err = backupToS3(context.Background(), postgresService, s3Service, source, bucket, key)
+				location = fmt.Sprintf("s3://%s/%s", bucket, key)
+			} else {
+				location = filepath.Join(outputDir, fmt.Sprintf("%s-%s.tar.gz", dbName, timestamp))
+				err = backupToFile(postgresService, source, location)
+			}
+			if err != nil {
+				logger.Fatalf("Failed to back up database %s: %v", dbName, err)
+			}
+
+			log.WithFields(logrus.Fields{
+				"database": dbName,
+				"archive":  location,
+			}).Info("Database backed up")
+		}
+
+		log.Info("Backup completed successfully")
+	},
+}
+
+var dbRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Command used to recreate a database from a backup archive",
+	Long:  `This command recreates a database from an archive written by db backup, after checking the dump against the archive's manifest. The database is restored under its original name unless --database is given, and handed to the role recorded in the archive when that role exists.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load()
+		if err != nil {
+			logger.Fatalf("Failed to load configuration: %v", err)
+		}
+
+		archive := cmd.Flag("archive").Value.String()
+		replace, _ := cmd.Flags().GetBool("replace")
+		opts := database.RestoreOptions{
+			Database: cmd.Flag("database").Value.String(),
+			Role:     cmd.Flag("role").Value.String(),
+			Replace:  replace,
+		}
+
+		log := logger.WithFields(logrus.Fields{
+			"command": "db restore",
+			"archive": archive,
+		})
+
+		region := cmd.Flag("s3-region").Value.String()
+		manifest, err := restoreArchive(context.Background(), cfg, archive, cfg.AWS.BucketRegion(region), opts)
+		if err != nil {
+			logger.Fatalf("Failed to restore database: %v", err)
+		}
+
+		log.WithFields(logrus.Fields{
+			"source":    manifest.Database,
+			"createdAt": manifest.CreatedAt,
+		}).Info("Restore completed successfully")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(dbCmd)
+	dbCmd.AddCommand(dbTemplateCmd)
+	dbTemplateCmd.AddCommand(dbTemplateRefreshCmd)
+	dbCmd.AddCommand(dbBackupCmd)
+	dbCmd.AddCommand(dbRestoreCmd)
+
+	dbTemplateRefreshCmd.Flags().String("source", "", "The database to copy, for example the live demo database")
+	dbTemplateRefreshCmd.MarkFlagRequired("source")
+	dbTemplateRefreshCmd.Flags().String("template", "", "The template set to rebuild, for example template-v42 (defaults to DB_TEMPLATE)")
+
+	dbBackupCmd.Flags().StringP("client-name", "c", "", "The name of the client to back up")
+	dbBackupCmd.MarkFlagRequired("client-name")
+	dbBackupCmd.Flags().String("output-dir", ".", "The directory to write the archives to")
+	dbBackupCmd.Flags().Bool("s3", false, "Upload the archives to S3_BACKUP_BUCKET, or to the client's own bucket when it is not set")
+
+	dbRestoreCmd.Flags().String("archive", "", "The archive to restore, a local path or s3://bucket/key")
+	dbRestoreCmd.MarkFlagRequired("archive")
+	dbRestoreCmd.Flags().String("database", "", "The database to restore into (defaults to the database the archive was taken from)")
+	dbRestoreCmd.Flags().String("role", "", "The role to hand the database to (defaults to the role recorded in the archive)")
+	dbRestoreCmd.Flags().Bool("replace", false, "Drop the database first if it already exists")
+	dbRestoreCmd.Flags().String("s3-region", "", "Region of the bucket an s3:// archive is stored in (defaults to AWS_REGION, where S3_BACKUP_BUCKET is)")
+}
+
+// loadBackupState returns the client's deployment state, or one with derived resource names
+// when there is none.
+func loadBackupState(cfg *config.Config, clientName, sanitizedClientName string) (*types.DeploymentState, error) {
+	derived := resources.GenerateResourceNames(sanitizedClientName, "", cfg)
+
+	deploymentState, err := state.NewStore(cfg.State.Dir).Load(sanitizedClientName)
+	if errors.Is(err, state.ErrNotFound) {
+		return state.New(types.Client{
+			Name:                clientName,
+			SanitizedClientName: sanitizedClientName,
+		}, derived), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if deploymentState.ResourceNames.DatabaseRole == "" {
+		// States written before client database roles existed.
+		deploymentState.ResourceNames.DatabaseRole = derived.DatabaseRole
+	}
+	return deploymentState, nil
+}
+
+func backupToFile(postgresService *database.PostgresService, source database.BackupSource, path string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+
+	if _, err := postgresService.BackupDatabase(source, file); err != nil {
+		file.Close()
+		os.Remove(path)
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	return nil
+}
+
+// restoreArchive restores a local or s3:// archive. A downloaded archive is removed before it
+// returns, including when the restore fails.
+func restoreArchive(ctx context.Context, cfg *config.Config, archive, region string, opts database.RestoreOptions) (*types.BackupManifest, error) {
+	reader, cleanup, err := openBackupArchive(ctx, cfg, archive, region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer cleanup()
+
+	return database.NewPostgresService(cfg.Database).RestoreDatabase(reader, opts)
+}
+
+// backupToS3 writes the archive to a temporary file first, since uploads need a seekable body.
+func backupToS3(ctx context.Context, postgresService *database.PostgresService, s3Service *aws.S3Service, source database.BackupSource, bucket, key string) error {
+	file, err := os.CreateTemp("", "easy-cli-backup-*.tar.gz")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary archive: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	if _, err := postgresService.BackupDatabase(source, file); err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind archive: %w", err)
+	}
+
+	return s3Service.UploadObject(ctx, bucket, key, file)
+}
+
+// openBackupArchive opens a local archive, or downloads one given as s3://bucket/key to a
+// temporary file. The returned function releases it.
+func openBackupArchive(ctx context.Context, cfg *config.Config, archive, region string) (io.Reader, func(), error) {
+	location, isS3 := strings.CutPrefix(archive, "s3://")
+	if !isS3 {
+		file, err := os.Open(archive)
+		if err != nil {
+			return nil, nil, err
+		}
+		return file, func() { file.Close() }, nil
+	}
+
+	bucket, key, ok := strings.Cut(location, "/")
+	if !ok || bucket == "" || key == "" {
+		return nil, nil, fmt.Errorf("invalid S3 location %q, expected s3://bucket/key", archive)
+	}
+
+	s3Service, err := aws.NewS3Service(region, cfg.AWS.AccessKeyID, cfg.AWS.SecretAccessKey, cfg.AWS.S3Endpoint, cfg.AWS.S3UsePathStyle)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create S3 service: %w", err)
+	}
+
+	file, err := os.CreateTemp("", "easy-cli-backup-*.tar.gz")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create temporary archive: %w", err)
+	}
+	cleanup := func() {
+		file.Close()
+		os.Remove(file.Name())
+	}
+
+	if err := s3Service.DownloadObject(ctx, bucket, key, file); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to rewind archive: %w", err)
+	}
+
+	return file, cleanup, nil
+}
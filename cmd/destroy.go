@@ -0,0 +1,299 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/CaioDGallo/easy-cli/internal/aws"
+	"github.com/CaioDGallo/easy-cli/internal/config"
+	"github.com/CaioDGallo/easy-cli/internal/database"
+	"github.com/CaioDGallo/easy-cli/internal/digitalocean"
+	"github.com/CaioDGallo/easy-cli/internal/dns"
+	"github.com/CaioDGallo/easy-cli/internal/logger"
+	"github.com/CaioDGallo/easy-cli/internal/resources"
+	"github.com/CaioDGallo/easy-cli/internal/state"
+	"github.com/CaioDGallo/easy-cli/internal/types"
+	"github.com/CaioDGallo/easy-cli/internal/utils"
+	"github.com/CaioDGallo/easy-cli/internal/vercel"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type destroyOutcome string
+
+const (
+	destroyOutcomeDeleted destroyOutcome = "deleted"
+	destroyOutcomeSkipped destroyOutcome = "skipped"
+	destroyOutcomeFailed  destroyOutcome = "failed"
+)
+
+type destroyResult struct {
+	Resource string
+	Name     string
+	Outcome  destroyOutcome
+	Detail   string
+}
+
+var destroyCmd = &cobra.Command{
+	Use:   "destroy",
+	Short: "Command used to tear down every resource of an existing client",
+	Long:  `This command removes the published DNS records, Vercel project, DigitalOcean app, IAM user, databases, database role and S3 bucket of a client, in reverse dependency order. It refuses to delete a bucket that holds database backups written by db backup --s3 without S3_BACKUP_BUCKET, unless --delete-backups is given.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load()
+		if err != nil {
+			logger.Fatalf("Failed to load configuration: %v", err)
+		}
+
+		clientName := cmd.Flag("client-name").Value.String()
+		keepData, _ := cmd.Flags().GetBool("keep-data")
+		assumeYes, _ := cmd.Flags().GetBool("yes")
+		deleteBackups, _ := cmd.Flags().GetBool("delete-backups")
+		sanitizedClientName := utils.SanitizeClientName(clientName)
+
+		log := logger.WithFields(logrus.Fields{
+			"client":  clientName,
+			"command": "destroy",
+		})
+
+		store := state.NewStore(cfg.State.Dir)
+		deploymentState, err := store.Load(sanitizedClientName)
+		if err != nil {
+			if !errors.Is(err, state.ErrNotFound) {
+				logger.Fatalf("Failed to load deployment state: %v", err)
+			}
+			log.Warn("No deployment state found, falling back to derived resource names")
+			deploymentState = state.New(types.Client{
+				Name:                clientName,
+				SanitizedClientName: sanitizedClientName,
+			}, resources.GenerateResourceNames(sanitizedClientName, "", cfg))
+		}
+		if deploymentState.ResourceNames.DatabaseRole == "" {
+			// States written before client database roles existed.
+			deploymentState.ResourceNames.DatabaseRole = resources.GenerateResourceNames(sanitizedClientName, "", cfg).DatabaseRole
+		}
+
+		if !keepData && !deleteBackups {
+			hasBackups, err := bucketHasBackups(context.Background(), cfg, deploymentState)
+			if err != nil {
+				logger.Fatalf("Failed to check the bucket for backups: %v", err)
+			}
+			if hasBackups {
+				logger.Fatalf("Bucket %s holds database backups under %s, which would be deleted with it. Copy them elsewhere and pass --delete-backups, or keep the data with --keep-data", deploymentState.ResourceNames.S3Bucket, backupPrefix)
+			}
+		}
+
+		if !assumeYes && !confirmDestroy(deploymentState, keepData) {
+			log.Info("Destroy aborted by user")
+			return
+		}
+
+		results := destroyClient(context.Background(), deploymentState, cfg, store, keepData)
+		printDestroyReport(results)
+
+		for _, result := range results {
+			if result.Outcome == destroyOutcomeFailed {
+				logger.Fatalf("Destroy finished with failures")
+			}
+		}
+		log.Info("Destroy completed successfully")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(destroyCmd)
+
+	destroyCmd.Flags().StringP("client-name", "c", "", "The name of the client to destroy")
+	destroyCmd.MarkFlagRequired("client-name")
+	destroyCmd.Flags().Bool("keep-data", false, "Keep the S3 bucket and databases")
+	destroyCmd.Flags().BoolP("yes", "y", false, "Skip the confirmation prompt")
+	destroyCmd.Flags().Bool("delete-backups", false, "Delete the S3 bucket even when it holds database backups")
+}
+
+// bucketHasBackups reports whether db backup --s3 stored archives in the client's bucket.
+func bucketHasBackups(ctx context.Context, cfg *config.Config, deploymentState *types.DeploymentState) (bool, error) {
+	s3Service, err := aws.NewS3Service(cfg.AWS.BucketRegion(deploymentState.S3Region), cfg.AWS.AccessKeyID, cfg.AWS.SecretAccessKey, cfg.AWS.S3Endpoint, cfg.AWS.S3UsePathStyle)
+	if err != nil {
+		return false, fmt.Errorf("failed to create S3 service: %w", err)
+	}
+	return s3Service.HasObjects(ctx, deploymentState.ResourceNames.S3Bucket, backupPrefix)
+}
+
+func confirmDestroy(deploymentState *types.DeploymentState, keepData bool) bool {
+	names := deploymentState.ResourceNames
+
+	fmt.Printf("The following resources of client %q will be destroyed:\n", deploymentState.ClientName)
+	for _, record := range dns.PublishedRecords(deploymentState.DNSRecords) {
+		fmt.Printf("  DNS record:         %s %s %s\n", record.Name, record.Type, record.Value)
+	}
+	fmt.Printf("  Vercel project:     %s\n", names.VercelProject)
+	fmt.Printf("  DigitalOcean app:   %s\n", names.DOApp)
+	if names.IAMUser != "" {
+		fmt.Printf("  IAM user:           %s\n", names.IAMUser)
+	}
+	if !keepData {
+		fmt.Printf("  Databases:          %s, %s\n", names.DatabaseMain, names.DatabaseHangfire)
+		fmt.Printf("  Database role:      %s\n", names.DatabaseRole)
+		fmt.Printf("  S3 bucket:          %s\n", names.S3Bucket)
+	}
+	fmt.Printf("Type %q to confirm: ", deploymentState.SanitizedName)
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	return strings.TrimSpace(answer) == deploymentState.SanitizedName
+}
+
+// destroyClient removes the client's resources in reverse dependency order. A failing
+// resource does not stop the others from being attempted.
+func destroyClient(ctx context.Context, deploymentState *types.DeploymentState, cfg *config.Config, store *state.Store, keepData bool) []destroyResult {
+	log := logger.WithFields(logrus.Fields{
+		"client":         deploymentState.ClientName,
+		"sanitized_name": deploymentState.SanitizedName,
+	})
+
+	names := deploymentState.ResourceNames
+	var results []destroyResult
+
+	record := func(step types.StepName, result destroyResult) {
+		results = append(results, result)
+		if result.Outcome == destroyOutcomeDeleted {
+			deploymentState.Steps[step] = types.StepState{Status: types.StepStatusDestroyed}
+		}
+	}
+
+	if publishedRecords := dns.PublishedRecords(deploymentState.DNSRecords); len(publishedRecords) > 0 {
+		recordNames := make([]string, len(publishedRecords))
+		for i, published := range publishedRecords {
+			recordNames[i] = published.Name
+		}
+		recordList := strings.Join(recordNames, ", ")
+
+		dnsProvider, err := dns.NewProvider(cfg)
+		if err == nil && dnsProvider == nil {
+			err = fmt.Errorf("the records were published by a DNS provider, but DNS_PROVIDER is not set")
+		}
+		if err == nil {
+			err = dnsProvider.DeleteRecords(ctx, publishedRecords)
+		}
+		if err != nil {
+			log.WithError(err).Error("Failed to delete DNS records")
+			record(types.StepDomains, destroyResult{"DNS records", recordList, destroyOutcomeFailed, err.Error()})
+		} else {
+			deploymentState.DNSRecords = nil
+			record(types.StepDomains, destroyResult{"DNS records", recordList, destroyOutcomeDeleted, ""})
+		}
+	}
+
+	vercelService := vercel.NewProjectService(cfg.Vercel)
+	vercelProject := names.VercelProject
+	if deploymentState.VercelProjectID != "" {
+		vercelProject = deploymentState.VercelProjectID
+	}
+	if err := vercelService.DeleteProject(ctx, vercelProject); err != nil {
+		log.WithError(err).Error("Failed to delete Vercel project")
+		record(types.StepVercelProject, destroyResult{"Vercel project", names.VercelProject, destroyOutcomeFailed, err.Error()})
+	} else {
+		record(types.StepVercelProject, destroyResult{"Vercel project", names.VercelProject, destroyOutcomeDeleted, ""})
+	}
+
+	doService := digitalocean.NewAppService(cfg.DO.Token)
+	var doErr error
+	if deploymentState.DOAppID != "" {
+		doErr = doService.DeleteAppByID(ctx, deploymentState.DOAppID)
+	} else {
+		doErr = doService.DeleteApp(ctx, deploymentState.SanitizedName, cfg)
+	}
+	if doErr != nil {
+		log.WithError(doErr).Error("Failed to delete DigitalOcean app")
+		record(types.StepDOApp, destroyResult{"DigitalOcean app", names.DOApp, destroyOutcomeFailed, doErr.Error()})
+	} else {
+		record(types.StepDOApp, destroyResult{"DigitalOcean app", names.DOApp, destroyOutcomeDeleted, ""})
+	}
+
+	// The bucket credentials go with the app that used them, even when the bucket is kept.
+	if names.IAMUser != "" {
+		iamService, err := aws.NewIAMService(cfg.AWS.AccessKeyID, cfg.AWS.SecretAccessKey, cfg.AWS.IAMEndpoint)
+		if err == nil {
+			err = iamService.DeleteBucketUser(ctx, names.IAMUser)
+		}
+		if err != nil {
+			log.WithError(err).Error("Failed to delete IAM user")
+			record(types.StepS3Credentials, destroyResult{"IAM user", names.IAMUser, destroyOutcomeFailed, err.Error()})
+		} else {
+			deploymentState.Secrets.S3AccessKeyID = ""
+			deploymentState.Secrets.S3SecretAccessKey = ""
+			record(types.StepS3Credentials, destroyResult{"IAM user", names.IAMUser, destroyOutcomeDeleted, ""})
+		}
+	}
+
+	if keepData {
+		results = append(results,
+			destroyResult{"Databases", names.DatabaseMain + ", " + names.DatabaseHangfire, destroyOutcomeSkipped, "--keep-data"},
+			destroyResult{"Database role", names.DatabaseRole, destroyOutcomeSkipped, "--keep-data"},
+			destroyResult{"S3 bucket", names.S3Bucket, destroyOutcomeSkipped, "--keep-data"},
+		)
+	} else {
+		dbService := database.NewPostgresService(cfg.Database)
+		if err := dbService.DeleteClientDatabases(names.DatabaseMain, names.DatabaseHangfire); err != nil {
+			log.WithError(err).Error("Failed to delete databases")
+			record(types.StepDatabases, destroyResult{"Databases", names.DatabaseMain + ", " + names.DatabaseHangfire, destroyOutcomeFailed, err.Error()})
+			results = append(results, destroyResult{"Database role", names.DatabaseRole, destroyOutcomeSkipped, "databases were not deleted"})
+		} else {
+			record(types.StepDatabases, destroyResult{"Databases", names.DatabaseMain + ", " + names.DatabaseHangfire, destroyOutcomeDeleted, ""})
+
+			if err := dbService.DropClientRole(names.DatabaseRole); err != nil {
+				log.WithError(err).Error("Failed to drop database role")
+				record(types.StepDatabaseRole, destroyResult{"Database role", names.DatabaseRole, destroyOutcomeFailed, err.Error()})
+			} else {
+				record(types.StepDatabaseRole, destroyResult{"Database role", names.DatabaseRole, destroyOutcomeDeleted, ""})
+			}
+		}
+
+		s3Service, err := aws.NewS3Service(cfg.AWS.BucketRegion(deploymentState.S3Region), cfg.AWS.AccessKeyID, cfg.AWS.SecretAccessKey, cfg.AWS.S3Endpoint, cfg.AWS.S3UsePathStyle)
+		if err == nil {
+			err = s3Service.DeleteBucket(ctx, names.S3Bucket)
+		}
+		if err != nil {
+			log.WithError(err).Error("Failed to delete S3 bucket")
+			record(types.StepS3Bucket, destroyResult{"S3 bucket", names.S3Bucket, destroyOutcomeFailed, err.Error()})
+		} else {
+			record(types.StepS3Bucket, destroyResult{"S3 bucket", names.S3Bucket, destroyOutcomeDeleted, ""})
+		}
+	}
+
+	failed := false
+	for _, result := range results {
+		if result.Outcome == destroyOutcomeFailed {
+			failed = true
+			break
+		}
+	}
+
+	// Only forget the client once nothing is left behind; otherwise keep the state so the
+	// remaining resources can still be found.
+	if !failed && !keepData {
+		if err := store.Delete(deploymentState.SanitizedName); err != nil {
+			log.WithError(err).Warn("Failed to delete deployment state")
+		}
+	} else if err := store.Save(deploymentState); err != nil {
+		log.WithError(err).Warn("Failed to persist deployment state")
+	}
+
+	return results
+}
+
+func printDestroyReport(results []destroyResult) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RESOURCE\tNAME\tOUTCOME\tDETAIL")
+	for _, result := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", result.Resource, result.Name, result.Outcome, result.Detail)
+	}
+	w.Flush()
+}
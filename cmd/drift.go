@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/CaioDGallo/easy-cli/internal/config"
+	"github.com/CaioDGallo/easy-cli/internal/digitalocean"
+	"github.com/CaioDGallo/easy-cli/internal/drift"
+	"github.com/CaioDGallo/easy-cli/internal/envvars"
+	"github.com/CaioDGallo/easy-cli/internal/logger"
+	"github.com/CaioDGallo/easy-cli/internal/manifest"
+	"github.com/CaioDGallo/easy-cli/internal/state"
+	"github.com/CaioDGallo/easy-cli/internal/types"
+	"github.com/CaioDGallo/easy-cli/internal/utils"
+	"github.com/CaioDGallo/easy-cli/internal/vercel"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var driftCmd = &cobra.Command{
+	Use:   "drift",
+	Short: "Command used to compare a client's live environment variables with the generated ones",
+	Long:  `This command fetches the environment variables of a client's DigitalOcean app and Vercel project, diffs them against the set fresh-install generates and can reconcile them with --fix.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load()
+		if err != nil {
+			logger.Fatalf("Failed to load configuration: %v", err)
+		}
+
+		fix, _ := cmd.Flags().GetBool("fix")
+		prune, _ := cmd.Flags().GetBool("prune")
+		output := cmd.Flag("output").Value.String()
+
+		if output != "table" && output != "json" {
+			logger.Fatalf("Unsupported output format %q (expected table or json)", output)
+		}
+		if output == "json" {
+			// Keep stdout clean for the JSON document.
+			logger.SetOutput(os.Stderr)
+		}
+		if prune && !fix {
+			logger.Fatalf("--prune can only be used together with --fix")
+		}
+
+		var clientManifest *manifest.Manifest
+		if manifestPath := cmd.Flag("file").Value.String(); manifestPath != "" {
+			clientManifest, err = manifest.Load(manifestPath)
+			if err != nil {
+				logger.Fatalf("Failed to load client manifest: %v", err)
+			}
+			applyManifestProviders(cfg, clientManifest)
+		}
+
+		client := resolveClient(cfg, clientManifest, manifestWithDefaults())
+		if clientName := cmd.Flag("client-name").Value.String(); clientName != "" {
+			client.Name = clientName
+			client.SanitizedClientName = utils.SanitizeClientName(clientName)
+		}
+		if client.Name == "" {
+			logger.Fatalf("A client name is required, set it with --client-name or in the manifest")
+		}
+
+		log := logger.WithFields(logrus.Fields{
+			"client":  client.Name,
+			"command": "drift",
+		})
+
+		store := state.NewStore(cfg.State.Dir)
+		deploymentState, err := store.Load(client.SanitizedClientName)
+		if err != nil {
+			logger.Fatalf("Failed to load deployment state: %v", err)
+		}
+
+		if clientManifest == nil {
+			if deploymentState.Inputs == nil {
+				// Without the settings of the install, SMTP and extra variables would be reset to
+				// the configured defaults.
+				if fix {
+					logger.Fatalf("Client %s was installed before its settings were recorded, pass the manifest it was installed with (-f) to use --fix", client.Name)
+				}
+				log.Warn("Client settings were not recorded at install, comparing against the configured defaults")
+			} else {
+				applyClientInputs(&client, deploymentState.Inputs)
+			}
+		}
+
+		expected, err := expectedEnvironment(client, deploymentState, cfg)
+		if err != nil {
+			logger.Fatalf("Failed to generate expected environment: %v", err)
+		}
+
+		ctx := context.Background()
+		doService := digitalocean.NewAppService(cfg.DO.Token)
+		vercelService := vercel.NewProjectService(cfg.Vercel)
+		detector := drift.NewDetector(doService, vercelService)
+
+		log.Info("Detecting environment drift")
+		report, err := detector.Detect(ctx, deploymentState, expected)
+		if err != nil {
+			logger.Fatalf("Drift detection failed: %v", err)
+		}
+
+		if output == "json" {
+			err = report.WriteJSON(os.Stdout)
+		} else {
+			err = report.WriteTable(os.Stdout)
+		}
+		if err != nil {
+			logger.Fatalf("Failed to write drift report: %v", err)
+		}
+
+		if !report.HasDrift() {
+			return
+		}
+
+		if !fix {
+			logger.Fatalf("Client %s has drifted from its generated environment, re-run with --fix to reconcile it", client.Name)
+		}
+
+		log.WithField("prune", prune).Info("Reconciling environment variables")
+		result, err := detector.Fix(ctx, report, prune)
+		if err != nil {
+			logger.Fatalf("Failed to reconcile environment variables: %v", err)
+		}
+
+		if result.FrontendUpdated {
+			// Vercel only applies environment changes to new deployments.
+			log.Info("Redeploying Vercel project to apply the reconciled variables")
+			if err := vercelService.CreateDeployment(ctx, client, cfg); err != nil {
+				logger.Fatalf("Failed to create Vercel deployment: %v", err)
+			}
+		}
+
+		log.WithFields(logrus.Fields{
+			"backend_updated":  result.BackendUpdated,
+			"frontend_updated": result.FrontendUpdated,
+		}).Info("Environment drift reconciled")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(driftCmd)
+
+	driftCmd.Flags().StringP("client-name", "c", "", "The name of the client to check")
+	driftCmd.Flags().StringP("file", "f", "", "Path to the client manifest used to install the client")
+	driftCmd.Flags().Bool("fix", false, "Write missing and changed variables back to DigitalOcean and Vercel")
+	driftCmd.Flags().Bool("prune", false, "With --fix, also remove variables that are not generated by easy-cli")
+	driftCmd.Flags().StringP("output", "o", "table", "Output format (table or json)")
+}
+
+// applyClientInputs restores the settings recorded at install. The SMTP password is not
+// recorded, so the configured one is kept.
+func applyClientInputs(client *types.Client, inputs *types.ClientInputs) {
+	password := client.SMTPInfo.Password
+	client.SMTPInfo = inputs.SMTP
+	client.SMTPInfo.Password = password
+	client.ExtraEnv = inputs.ExtraEnv
+	client.App = inputs.App
+}
+
+// expectedEnvironment regenerates the client's environment with the secrets, branches and URLs
+// recorded in its deployment state.
+func expectedEnvironment(client types.Client, deploymentState *types.DeploymentState, cfg *config.Config) (drift.Expected, error) {
+	client.Secrets = deploymentState.Secrets
+	client.BackendBranch = deploymentState.BackendBranch
+	client.FrontendBranch = deploymentState.FrontendBranch
+	client.BackendInfo.URL = deploymentState.BackendURL
+	client.FrontendInfo.URL = deploymentState.FrontendURL
+	client.Domain = deploymentState.Domain
+	client.S3Region = deploymentState.S3Region
+	client.Labels = deploymentState.Labels
+
+	deploymentEnv, err := envvars.GenerateDeploymentEnvironment(client, cfg)
+	if err != nil {
+		return drift.Expected{}, err
+	}
+
+	frontendEnvVars, err := envvars.GenerateVercelEnvironmentVariables(client, cfg)
+	if err != nil {
+		return drift.Expected{}, err
+	}
+
+	return drift.Expected{
+		Backend: types.DigitalOceanEnvVars{
+			AppEnvs:       deploymentEnv.Backend.AppLevelVars,
+			ComponentEnvs: deploymentEnv.Backend.ComponentLevelVars,
+		},
+		Frontend: frontendEnvVars,
+	}, nil
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/CaioDGallo/easy-cli/internal/types"
+)
+
+func TestClientInputsRoundTrip(t *testing.T) {
+	installed := types.Client{
+		SMTPInfo: types.SMTPInfo{
+			Server:          "smtp.acme.com",
+			Port:            "465",
+			Username:        "mailer",
+			Password:        "install-password",
+			DoNotReplyName:  "Acme",
+			DoNotReplyEmail: "noreply@acme.com",
+			DevEmail:        "dev@acme.com",
+		},
+		ExtraEnv: types.ExtraEnv{Backend: map[string]string{"FEATURE_X": "on"}},
+		App:      types.AppSpecOptions{Tier: "large"},
+	}
+
+	deploymentState := &types.DeploymentState{Steps: map[types.StepName]types.StepState{}}
+	recordClientInputs(installed, deploymentState)
+	if deploymentState.Inputs.SMTP.Password != "" {
+		t.Fatalf("recorded SMTP password %q, want none", deploymentState.Inputs.SMTP.Password)
+	}
+
+	client := types.Client{SMTPInfo: types.SMTPInfo{Server: "default", Password: "configured-password"}}
+	applyClientInputs(&client, deploymentState.Inputs)
+
+	want := installed.SMTPInfo
+	want.Password = "configured-password"
+	if client.SMTPInfo != want {
+		t.Errorf("SMTP = %+v, want %+v", client.SMTPInfo, want)
+	}
+	if !reflect.DeepEqual(client.ExtraEnv, installed.ExtraEnv) {
+		t.Errorf("extra env = %+v, want %+v", client.ExtraEnv, installed.ExtraEnv)
+	}
+	if client.App != installed.App {
+		t.Errorf("app = %+v, want %+v", client.App, installed.App)
+	}
+}
+
+func TestRecordClientInputsKeepsPushedSettings(t *testing.T) {
+	recorded := &types.ClientInputs{SMTP: types.SMTPInfo{Server: "smtp.acme.com"}}
+	deploymentState := &types.DeploymentState{
+		Inputs: recorded,
+		Steps: map[types.StepName]types.StepState{
+			types.StepDOEnv:     {Status: types.StepStatusCompleted},
+			types.StepVercelEnv: {Status: types.StepStatusCompleted},
+		},
+	}
+
+	recordClientInputs(types.Client{SMTPInfo: types.SMTPInfo{Server: "default"}}, deploymentState)
+	if deploymentState.Inputs != recorded {
+		t.Errorf("inputs were replaced after the environment was pushed")
+	}
+}
@@ -2,19 +2,34 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/CaioDGallo/easy-cli/internal/aws"
 	"github.com/CaioDGallo/easy-cli/internal/config"
 	"github.com/CaioDGallo/easy-cli/internal/database"
 	"github.com/CaioDGallo/easy-cli/internal/digitalocean"
+	"github.com/CaioDGallo/easy-cli/internal/dns"
+	"github.com/CaioDGallo/easy-cli/internal/engine"
 	"github.com/CaioDGallo/easy-cli/internal/envvars"
+	"github.com/CaioDGallo/easy-cli/internal/interfaces"
 	"github.com/CaioDGallo/easy-cli/internal/logger"
+	"github.com/CaioDGallo/easy-cli/internal/manifest"
+	"github.com/CaioDGallo/easy-cli/internal/plan"
+	"github.com/CaioDGallo/easy-cli/internal/resources"
+	"github.com/CaioDGallo/easy-cli/internal/retry"
 	"github.com/CaioDGallo/easy-cli/internal/rollback"
+	"github.com/CaioDGallo/easy-cli/internal/secrets"
+	"github.com/CaioDGallo/easy-cli/internal/state"
 	"github.com/CaioDGallo/easy-cli/internal/types"
 	"github.com/CaioDGallo/easy-cli/internal/utils"
 	"github.com/CaioDGallo/easy-cli/internal/validation"
 	"github.com/CaioDGallo/easy-cli/internal/vercel"
+	"github.com/digitalocean/godo"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -29,55 +44,51 @@ var freshInstallCmd = &cobra.Command{
 			logger.Fatalf("Failed to load configuration: %v", err)
 		}
 
-		clientName := cmd.Flag("client-name").Value.String()
-		smtpServer := cmd.Flag("smtp-server").Value.String()
-		smtpPort := cmd.Flag("smtp-port").Value.String()
-		smtpUsername := cmd.Flag("smtp-username").Value.String()
-		smtpPassword := cmd.Flag("smtp-password").Value.String()
-		smtpDoNotReplyName := cmd.Flag("smtp-donotreplyname").Value.String()
-		smtpDoNotReplyEmail := cmd.Flag("smtp-donotreplyemail").Value.String()
-		smtpDevEmail := cmd.Flag("smtp-devemail").Value.String()
-		backendBranch := cmd.Flag("backend-branch").Value.String()
-		frontendBranch := cmd.Flag("frontend-branch").Value.String()
+		resume, _ := cmd.Flags().GetBool("resume")
+		domainTimeout, _ := cmd.Flags().GetDuration("domain-timeout")
+		planOnly, _ := cmd.Flags().GetBool("plan")
+		output := cmd.Flag("output").Value.String()
+
+		var clientManifest *manifest.Manifest
+		if manifestPath := cmd.Flag("file").Value.String(); manifestPath != "" {
+			clientManifest, err = manifest.Load(manifestPath)
+			if err != nil {
+				logger.Fatalf("Failed to load client manifest: %v", err)
+			}
+			applyManifestProviders(cfg, clientManifest)
+		}
+
+		client := resolveClient(cfg, clientManifest, flagOverrides(cmd))
+		if client.Name == "" {
+			logger.Fatalf("A client name is required, set it with --client-name or in the manifest")
+		}
 
 		log := logger.WithFields(logrus.Fields{
-			"client":  clientName,
+			"client":  client.Name,
 			"command": "fresh-install",
 		})
 
-		client := types.Client{
-			Name:                clientName,
-			SanitizedClientName: utils.SanitizeClientName(clientName),
-			DatabaseHost:        cfg.Database.Host,
-			DatabaseUser:        cfg.Database.User,
-			BackendBranch:       backendBranch,
-			FrontendBranch:      frontendBranch,
-			SMTPInfo: types.SMTPInfo{
-				Server:          smtpServer,
-				Username:        smtpUsername,
-				Port:            smtpPort,
-				Password:        smtpPassword,
-				DoNotReplyName:  smtpDoNotReplyName,
-				DoNotReplyEmail: smtpDoNotReplyEmail,
-				DevEmail:        smtpDevEmail,
-			},
-			BackendInfo: types.BackendInfo{
-				URL:              "",
-				DatabasePassword: cfg.Database.Password,
-			},
-			FrontendInfo: types.FrontendInfo{
-				URL: "",
-			},
-		}
-
 		log.Info("Validating client configuration")
 		if err := validation.ValidateClient(client); err != nil {
 			log.WithError(err).Error("Client validation failed")
 			logger.Fatalf("Client validation failed: %v", err)
 		}
 
+		store := state.NewStore(cfg.State.Dir)
+
+		if planOnly {
+			if output == "json" {
+				// Keep stdout clean for the JSON document.
+				logger.SetOutput(os.Stderr)
+			}
+			if err := printPlan(client, cfg, store, output); err != nil {
+				logger.Fatalf("Plan failed: %v", err)
+			}
+			return
+		}
+
 		log.Info("Starting fresh install process")
-		if err := freshInstall(client, cfg); err != nil {
+		if _, err := freshInstall(client, cfg, store, freshInstallOptions{Resume: resume, DomainTimeout: domainTimeout}); err != nil {
 			log.WithError(err).Error("Fresh install failed")
 			logger.Fatalf("Fresh install failed: %v", err)
 		}
@@ -88,8 +99,10 @@ var freshInstallCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(freshInstallCmd)
 
+	freshInstallCmd.Flags().StringP("file", "f", "", "Path to a client manifest file; flags that are set explicitly override its values")
 	freshInstallCmd.Flags().StringP("client-name", "c", "", "The name of the client for this setup")
-	freshInstallCmd.MarkFlagRequired("client-name")
+	freshInstallCmd.Flags().String("domain", "", "Custom domain of the client; the frontend is served on it and on www, the backend on its api subdomain")
+	freshInstallCmd.Flags().Duration("domain-timeout", defaultDomainTimeout, "How long to wait for the custom domain's DNS records to be verified")
 
 	// Load config to get SMTP defaults
 	cfg, _ := config.Load()
@@ -114,170 +127,1139 @@ func init() {
 	freshInstallCmd.Flags().StringP("smtp-devemail", "e", smtpDevEmail, "The SMTP devemail for the client of this setup")
 	
 	freshInstallCmd.Flags().StringP("backend-branch", "b", "master", "The git branch to use for the backend deployment")
-	freshInstallCmd.Flags().StringP("frontend-branch", "f", "master", "The git branch to use for the frontend deployment")
+	freshInstallCmd.Flags().String("frontend-branch", "master", "The git branch to use for the frontend deployment")
+	freshInstallCmd.Flags().String("tier", "", "DigitalOcean size tier (small, medium or large)")
+	freshInstallCmd.Flags().String("region", "", "DigitalOcean App Platform region")
+	freshInstallCmd.Flags().String("instance-size", "", "DigitalOcean instance size slug, overrides the tier")
+	freshInstallCmd.Flags().Int64("instance-count", 0, "Number of DigitalOcean instances, overrides the tier")
+	freshInstallCmd.Flags().Int64("http-port", 0, "HTTP port the backend listens on")
+	freshInstallCmd.Flags().String("source-dir", "", "Directory of the backend repository to build from")
+	freshInstallCmd.Flags().String("dockerfile-path", "", "Path of the backend Dockerfile")
+	freshInstallCmd.Flags().String("db-template", "", "Template set the client databases are cloned from (defaults to DB_TEMPLATE)")
+	freshInstallCmd.Flags().String("s3-region", "", "Region of the client's S3 bucket (defaults to AWS_REGION)")
+	freshInstallCmd.Flags().String("s3-seed", "", "Directory or s3://bucket/prefix the client's S3 bucket is seeded from, or none (defaults to S3_SEED_SOURCE)")
+	freshInstallCmd.Flags().Bool("resume", false, "Continue a previous install from its first incomplete step")
+	freshInstallCmd.Flags().Bool("plan", false, "Print what would be created and run read-only preflight checks, without changing anything")
+	freshInstallCmd.Flags().StringP("output", "o", "text", "Plan output format (text or json)")
+}
+
+// clientOption picks the value of a client parameter given its fresh-install flag name and the
+// value found in the manifest.
+type clientOption func(flagName, manifestValue string) string
+
+// flagOverrides lets flags that were set explicitly override the manifest; otherwise the flag
+// default fills the gaps.
+func flagOverrides(cmd *cobra.Command) clientOption {
+	return func(flagName, manifestValue string) string {
+		flag := cmd.Flag(flagName)
+		if flag.Changed || manifestValue == "" {
+			return flag.Value.String()
+		}
+		return manifestValue
+	}
+}
+
+// manifestWithDefaults uses the manifest value and falls back to the fresh-install flag default.
+func manifestWithDefaults() clientOption {
+	return func(flagName, manifestValue string) string {
+		if manifestValue != "" {
+			return manifestValue
+		}
+		return freshInstallCmd.Flag(flagName).DefValue
+	}
+}
+
+// resolveClient builds the client from the manifest, if any, resolving each parameter through option.
+func resolveClient(cfg *config.Config, clientManifest *manifest.Manifest, option clientOption) types.Client {
+	if clientManifest == nil {
+		clientManifest = &manifest.Manifest{}
+	}
+
+	clientName := option("client-name", clientManifest.Client.Name)
+
+	return types.Client{
+		Name:                clientName,
+		SanitizedClientName: utils.SanitizeClientName(clientName),
+		Domain:              option("domain", clientManifest.Client.Domain),
+		DatabaseHost:        cfg.Database.Host,
+		DatabasePort:        cfg.Database.Port,
+		DatabaseSSLMode:     cfg.Database.SSLMode,
+		DatabaseTemplate:    option("db-template", clientManifest.Database.Template),
+		S3Region:            option("s3-region", clientManifest.S3.Region),
+		S3Seed:              option("s3-seed", clientManifest.S3.Seed),
+		BackendBranch:       option("backend-branch", clientManifest.Branches.Backend),
+		FrontendBranch:      option("frontend-branch", clientManifest.Branches.Frontend),
+		SMTPInfo: types.SMTPInfo{
+			Server:          option("smtp-server", clientManifest.SMTP.Server),
+			Username:        option("smtp-username", clientManifest.SMTP.Username),
+			Port:            option("smtp-port", clientManifest.SMTP.Port),
+			Password:        option("smtp-password", clientManifest.SMTPPassword()),
+			DoNotReplyName:  option("smtp-donotreplyname", clientManifest.SMTP.DoNotReplyName),
+			DoNotReplyEmail: option("smtp-donotreplyemail", clientManifest.SMTP.DoNotReplyEmail),
+			DevEmail:        option("smtp-devemail", clientManifest.SMTP.DevEmail),
+		},
+		BackendInfo: types.BackendInfo{
+			URL: "",
+		},
+		FrontendInfo: types.FrontendInfo{
+			URL: "",
+		},
+		ExtraEnv: types.ExtraEnv{
+			Backend:  clientManifest.Env.Backend,
+			Frontend: clientManifest.Env.Frontend,
+		},
+		App: types.AppSpecOptions{
+			Tier:             option("tier", clientManifest.DigitalOcean.Tier),
+			Region:           option("region", clientManifest.DigitalOcean.Region),
+			InstanceSizeSlug: option("instance-size", clientManifest.DigitalOcean.InstanceSize),
+			InstanceCount:    int64Option(option, "instance-count", clientManifest.DigitalOcean.InstanceCount),
+			HTTPPort:         int64Option(option, "http-port", clientManifest.DigitalOcean.HTTPPort),
+			SourceDir:        option("source-dir", clientManifest.DigitalOcean.SourceDir),
+			DockerfilePath:   option("dockerfile-path", clientManifest.DigitalOcean.DockerfilePath),
+		},
+	}
+}
+
+// int64Option resolves a numeric parameter, where zero means unset.
+func int64Option(option clientOption, flagName string, manifestValue int64) int64 {
+	value := ""
+	if manifestValue != 0 {
+		value = strconv.FormatInt(manifestValue, 10)
+	}
+
+	parsed, _ := strconv.ParseInt(option(flagName, value), 10, 64)
+	return parsed
+}
+
+func applyManifestProviders(cfg *config.Config, clientManifest *manifest.Manifest) {
+	cfg.Repository.Backend = applyRepositorySpec(cfg.Repository.Backend, clientManifest.Providers.Repositories.Backend)
+	cfg.Repository.Frontend = applyRepositorySpec(cfg.Repository.Frontend, clientManifest.Providers.Repositories.Frontend)
+}
+
+// applyRepositorySpec overrides the configured repository with the fields the manifest sets. The
+// configured ID belongs to the configured provider, so it is dropped when the provider changes.
+func applyRepositorySpec(repo types.Repository, spec manifest.RepositorySpec) types.Repository {
+	if spec.Provider != "" && types.GitProvider(spec.Provider) != repo.Provider {
+		repo.Provider = types.GitProvider(spec.Provider)
+		repo.ID = ""
+	}
+	if spec.Name != "" {
+		repo.Name = spec.Name
+	}
+	if spec.ID != "" {
+		repo.ID = spec.ID
+	}
+	return repo
+}
+
+func printPlan(client types.Client, cfg *config.Config, store *state.Store, output string) error {
+	if output != "text" && output != "json" {
+		return fmt.Errorf("unsupported output format %q (expected text or json)", output)
+	}
+
+	// The plan only shows masked values, so throwaway secrets are enough to render it.
+	clientSecrets, err := secrets.Generate()
+	if err != nil {
+		return fmt.Errorf("failed to generate client secrets: %w", err)
+	}
+	client.Secrets = clientSecrets
+	if cfg.AWS.ClientCredentials {
+		// The access key is issued during the install; a placeholder shows where it goes.
+		client.Secrets.S3AccessKeyID = "pending"
+		client.Secrets.S3SecretAccessKey = "pending"
+	}
+	if client.DatabaseTemplate == "" {
+		client.DatabaseTemplate = cfg.Database.Template
+	}
+	if err := database.ValidateTemplateName(client.DatabaseTemplate); err != nil {
+		return err
+	}
+	client.S3Region = cfg.AWS.BucketRegion(client.S3Region)
+	if err := config.ValidateRegion(client.S3Region); err != nil {
+		return err
+	}
+	if client.S3Seed == "" {
+		client.S3Seed = cfg.AWS.SeedSource
+	}
+
+	installPlan, err := plan.Build(client, cfg)
+	if err != nil {
+		return err
+	}
+
+	installPlan.RunPreflight(context.Background(), cfg, store)
+
+	if output == "json" {
+		err = installPlan.WriteJSON(os.Stdout)
+	} else {
+		err = installPlan.WriteText(os.Stdout)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write plan: %w", err)
+	}
+
+	if installPlan.HasConflicts() {
+		return fmt.Errorf("preflight checks found conflicts")
+	}
+
+	return nil
+}
+
+type freshInstallOptions struct {
+	Resume        bool
+	DomainTimeout time.Duration
+}
+
+const (
+	defaultDomainTimeout = 30 * time.Minute
+	domainPollInterval   = 30 * time.Second
+)
+
+// Keys of the values install steps hand to each other.
+const (
+	valueDOAppID         = "do_app_id"
+	valueBackendURL      = "backend_url"
+	valueVercelProjectID = "vercel_project_id"
+	valueFrontendURL     = "frontend_url"
+	// The client's bucket credentials, stored with the other client secrets.
+	valueS3AccessKeyID     = "s3_access_key_id"
+	valueS3SecretAccessKey = "s3_secret_access_key"
+)
+
+type installServices struct {
+	s3     *aws.S3Service
+	db     *database.PostgresService
+	do     *digitalocean.AppService
+	vercel *vercel.ProjectService
+	// dns is nil when DNS records are created by hand.
+	dns interfaces.DNSProvider
+	// iam is nil when clients share our credentials.
+	iam *aws.IAMService
 }
 
-func freshInstall(client types.Client, cfg *config.Config) error {
+func freshInstall(client types.Client, cfg *config.Config, store *state.Store, opts freshInstallOptions) (*types.DeploymentState, error) {
 	ctx := context.Background()
 
 	log := logger.WithFields(logrus.Fields{
 		"client":         client.Name,
 		"sanitized_name": client.SanitizedClientName,
+		"resume":         opts.Resume,
 	})
 
-	rollbackMgr := rollback.NewManager()
+	resourceNames := resources.GenerateResourceNames(client.SanitizedClientName, client.S3Region, cfg)
+	if err := resources.ValidateResourceNames(resourceNames); err != nil {
+		log.WithError(err).Error("Invalid resource names")
+		return nil, fmt.Errorf("invalid resource names: %w", err)
+	}
+
+	appSpec, err := resources.ResolveAppSpec(cfg, client.App)
+	if err != nil {
+		log.WithError(err).Error("Invalid DigitalOcean app settings")
+		return nil, err
+	}
+
+	if err := cfg.Repository.Validate(); err != nil {
+		log.WithError(err).Error("Invalid repository configuration")
+		return nil, err
+	}
+
+	deploymentState, err := prepareDeploymentState(client, resourceNames, store, opts.Resume)
+	if err != nil {
+		log.WithError(err).Error("Failed to prepare deployment state")
+		return deploymentState, err
+	}
+	client.Secrets = deploymentState.Secrets
+	if deploymentState.ResourceNames.IAMUser == "" {
+		// States written before client credentials existed.
+		deploymentState.ResourceNames.IAMUser = resourceNames.IAMUser
+	}
+	if deploymentState.Labels.IsZero() {
+		// Labels are fixed when a client is first installed. States written before resources
+		// were labeled get them on resume, for the steps still to run.
+		deploymentState.Labels = resources.LabelsFor(client, cfg)
+	}
+	client.Labels = deploymentState.Labels
+	recordClientInputs(client, deploymentState)
+
+	if err := resolveInstalledDomain(&client, deploymentState); err != nil {
+		log.WithError(err).Error("Custom domain does not match deployment state")
+		return deploymentState, err
+	}
+
+	if err := resolveDatabaseTemplate(&client, deploymentState, cfg); err != nil {
+		log.WithError(err).Error("Invalid database template")
+		return deploymentState, err
+	}
+
+	if err := resolveS3Region(&client, deploymentState, cfg); err != nil {
+		log.WithError(err).Error("Invalid S3 region")
+		return deploymentState, err
+	}
+
+	seed, err := resolveS3Seed(&client, deploymentState, cfg)
+	if err != nil {
+		log.WithError(err).Error("Invalid S3 seed source")
+		return deploymentState, err
+	}
 
 	log.Info("Generating deployment environment")
 	deploymentEnv, err := envvars.GenerateDeploymentEnvironment(client, cfg)
 	if err != nil {
 		log.WithError(err).Error("Failed to generate deployment environment")
-		return fmt.Errorf("failed to generate deployment environment: %w", err)
+		return deploymentState, fmt.Errorf("failed to generate deployment environment: %w", err)
 	}
 
-	bucketName := deploymentEnv.ResourceNames.S3Bucket
-
 	log.Info("Creating S3 service")
-	s3Service, err := aws.NewS3Service(cfg.AWS.Region, cfg.AWS.AccessKeyID, cfg.AWS.SecretAccessKey)
+	s3Service, err := aws.NewS3Service(client.S3Region, cfg.AWS.AccessKeyID, cfg.AWS.SecretAccessKey, cfg.AWS.S3Endpoint, cfg.AWS.S3UsePathStyle)
 	if err != nil {
 		log.WithError(err).Error("Failed to create S3 service")
-		return fmt.Errorf("failed to create S3 service: %w", err)
+		return deploymentState, fmt.Errorf("failed to create S3 service: %w", err)
 	}
 
-	if err := s3Service.CreateBucket(ctx, bucketName); err != nil {
-		log.WithError(err).Error("Failed to setup AWS S3")
-		return fmt.Errorf("failed to setup AWS S3: %w", err)
-	}
-	rollbackMgr.AddAction("S3 bucket cleanup", func(ctx context.Context) error {
-		log.Info("Rolling back S3 bucket creation")
-		if err := s3Service.DeleteBucket(ctx, bucketName); err != nil {
-			log.WithError(err).Error("Failed to rollback S3 bucket")
-			return fmt.Errorf("failed to delete S3 bucket during rollback: %w", err)
+	var iamService *aws.IAMService
+	if cfg.AWS.ClientCredentials {
+		log.Info("Creating IAM service")
+		iamService, err = aws.NewIAMService(cfg.AWS.AccessKeyID, cfg.AWS.SecretAccessKey, cfg.AWS.IAMEndpoint)
+		if err != nil {
+			log.WithError(err).Error("Failed to create IAM service")
+			return deploymentState, fmt.Errorf("failed to create IAM service: %w", err)
 		}
-		log.Info("S3 bucket rollback completed")
-		return nil
-	})
+	}
+
+	dnsProvider, err := dns.NewProvider(cfg)
+	if err != nil {
+		log.WithError(err).Error("Failed to create DNS provider")
+		return deploymentState, fmt.Errorf("failed to create DNS provider: %w", err)
+	}
 
-	log.Info("Creating database service")
-	dbService := database.NewPostgresService(cfg.Database)
-	if err := dbService.CreateClientDatabases(deploymentEnv.ResourceNames.DatabaseMain, deploymentEnv.ResourceNames.DatabaseHangfire); err != nil {
-		log.WithError(err).Error("Failed to setup database")
-		if rollbackErr := rollbackMgr.ExecuteRollback(ctx); rollbackErr != nil {
-			log.WithError(rollbackErr).Error("Rollback failed")
+	services := installServices{
+		s3:     s3Service,
+		iam:    iamService,
+		db:     database.NewPostgresService(cfg.Database),
+		do:     digitalocean.NewAppService(cfg.DO.Token),
+		vercel: vercel.NewProjectService(cfg.Vercel),
+		dns:    dnsProvider,
+	}
+
+	if !state.IsStepCompleted(deploymentState, types.StepDOApp) {
+		log.WithFields(logrus.Fields{
+			"region":         appSpec.Region,
+			"instance_size":  appSpec.InstanceSizeSlug,
+			"instance_count": appSpec.InstanceCount,
+		}).Info("Validating DigitalOcean app settings")
+		if err := services.do.ValidateAppSpec(ctx, appSpec); err != nil {
+			log.WithError(err).Error("Invalid DigitalOcean app settings")
+			return deploymentState, fmt.Errorf("invalid DigitalOcean app settings: %w", err)
 		}
-		return fmt.Errorf("failed to setup database: %w", err)
 	}
-	rollbackMgr.AddAction("Database cleanup", func(ctx context.Context) error {
-		log.Info("Rolling back database creation")
-		if err := dbService.DeleteClientDatabases(deploymentEnv.ResourceNames.DatabaseMain, deploymentEnv.ResourceNames.DatabaseHangfire); err != nil {
-			log.WithError(err).Error("Failed to rollback databases")
-			return fmt.Errorf("failed to delete databases during rollback: %w", err)
+
+	if !seed.IsZero() && !state.IsStepCompleted(deploymentState, types.StepS3Seed) {
+		exists, err := services.s3.SeedSourceExists(ctx, seed)
+		if err != nil {
+			log.WithError(err).Error("Failed to check S3 seed source")
+			return deploymentState, fmt.Errorf("failed to check S3 seed source: %w", err)
 		}
-		log.Info("Database rollback completed")
-		return nil
-	})
+		if !exists {
+			log.WithField("seed", seed.String()).Error("S3 seed source not found")
+			return deploymentState, fmt.Errorf("S3 seed source %s not found", seed)
+		}
+	}
 
-	log.Info("Creating DigitalOcean service")
-	doService := digitalocean.NewAppService(cfg.DO.Token)
-	backendEnvVars := types.DigitalOceanEnvVars{
-		AppEnvs:       deploymentEnv.Backend.AppLevelVars,
-		ComponentEnvs: deploymentEnv.Backend.ComponentLevelVars,
+	recordStep := func(step types.StepName, status types.StepStatus, stepErr error) {
+		if err := store.UpdateStep(deploymentState, step, status, stepErr); err != nil {
+			log.WithError(err).WithField("step", step).Warn("Failed to persist deployment state")
+		}
 	}
-	backendURL, err := doService.CreateApp(ctx, client, backendEnvVars, cfg)
+
+	// values is seeded once completed steps have been verified, before the engine emits events.
+	var values *engine.Values
+
+	// dnsRecords is written by the domains step and read by the event handler once that step has
+	// completed.
+	var dnsRecords []types.DNSRecord
+
+	steps := installSteps(client, cfg, deploymentEnv, services, opts, seed, deploymentState.Labels, &dnsRecords)
+	installEngine, err := engine.New(steps, rollback.NewManager(), engine.Options{
+		Skip: func(step types.StepName) bool {
+			return state.IsStepCompleted(deploymentState, step)
+		},
+		OnEvent: func(event engine.Event) {
+			syncDeploymentState(deploymentState, values)
+
+			eventLog := log.WithFields(logrus.Fields{
+				"step":  event.Step,
+				"event": event.Type,
+			})
+
+			switch event.Type {
+			case engine.EventStepStarted:
+				eventLog.Info("Step started")
+				recordStep(event.Step, types.StepStatusInProgress, nil)
+			case engine.EventStepSkipped:
+				eventLog.Info("Step already completed, skipping")
+			case engine.EventStepCompleted:
+				if event.Step == types.StepDomains {
+					deploymentState.DNSRecords = dnsRecords
+				}
+				eventLog.WithField("duration", event.Duration.Round(time.Millisecond)).Info("Step completed")
+				recordStep(event.Step, types.StepStatusCompleted, nil)
+			case engine.EventStepFailed:
+				eventLog.WithError(event.Err).WithField("duration", event.Duration.Round(time.Millisecond)).Error("Step failed")
+				recordStep(event.Step, types.StepStatusFailed, event.Err)
+			case engine.EventStepRolledBack:
+				eventLog.Info("Step rolled back")
+				recordStep(event.Step, types.StepStatusRolledBack, nil)
+			}
+		},
+	})
 	if err != nil {
-		log.WithError(err).Error("Failed to setup DigitalOcean")
-		if rollbackErr := rollbackMgr.ExecuteRollback(ctx); rollbackErr != nil {
-			log.WithError(rollbackErr).Error("Rollback failed")
+		log.WithError(err).Error("Invalid install steps")
+		return deploymentState, fmt.Errorf("invalid install steps: %w", err)
+	}
+
+	if opts.Resume {
+		log.Info("Verifying resources of completed steps")
+		if err := verifyCompletedSteps(ctx, deploymentState, store, services, installEngine.Dependents); err != nil {
+			log.WithError(err).Error("Failed to verify completed steps")
+			return deploymentState, fmt.Errorf("failed to verify completed steps: %w", err)
 		}
-		return fmt.Errorf("failed to setup DigitalOcean: %w", err)
 	}
-	log.WithField("backend_url", backendURL).Info("DigitalOcean app created successfully")
-	rollbackMgr.AddAction("DigitalOcean app cleanup", func(ctx context.Context) error {
-		log.Info("Rolling back DigitalOcean app creation")
-		if err := doService.DeleteApp(ctx, client.SanitizedClientName, cfg); err != nil {
-			log.WithError(err).Error("Failed to rollback DigitalOcean app")
-			return fmt.Errorf("failed to delete DigitalOcean app during rollback: %w", err)
+
+	values = engine.NewValues(map[string]string{
+		valueDOAppID:           deploymentState.DOAppID,
+		valueBackendURL:        deploymentState.BackendURL,
+		valueVercelProjectID:   deploymentState.VercelProjectID,
+		valueFrontendURL:       deploymentState.FrontendURL,
+		valueS3AccessKeyID:     deploymentState.Secrets.S3AccessKeyID,
+		valueS3SecretAccessKey: deploymentState.Secrets.S3SecretAccessKey,
+	})
+
+	if err := installEngine.Run(ctx, values); err != nil {
+		log.WithError(err).Error("Fresh install failed")
+		var stepErr *engine.StepError
+		if errors.As(err, &stepErr) && stepErr.Resumable {
+			// Resumable steps only reconfigure resources that already exist, so nothing was
+			// rolled back.
+			return deploymentState, fmt.Errorf("%w (re-run with --resume to continue)", err)
 		}
-		log.Info("DigitalOcean app rollback completed")
-		return nil
+		return deploymentState, err
+	}
+
+	completionLog := log.WithFields(logrus.Fields{
+		"backend_url":  deploymentState.BackendURL,
+		"frontend_url": deploymentState.FrontendURL,
 	})
+	if client.Domain != "" {
+		domains := resources.CustomDomainsFor(client.Domain)
+		completionLog = completionLog.WithFields(logrus.Fields{
+			"custom_frontend_url": "https://" + domains.Frontend,
+			"custom_backend_url":  "https://" + domains.Backend,
+		})
+	}
+	completionLog.Info("Fresh install completed successfully with bidirectional URL configuration")
 
-	log.Info("Creating Vercel service")
-	vercelService := vercel.NewProjectService(cfg.Vercel)
+	return deploymentState, nil
+}
 
-	log.Info("Updating client with backend URL")
-	client.BackendInfo.URL = backendURL
+// installSteps declares the provisioning steps of a client. The bucket and the databases do not
+// depend on each other and are created concurrently; the backend and frontend URLs are then
+// wired into each other's environment once both platforms have assigned them. Clients with a
+// custom domain get two more steps that attach it and wait for its DNS records, and clients with
+// a seed source get one that fills the bucket once it exists. Every resource that can carry
+// labels is labeled as it is created.
+func installSteps(client types.Client, cfg *config.Config, deploymentEnv types.DeploymentEnvironment, services installServices, opts freshInstallOptions, seed types.SeedSource, labels types.ResourceLabels, dnsRecords *[]types.DNSRecord) []engine.Step {
+	resume := opts.Resume
 
-	log.Info("Generating Vercel environment variables")
-	frontendEnvVars, err := envvars.GenerateVercelEnvironmentVariables(client, cfg)
-	if err != nil {
-		log.WithError(err).Error("Failed to generate Vercel environment variables")
-		if rollbackErr := rollbackMgr.ExecuteRollback(ctx); rollbackErr != nil {
-			log.WithError(rollbackErr).Error("Rollback failed")
-		}
-		return fmt.Errorf("failed to generate Vercel environment variables: %w", err)
+	log := logger.WithFields(logrus.Fields{
+		"client":         client.Name,
+		"sanitized_name": client.SanitizedClientName,
+	})
+
+	names := deploymentEnv.ResourceNames
+
+	// resolvedClient returns the client with the URLs and bucket credentials resolved by earlier
+	// steps.
+	resolvedClient := func(values *engine.Values) types.Client {
+		resolved := client
+		resolved.BackendInfo.URL = values.Get(valueBackendURL)
+		resolved.FrontendInfo.URL = values.Get(valueFrontendURL)
+		resolved.Secrets.S3AccessKeyID = values.Get(valueS3AccessKeyID)
+		resolved.Secrets.S3SecretAccessKey = values.Get(valueS3SecretAccessKey)
+		return resolved
 	}
 
-	frontendURL, err := vercelService.CreateProject(ctx, client.SanitizedClientName, frontendEnvVars, cfg)
-	if err != nil {
-		log.WithError(err).Error("Failed to setup Vercel")
-		if rollbackErr := rollbackMgr.ExecuteRollback(ctx); rollbackErr != nil {
-			log.WithError(rollbackErr).Error("Rollback failed")
+	doAppDependencies := []types.StepName{types.StepS3Bucket, types.StepDatabaseRole}
+	var doAppInputs []string
+	if services.iam != nil {
+		doAppDependencies = append(doAppDependencies, types.StepS3Credentials)
+		doAppInputs = []string{valueS3AccessKeyID, valueS3SecretAccessKey}
+	}
+
+	// Only resources touched by this run are compensated.
+	var doAppID, createdProjectID string
+
+	steps := []engine.Step{
+		{
+			Name: types.StepS3Bucket,
+			Run: func(ctx context.Context, values *engine.Values) error {
+				log.WithField("bucket", names.S3Bucket).Info("Creating S3 bucket")
+				createBucket := services.s3.CreateBucket
+				if resume {
+					createBucket = services.s3.EnsureBucket
+				}
+				return createBucket(ctx, names.S3Bucket, resources.BucketOptionsFor(cfg, names, client.Domain, labels))
+			},
+			Compensate: func(ctx context.Context) error {
+				log.Info("Rolling back S3 bucket creation")
+				if err := services.s3.DeleteBucket(ctx, names.S3Bucket); err != nil {
+					return fmt.Errorf("failed to delete S3 bucket during rollback: %w", err)
+				}
+				return nil
+			},
+		},
+		{
+			Name: types.StepDatabases,
+			Run: func(ctx context.Context, values *engine.Values) error {
+				log.WithField("template", client.DatabaseTemplate).Info("Creating client databases")
+				createDatabases := services.db.CreateClientDatabases
+				if resume {
+					createDatabases = services.db.EnsureClientDatabases
+				}
+				if err := createDatabases(names.DatabaseMain, names.DatabaseHangfire, client.DatabaseTemplate); err != nil {
+					return err
+				}
+				return services.db.LabelDatabases(labels, names.DatabaseMain, names.DatabaseHangfire)
+			},
+			Compensate: func(ctx context.Context) error {
+				log.Info("Rolling back database creation")
+				if err := services.db.DeleteClientDatabases(names.DatabaseMain, names.DatabaseHangfire); err != nil {
+					return fmt.Errorf("failed to delete databases during rollback: %w", err)
+				}
+				return nil
+			},
+		},
+		{
+			Name:      types.StepDatabaseRole,
+			DependsOn: []types.StepName{types.StepDatabases},
+			Run: func(ctx context.Context, values *engine.Values) error {
+				log.WithField("role", names.DatabaseRole).Info("Configuring client database role")
+				if err := services.db.ConfigureClientRole(names.DatabaseRole, client.Secrets.DatabasePassword, names.DatabaseMain, names.DatabaseHangfire); err != nil {
+					return err
+				}
+				return services.db.LabelRole(names.DatabaseRole, labels)
+			},
+			Compensate: func(ctx context.Context) error {
+				log.Info("Rolling back database role")
+				if err := services.db.DropClientRole(names.DatabaseRole, names.DatabaseMain, names.DatabaseHangfire); err != nil {
+					return fmt.Errorf("failed to drop database role during rollback: %w", err)
+				}
+				return nil
+			},
+			CompensateOnFailure: true,
+		},
+		{
+			Name:      types.StepDOApp,
+			DependsOn: doAppDependencies,
+			Inputs:    doAppInputs,
+			Outputs:   []string{valueDOAppID, valueBackendURL},
+			Run: func(ctx context.Context, values *engine.Values) error {
+				var doApp types.DigitalOceanApp
+				var err error
+
+				// The recorded app may have been deleted by the rollback of an earlier run.
+				var existingApp *godo.App
+				if appID := values.Get(valueDOAppID); resume && appID != "" {
+					existingApp, err = services.do.GetApp(ctx, appID)
+					if err != nil {
+						return err
+					}
+					if existingApp == nil {
+						log.WithField("app_id", appID).Info("Recorded DigitalOcean app no longer exists, creating it again")
+						values.Set(valueDOAppID, "")
+					}
+				}
+
+				if existingApp != nil {
+					log.WithField("app_id", existingApp.ID).Info("Resuming DigitalOcean app deployment")
+					doApp.ID = existingApp.ID
+					doApp.URL, err = services.do.WaitForAppDeploymentAndGetURL(ctx, existingApp.ID)
+				} else {
+					log.Info("Creating DigitalOcean app")
+					// The environment generated up front lacks the bucket credentials.
+					backendEnv, envErr := envvars.GenerateDeploymentEnvironment(resolvedClient(values), cfg)
+					if envErr != nil {
+						return fmt.Errorf("failed to generate deployment environment: %w", envErr)
+					}
+					backendEnvVars := types.DigitalOceanEnvVars{
+						AppEnvs:       backendEnv.Backend.AppLevelVars,
+						ComponentEnvs: backendEnv.Backend.ComponentLevelVars,
+					}
+					doApp, err = services.do.CreateApp(ctx, client, backendEnvVars, cfg)
+				}
+
+				if doApp.ID != "" {
+					doAppID = doApp.ID
+					values.Set(valueDOAppID, doApp.ID)
+				}
+				if err != nil {
+					return err
+				}
+
+				values.Set(valueBackendURL, doApp.URL)
+				log.WithField("backend_url", doApp.URL).Info("DigitalOcean app created successfully")
+				return nil
+			},
+			Compensate: func(ctx context.Context) error {
+				if doAppID == "" {
+					return nil
+				}
+				log.Info("Rolling back DigitalOcean app creation")
+				if err := services.do.DeleteAppByID(ctx, doAppID); err != nil {
+					return fmt.Errorf("failed to delete DigitalOcean app during rollback: %w", err)
+				}
+				return nil
+			},
+			CompensateOnFailure: true,
+		},
+		{
+			Name:      types.StepVercelProject,
+			DependsOn: []types.StepName{types.StepDOApp},
+			Inputs:    []string{valueBackendURL},
+			Outputs:   []string{valueVercelProjectID, valueFrontendURL},
+			Run: func(ctx context.Context, values *engine.Values) error {
+				if resume {
+					projectRef := values.Get(valueVercelProjectID)
+					if projectRef == "" {
+						projectRef = names.VercelProject
+					}
+
+					project, err := services.vercel.GetProject(ctx, projectRef)
+					if err != nil {
+						return err
+					}
+					if project != nil {
+						log.WithField("project", projectRef).Info("Vercel project already exists, resolving its domain")
+						domain, err := services.vercel.GetProjectDomain(ctx, project.ID)
+						if err != nil {
+							return fmt.Errorf("failed to get project domain: %w", err)
+						}
+						values.Set(valueVercelProjectID, project.ID)
+						values.Set(valueFrontendURL, domain)
+						return nil
+					}
+				}
+
+				log.Info("Generating Vercel environment variables")
+				frontendEnvVars, err := envvars.GenerateVercelEnvironmentVariables(resolvedClient(values), cfg)
+				if err != nil {
+					return fmt.Errorf("failed to generate Vercel environment variables: %w", err)
+				}
+
+				vercelProject, err := services.vercel.CreateProject(ctx, client.SanitizedClientName, frontendEnvVars, cfg)
+				if vercelProject.ID != "" {
+					createdProjectID = vercelProject.ID
+					values.Set(valueVercelProjectID, vercelProject.ID)
+				}
+				if err != nil {
+					return err
+				}
+
+				values.Set(valueFrontendURL, vercelProject.URL)
+				log.WithField("frontend_url", vercelProject.URL).Info("Vercel project created successfully")
+				return nil
+			},
+			Compensate: func(ctx context.Context) error {
+				if createdProjectID == "" {
+					return nil
+				}
+				log.Info("Rolling back Vercel project creation")
+				if err := services.vercel.DeleteProject(ctx, createdProjectID); err != nil {
+					return fmt.Errorf("failed to delete Vercel project during rollback: %w", err)
+				}
+				return nil
+			},
+			CompensateOnFailure: true,
+		},
+		{
+			Name:      types.StepVercelEnv,
+			DependsOn: []types.StepName{types.StepVercelProject},
+			Inputs:    []string{valueVercelProjectID, valueBackendURL, valueFrontendURL},
+			Resumable: true,
+			Run: func(ctx context.Context, values *engine.Values) error {
+				log.Info("Updating Vercel environment variables with actual frontend URL")
+				frontendEnvVars, err := envvars.GenerateVercelEnvironmentVariables(resolvedClient(values), cfg)
+				if err != nil {
+					return fmt.Errorf("failed to generate updated Vercel environment variables: %w", err)
+				}
+
+				return services.vercel.UpdateProjectEnvironmentVariables(ctx, values.Get(valueVercelProjectID), frontendEnvVars)
+			},
+		},
+		{
+			Name:      types.StepVercelDeployment,
+			DependsOn: []types.StepName{types.StepVercelEnv},
+			Resumable: true,
+			Run: func(ctx context.Context, values *engine.Values) error {
+				log.Info("Creating initial Vercel deployment")
+				return services.vercel.CreateDeployment(ctx, client, cfg)
+			},
+		},
+		{
+			Name:      types.StepDOEnv,
+			DependsOn: []types.StepName{types.StepDOApp, types.StepVercelProject},
+			Inputs:    []string{valueDOAppID, valueBackendURL, valueFrontendURL},
+			Resumable: true,
+			Run: func(ctx context.Context, values *engine.Values) error {
+				log.Info("Updating DigitalOcean app with frontend URL")
+				backendEnv, err := envvars.GenerateDeploymentEnvironment(resolvedClient(values), cfg)
+				if err != nil {
+					return fmt.Errorf("failed to generate updated deployment environment: %w", err)
+				}
+
+				backendEnvVars := types.DigitalOceanEnvVars{
+					AppEnvs:       backendEnv.Backend.AppLevelVars,
+					ComponentEnvs: backendEnv.Backend.ComponentLevelVars,
+				}
+
+				return services.do.UpdateAppEnvironmentVariablesByID(ctx, values.Get(valueDOAppID), backendEnvVars)
+			},
+		},
+	}
+
+	if services.iam != nil {
+		steps = append(steps, engine.Step{
+			Name:      types.StepS3Credentials,
+			DependsOn: []types.StepName{types.StepS3Bucket},
+			Outputs:   []string{valueS3AccessKeyID, valueS3SecretAccessKey},
+			Run: func(ctx context.Context, values *engine.Values) error {
+				log.WithField("user", names.IAMUser).Info("Creating IAM user for the S3 bucket")
+				accessKey, err := services.iam.EnsureBucketUser(ctx, names.IAMUser, names.S3Bucket, values.Get(valueS3AccessKeyID), labels)
+				if err != nil {
+					return err
+				}
+				if accessKey != nil {
+					values.Set(valueS3AccessKeyID, accessKey.ID)
+					values.Set(valueS3SecretAccessKey, accessKey.Secret)
+				}
+				return nil
+			},
+			Compensate: func(ctx context.Context) error {
+				log.Info("Rolling back IAM user creation")
+				if err := services.iam.DeleteBucketUser(ctx, names.IAMUser); err != nil {
+					return fmt.Errorf("failed to delete IAM user during rollback: %w", err)
+				}
+				return nil
+			},
+			CompensateOnFailure: true,
+		})
+	}
+
+	if !seed.IsZero() {
+		steps = append(steps, engine.Step{
+			Name:      types.StepS3Seed,
+			DependsOn: []types.StepName{types.StepS3Bucket},
+			// Seeding skips objects that are already in place, so a failed run is finished by
+			// resuming rather than by deleting the bucket.
+			Resumable: true,
+			Run: func(ctx context.Context, values *engine.Values) error {
+				log.WithFields(logrus.Fields{
+					"bucket": names.S3Bucket,
+					"seed":   seed.String(),
+				}).Info("Seeding S3 bucket")
+				return services.s3.SeedBucket(ctx, names.S3Bucket, seed, cfg.AWS.SeedCacheControl)
+			},
+		})
+	}
+
+	if client.Domain == "" {
+		return steps
+	}
+
+	domains := resources.CustomDomainsFor(client.Domain)
+
+	domainTimeout := opts.DomainTimeout
+	if domainTimeout <= 0 {
+		domainTimeout = defaultDomainTimeout
+	}
+
+	// Only records published by this run are compensated.
+	var publishedRecords []types.DNSRecord
+
+	return append(steps,
+		engine.Step{
+			Name:      types.StepDomains,
+			DependsOn: []types.StepName{types.StepDOApp, types.StepVercelProject},
+			Inputs:    []string{valueDOAppID, valueVercelProjectID},
+			Resumable: true,
+			Run: func(ctx context.Context, values *engine.Values) error {
+				projectID := values.Get(valueVercelProjectID)
+
+				log.WithField("domain", domains.Frontend).Info("Adding custom domains to Vercel project")
+				apex, err := services.vercel.AddProjectDomain(ctx, projectID, domains.Frontend, "")
+				if err != nil {
+					return err
+				}
+				www, err := services.vercel.AddProjectDomain(ctx, projectID, domains.FrontendWWW, domains.Frontend)
+				if err != nil {
+					return err
+				}
+
+				appRecords, err := services.do.AppDomainRecords(ctx, values.Get(valueDOAppID))
+				if err != nil {
+					return err
+				}
+
+				records := append(vercel.DomainRecords([]types.VercelDomain{*apex, *www}), appRecords...)
+				message := "DNS record required"
+
+				if services.dns != nil {
+					log.WithField("dns_provider", services.dns.Name()).Info("Publishing DNS records")
+					records, err = services.dns.UpsertRecords(ctx, records)
+					if err != nil {
+						return fmt.Errorf("failed to publish DNS records: %w", err)
+					}
+					publishedRecords = records
+					message = "DNS record published"
+				}
+
+				for _, record := range records {
+					log.WithFields(logrus.Fields{
+						"provider": record.Provider,
+						"name":     record.Name,
+						"type":     record.Type,
+						"value":    record.Value,
+					}).Info(message)
+				}
+				*dnsRecords = records
+				return nil
+			},
+			Compensate: func(ctx context.Context) error {
+				if len(publishedRecords) == 0 {
+					return nil
+				}
+				log.Info("Rolling back DNS records")
+				if err := services.dns.DeleteRecords(ctx, publishedRecords); err != nil {
+					return fmt.Errorf("failed to delete DNS records during rollback: %w", err)
+				}
+				return nil
+			},
+		},
+		engine.Step{
+			Name:      types.StepDomainVerify,
+			DependsOn: []types.StepName{types.StepDomains},
+			Inputs:    []string{valueDOAppID, valueVercelProjectID},
+			Resumable: true,
+			Run: func(ctx context.Context, values *engine.Values) error {
+				log.WithField("timeout", domainTimeout).Info("Waiting for custom domains to be verified")
+
+				retryConfig := retry.Config{
+					MaxAttempts: int(domainTimeout/domainPollInterval) + 1,
+					Delay:       domainPollInterval,
+				}
+
+				return retry.Do(ctx, retryConfig, func() error {
+					var pending []string
+
+					for _, domain := range []string{domains.Frontend, domains.FrontendWWW} {
+						ready, err := services.vercel.ProjectDomainReady(ctx, values.Get(valueVercelProjectID), domain)
+						if err != nil {
+							return err
+						}
+						if !ready {
+							pending = append(pending, domain)
+						}
+					}
+
+					phase, err := services.do.AppDomainPhase(ctx, values.Get(valueDOAppID), domains.Backend)
+					if err != nil {
+						return err
+					}
+					if phase != godo.AppJobSpecKindPHASE_Active {
+						pending = append(pending, fmt.Sprintf("%s (%s)", domains.Backend, phase))
+					}
+
+					if len(pending) > 0 {
+						log.WithField("pending", pending).Info("Custom domains not verified yet")
+						return fmt.Errorf("domains not verified yet: %s", strings.Join(pending, ", "))
+					}
+					return nil
+				})
+			},
+		},
+	)
+}
+
+// syncDeploymentState copies the outputs of the install steps into the deployment state. It runs
+// from the engine's event handler, so the state is only ever written from one goroutine.
+func syncDeploymentState(deploymentState *types.DeploymentState, values *engine.Values) {
+	deploymentState.DOAppID = values.Get(valueDOAppID)
+	deploymentState.BackendURL = values.Get(valueBackendURL)
+	deploymentState.VercelProjectID = values.Get(valueVercelProjectID)
+	deploymentState.FrontendURL = values.Get(valueFrontendURL)
+	deploymentState.Secrets.S3AccessKeyID = values.Get(valueS3AccessKeyID)
+	deploymentState.Secrets.S3SecretAccessKey = values.Get(valueS3SecretAccessKey)
+}
+
+// prepareDeploymentState starts a new deployment state, or loads the existing one when resuming.
+func prepareDeploymentState(client types.Client, resourceNames types.ResourceNames, store *state.Store, resume bool) (*types.DeploymentState, error) {
+	existingState, err := store.Load(client.SanitizedClientName)
+	if err != nil && !errors.Is(err, state.ErrNotFound) {
+		return nil, fmt.Errorf("failed to load deployment state: %w", err)
+	}
+
+	if resume {
+		if existingState == nil {
+			return nil, fmt.Errorf("no deployment state found for client %s, nothing to resume", client.SanitizedClientName)
 		}
-		return fmt.Errorf("failed to setup Vercel: %w", err)
+		if existingState.ResourceNames.DatabaseRole == "" {
+			// States written before client database roles existed.
+			existingState.ResourceNames.DatabaseRole = resourceNames.DatabaseRole
+		}
+		if err := ensureClientSecrets(existingState, store); err != nil {
+			return nil, err
+		}
+		return existingState, nil
 	}
-	log.WithField("frontend_url", frontendURL).Info("Vercel project created successfully")
-	rollbackMgr.AddAction("Vercel project cleanup", func(ctx context.Context) error {
-		log.Info("Rolling back Vercel project creation")
-		if err := vercelService.DeleteProject(ctx, client.SanitizedClientName); err != nil {
-			log.WithError(err).Error("Failed to rollback Vercel project")
-			return fmt.Errorf("failed to delete Vercel project during rollback: %w", err)
+
+	if existingState != nil {
+		for step, stepState := range existingState.Steps {
+			if stepState.Status == types.StepStatusCompleted {
+				return nil, fmt.Errorf("client %s already has provisioned resources (step %s completed), use --resume to continue a partial install", client.SanitizedClientName, step)
+			}
 		}
-		log.Info("Vercel project rollback completed")
+	}
+
+	deploymentState := state.New(client, resourceNames)
+	if err := ensureClientSecrets(deploymentState, store); err != nil {
+		return nil, err
+	}
+
+	return deploymentState, nil
+}
+
+// resolveInstalledDomain keeps a resumed install on the domain it was started with. The api
+// subdomain is part of the DigitalOcean app spec, so a domain cannot be added once the app exists.
+func resolveInstalledDomain(client *types.Client, deploymentState *types.DeploymentState) error {
+	switch {
+	case deploymentState.Domain == client.Domain:
 		return nil
-	})
+	case client.Domain == "":
+		client.Domain = deploymentState.Domain
+		return nil
+	case deploymentState.Domain != "":
+		return fmt.Errorf("client %s was installed with domain %s, not %s", client.SanitizedClientName, deploymentState.Domain, client.Domain)
+	case state.IsStepCompleted(deploymentState, types.StepDOApp):
+		return fmt.Errorf("client %s was installed without a custom domain and its DigitalOcean app already exists", client.SanitizedClientName)
+	}
 
-	log.Info("Updating Vercel environment variables with actual frontend URL")
-	client.FrontendInfo.URL = frontendURL
+	deploymentState.Domain = client.Domain
+	return nil
+}
 
-	updatedFrontendEnvVars, err := envvars.GenerateVercelEnvironmentVariables(client, cfg)
-	if err != nil {
-		log.WithError(err).Error("Failed to generate updated Vercel environment variables")
-		return fmt.Errorf("failed to generate updated Vercel environment variables: %w", err)
+// resolveDatabaseTemplate picks the template set the client databases are cloned from: the
+// client's own, the one a resumed install was started with, or the configured default. Once the
+// databases exist, a resumed install cannot switch templates.
+func resolveDatabaseTemplate(client *types.Client, deploymentState *types.DeploymentState, cfg *config.Config) error {
+	switch {
+	case client.DatabaseTemplate == "" && deploymentState.DatabaseTemplate != "":
+		client.DatabaseTemplate = deploymentState.DatabaseTemplate
+	case client.DatabaseTemplate == "":
+		client.DatabaseTemplate = cfg.Database.Template
+	case deploymentState.DatabaseTemplate != "" && deploymentState.DatabaseTemplate != client.DatabaseTemplate &&
+		state.IsStepCompleted(deploymentState, types.StepDatabases):
+		return fmt.Errorf("client %s databases were cloned from template %s, not %s", client.SanitizedClientName, deploymentState.DatabaseTemplate, client.DatabaseTemplate)
 	}
 
-	if err := vercelService.UpdateProjectEnvironmentVariables(ctx, client.SanitizedClientName, updatedFrontendEnvVars); err != nil {
-		log.WithError(err).Error("Failed to update Vercel environment variables")
-		return fmt.Errorf("failed to update Vercel environment variables: %w", err)
+	if err := database.ValidateTemplateName(client.DatabaseTemplate); err != nil {
+		return err
 	}
 
-	log.Info("Creating initial Vercel deployment")
-	if err := vercelService.CreateDeployment(ctx, client, cfg); err != nil {
-		log.WithError(err).Error("Failed to create Vercel deployment")
-		return fmt.Errorf("failed to create Vercel deployment: %w", err)
+	deploymentState.DatabaseTemplate = client.DatabaseTemplate
+	return nil
+}
+
+// resolveS3Region picks the region of the client bucket: the requested one, the one recorded in
+// the state, or AWS_REGION. A bucket cannot move, so it must match the state once created.
+func resolveS3Region(client *types.Client, deploymentState *types.DeploymentState, cfg *config.Config) error {
+	// States without a region were created in AWS_REGION.
+	recorded := cfg.AWS.BucketRegion(deploymentState.S3Region)
+
+	switch {
+	case client.S3Region == "":
+		client.S3Region = recorded
+	case client.S3Region != recorded && state.IsStepCompleted(deploymentState, types.StepS3Bucket):
+		return fmt.Errorf("client %s bucket was created in region %s, not %s", client.SanitizedClientName, recorded, client.S3Region)
+	}
+
+	if err := config.ValidateRegion(client.S3Region); err != nil {
+		return err
 	}
 
-	log.Info("Updating DigitalOcean app with frontend URL")
+	deploymentState.S3Region = client.S3Region
+	deploymentState.ResourceNames.S3URL = cfg.AWS.BucketURL(deploymentState.ResourceNames.S3Bucket, client.S3Region)
+	return nil
+}
+
+// resolveS3Seed picks what the client bucket is seeded with: the client's own source, the one a
+// resumed install was started with, or S3_SEED_SOURCE. Seeding only adds and updates objects,
+// so a resumed install may switch sources.
+func resolveS3Seed(client *types.Client, deploymentState *types.DeploymentState, cfg *config.Config) (types.SeedSource, error) {
+	switch {
+	case client.S3Seed == "" && deploymentState.S3Seed != "":
+		client.S3Seed = deploymentState.S3Seed
+	case client.S3Seed == "":
+		client.S3Seed = cfg.AWS.SeedSource
+	}
 
-	updatedBackendEnv, err := envvars.GenerateDeploymentEnvironment(client, cfg)
+	seed, err := resources.ParseSeedSource(client.S3Seed)
 	if err != nil {
-		log.WithError(err).Error("Failed to generate updated deployment environment")
-		return fmt.Errorf("failed to generate updated deployment environment: %w", err)
+		return seed, err
+	}
+
+	deploymentState.S3Seed = client.S3Seed
+	return seed, nil
+}
+
+// recordClientInputs keeps the settings the client's environment is generated from, so drift can
+// regenerate it without the flags or manifest of the install. Once every step that pushes the
+// environment has completed, the recorded settings describe what was pushed and are kept.
+func recordClientInputs(client types.Client, deploymentState *types.DeploymentState) {
+	if deploymentState.Inputs != nil &&
+		state.IsStepCompleted(deploymentState, types.StepDOEnv) &&
+		state.IsStepCompleted(deploymentState, types.StepVercelEnv) {
+		return
+	}
+
+	smtp := client.SMTPInfo
+	smtp.Password = ""
+	deploymentState.Inputs = &types.ClientInputs{
+		SMTP:     smtp,
+		ExtraEnv: client.ExtraEnv,
+		App:      client.App,
+	}
+}
+
+// ensureClientSecrets generates the client's secrets once and persists them, so every re-run
+// of the install reuses the same values.
+func ensureClientSecrets(deploymentState *types.DeploymentState, store *state.Store) error {
+	if deploymentState.Secrets.JWTKey == "" || deploymentState.Secrets.RevalidationToken == "" || deploymentState.Secrets.DatabasePassword == "" {
+		clientSecrets, err := secrets.Generate()
+		if err != nil {
+			return fmt.Errorf("failed to generate client secrets: %w", err)
+		}
+		if deploymentState.Secrets.JWTKey == "" {
+			deploymentState.Secrets.JWTKey = clientSecrets.JWTKey
+		}
+		if deploymentState.Secrets.RevalidationToken == "" {
+			deploymentState.Secrets.RevalidationToken = clientSecrets.RevalidationToken
+		}
+		if deploymentState.Secrets.DatabasePassword == "" {
+			deploymentState.Secrets.DatabasePassword = clientSecrets.DatabasePassword
+		}
+	}
+
+	if err := store.Save(deploymentState); err != nil {
+		return fmt.Errorf("failed to save deployment state: %w", err)
+	}
+
+	return nil
+}
+
+// verifyCompletedSteps checks that the resources of completed steps still exist. Steps whose
+// resource has disappeared are reset to pending, together with the steps that depend on them.
+func verifyCompletedSteps(ctx context.Context, deploymentState *types.DeploymentState, store *state.Store, services installServices, dependents func(types.StepName) []types.StepName) error {
+	log := logger.WithFields(logrus.Fields{
+		"client":    deploymentState.ClientName,
+		"component": "resume",
+	})
+
+	names := deploymentState.ResourceNames
+	var missing []types.StepName
+
+	if state.IsStepCompleted(deploymentState, types.StepS3Bucket) {
+		exists, err := services.s3.BucketExists(ctx, names.S3Bucket)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			log.WithField("bucket", names.S3Bucket).Warn("S3 bucket recorded as created but missing")
+			missing = append(missing, types.StepS3Bucket)
+		}
+	}
+
+	if services.iam != nil && state.IsStepCompleted(deploymentState, types.StepS3Credentials) {
+		exists, err := services.iam.AccessKeyExists(ctx, names.IAMUser, deploymentState.Secrets.S3AccessKeyID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			log.WithField("user", names.IAMUser).Warn("IAM user or access key recorded as created but missing")
+			deploymentState.Secrets.S3AccessKeyID = ""
+			deploymentState.Secrets.S3SecretAccessKey = ""
+			missing = append(missing, types.StepS3Credentials)
+		}
+	}
+
+	if state.IsStepCompleted(deploymentState, types.StepDatabases) {
+		exists, err := services.db.ClientDatabasesExist(names.DatabaseMain, names.DatabaseHangfire)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			log.Warn("Client databases recorded as created but missing")
+			missing = append(missing, types.StepDatabases)
+		}
 	}
 
-	updatedBackendEnvVars := types.DigitalOceanEnvVars{
-		AppEnvs:       updatedBackendEnv.Backend.AppLevelVars,
-		ComponentEnvs: updatedBackendEnv.Backend.ComponentLevelVars,
+	if state.IsStepCompleted(deploymentState, types.StepDatabaseRole) {
+		exists, err := services.db.RoleExists(names.DatabaseRole)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			log.WithField("role", names.DatabaseRole).Warn("Client database role recorded as created but missing")
+			missing = append(missing, types.StepDatabaseRole)
+		}
 	}
 
-	if err := doService.UpdateAppEnvironmentVariables(ctx, client.SanitizedClientName, updatedBackendEnvVars, cfg); err != nil {
-		log.WithError(err).Error("Failed to update DigitalOcean app environment variables")
-		return fmt.Errorf("failed to update DigitalOcean app environment variables: %w", err)
+	if state.IsStepCompleted(deploymentState, types.StepDOApp) {
+		app, err := services.do.GetApp(ctx, deploymentState.DOAppID)
+		if err != nil {
+			return err
+		}
+		if app == nil {
+			log.WithField("app_id", deploymentState.DOAppID).Warn("DigitalOcean app recorded as created but missing")
+			deploymentState.DOAppID = ""
+			deploymentState.BackendURL = ""
+			missing = append(missing, types.StepDOApp)
+		}
+	}
+
+	if state.IsStepCompleted(deploymentState, types.StepVercelProject) {
+		exists, err := services.vercel.ProjectExists(ctx, deploymentState.VercelProjectID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			log.WithField("project_id", deploymentState.VercelProjectID).Warn("Vercel project recorded as created but missing")
+			deploymentState.VercelProjectID = ""
+			deploymentState.FrontendURL = ""
+			missing = append(missing, types.StepVercelProject)
+		}
 	}
 
-	log.WithFields(logrus.Fields{
-		"backend_url":  backendURL,
-		"frontend_url": frontendURL,
-	}).Info("Fresh install completed successfully with bidirectional URL configuration")
+	reset := make(map[types.StepName]bool)
+	for _, step := range missing {
+		for _, resetStep := range append([]types.StepName{step}, dependents(step)...) {
+			if reset[resetStep] {
+				continue
+			}
+			reset[resetStep] = true
+			if err := store.UpdateStep(deploymentState, resetStep, types.StepStatusPending, nil); err != nil {
+				return fmt.Errorf("failed to reset step %s: %w", resetStep, err)
+			}
+		}
+	}
 
 	return nil
 }
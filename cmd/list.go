@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/CaioDGallo/easy-cli/internal/config"
+	"github.com/CaioDGallo/easy-cli/internal/logger"
+	"github.com/CaioDGallo/easy-cli/internal/orphans"
+	"github.com/CaioDGallo/easy-cli/internal/state"
+	"github.com/CaioDGallo/easy-cli/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Command used to list the known clients or the resources no client accounts for",
+	Long:  `This command lists the clients that have a deployment state. With --orphans, it instead searches S3, IAM, Postgres and DigitalOcean for resources labeled with APP_ENVIRONMENT that no deployment state records, and exits with a non-zero status when it finds any. Vercel projects are not labeled, so they are not searched.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load()
+		if err != nil {
+			logger.Fatalf("Failed to load configuration: %v", err)
+		}
+
+		findOrphans, _ := cmd.Flags().GetBool("orphans")
+		output := cmd.Flag("output").Value.String()
+
+		if output != "table" && output != "json" {
+			logger.Fatalf("Unsupported output format %q (expected table or json)", output)
+		}
+		if output == "json" {
+			// Keep stdout clean for the JSON document.
+			logger.SetOutput(os.Stderr)
+		}
+
+		store := state.NewStore(cfg.State.Dir)
+		states, err := store.List()
+		if err != nil {
+			logger.Fatalf("Failed to list deployment states: %v", err)
+		}
+
+		if !findOrphans {
+			if output == "json" {
+				err = writeClientsJSON(os.Stdout, states)
+			} else {
+				err = writeClientsTable(os.Stdout, states)
+			}
+			if err != nil {
+				logger.Fatalf("Failed to write client list: %v", err)
+			}
+			return
+		}
+
+		report := orphans.Find(context.Background(), cfg, states)
+
+		if output == "json" {
+			err = report.WriteJSON(os.Stdout)
+		} else {
+			err = report.WriteTable(os.Stdout)
+		}
+		if err != nil {
+			logger.Fatalf("Failed to write orphan report: %v", err)
+		}
+
+		if len(report.Errors) > 0 {
+			logger.Fatalf("Failed to search %d providers for orphaned resources", len(report.Errors))
+		}
+		if len(report.Orphans) > 0 {
+			logger.Fatalf("Found %d orphaned resources", len(report.Orphans))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+
+	listCmd.Flags().Bool("orphans", false, "List resources labeled by easy-cli that no known client accounts for")
+	listCmd.Flags().StringP("output", "o", "table", "Output format (table or json)")
+}
+
+type clientSummary struct {
+	ClientName     string               `json:"clientName"`
+	SanitizedName  string               `json:"sanitizedName"`
+	Labels         types.ResourceLabels `json:"labels"`
+	Domain         string               `json:"domain,omitempty"`
+	CompletedSteps int                  `json:"completedSteps"`
+	Steps          int                  `json:"steps"`
+	UpdatedAt      time.Time            `json:"updatedAt"`
+}
+
+func summarizeClient(deploymentState *types.DeploymentState) clientSummary {
+	completed := 0
+	for _, stepState := range deploymentState.Steps {
+		if stepState.Status == types.StepStatusCompleted {
+			completed++
+		}
+	}
+
+	return clientSummary{
+		ClientName:     deploymentState.ClientName,
+		SanitizedName:  deploymentState.SanitizedName,
+		Labels:         deploymentState.Labels,
+		Domain:         deploymentState.Domain,
+		CompletedSteps: completed,
+		Steps:          len(deploymentState.Steps),
+		UpdatedAt:      deploymentState.UpdatedAt,
+	}
+}
+
+func writeClientsJSON(w io.Writer, states []*types.DeploymentState) error {
+	summaries := make([]clientSummary, 0, len(states))
+	for _, deploymentState := range states {
+		summaries = append(summaries, summarizeClient(deploymentState))
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(summaries)
+}
+
+func writeClientsTable(w io.Writer, states []*types.DeploymentState) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CLIENT\tSANITIZED NAME\tENVIRONMENT\tDOMAIN\tSTEPS\tUPDATED")
+	for _, deploymentState := range states {
+		summary := summarizeClient(deploymentState)
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d/%d\t%s\n",
+			summary.ClientName,
+			summary.SanitizedName,
+			valueOrDash(summary.Labels.Environment),
+			valueOrDash(summary.Domain),
+			summary.CompletedSteps,
+			summary.Steps,
+			summary.UpdatedAt.Format(time.RFC3339),
+		)
+	}
+	return tw.Flush()
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
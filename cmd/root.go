@@ -6,12 +6,14 @@ package cmd
 import (
 	"os"
 
+	"github.com/CaioDGallo/easy-cli/internal/version"
 	"github.com/spf13/cobra"
 )
 
 var rootCmd = &cobra.Command{
-	Use:   "easy-cli",
-	Short: "Easy CLI will make it easy to run on DevOps setup actions",
+	Use:     "easy-cli",
+	Short:   "Easy CLI will make it easy to run on DevOps setup actions",
+	Version: version.String(),
 }
 
 func Execute() {
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CaioDGallo/easy-cli/internal/config"
+	"github.com/CaioDGallo/easy-cli/internal/digitalocean"
+	"github.com/CaioDGallo/easy-cli/internal/envvars"
+	"github.com/CaioDGallo/easy-cli/internal/logger"
+	"github.com/CaioDGallo/easy-cli/internal/secrets"
+	"github.com/CaioDGallo/easy-cli/internal/state"
+	"github.com/CaioDGallo/easy-cli/internal/types"
+	"github.com/CaioDGallo/easy-cli/internal/utils"
+	"github.com/CaioDGallo/easy-cli/internal/vercel"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var rotateSecretsCmd = &cobra.Command{
+	Use:   "rotate-secrets",
+	Short: "Command used to regenerate a client's JWT key and revalidation token",
+	Long:  `This command generates new per-client secrets, pushes them to DigitalOcean and Vercel and records them in the deployment state.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load()
+		if err != nil {
+			logger.Fatalf("Failed to load configuration: %v", err)
+		}
+
+		clientName := cmd.Flag("client-name").Value.String()
+
+		log := logger.WithFields(logrus.Fields{
+			"client":  clientName,
+			"command": "rotate-secrets",
+		})
+
+		store := state.NewStore(cfg.State.Dir)
+		deploymentState, err := store.Load(utils.SanitizeClientName(clientName))
+		if err != nil {
+			logger.Fatalf("Failed to load deployment state: %v", err)
+		}
+
+		log.Info("Rotating client secrets")
+		if err := rotateSecrets(context.Background(), deploymentState, cfg, store); err != nil {
+			log.WithError(err).Error("Secret rotation failed")
+			logger.Fatalf("Secret rotation failed: %v", err)
+		}
+		log.Info("Client secrets rotated successfully")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rotateSecretsCmd)
+
+	rotateSecretsCmd.Flags().StringP("client-name", "c", "", "The name of the client whose secrets are rotated")
+	rotateSecretsCmd.MarkFlagRequired("client-name")
+}
+
+// rotateSecrets pushes the new JWT key to DigitalOcean and the new revalidation token to Vercel.
+// Each value is persisted as soon as its platform accepted it, so a partial failure never leaves
+// the state out of sync with what is deployed.
+func rotateSecrets(ctx context.Context, deploymentState *types.DeploymentState, cfg *config.Config, store *state.Store) error {
+	log := logger.WithFields(logrus.Fields{
+		"client":         deploymentState.ClientName,
+		"sanitized_name": deploymentState.SanitizedName,
+	})
+
+	if deploymentState.DOAppID == "" {
+		return fmt.Errorf("deployment state has no DigitalOcean app ID")
+	}
+
+	newSecrets, err := secrets.Generate()
+	if err != nil {
+		return fmt.Errorf("failed to generate client secrets: %w", err)
+	}
+
+	backendEnvVars, frontendEnvVars, err := envvars.GenerateSecretEnvironment(newSecrets)
+	if err != nil {
+		return fmt.Errorf("failed to generate secret environment: %w", err)
+	}
+
+	log.Info("Pushing new JWT key to DigitalOcean")
+	doService := digitalocean.NewAppService(cfg.DO.Token)
+	if err := doService.MergeAppEnvironmentVariablesByID(ctx, deploymentState.DOAppID, backendEnvVars); err != nil {
+		return fmt.Errorf("failed to update DigitalOcean app environment variables: %w", err)
+	}
+
+	deploymentState.Secrets.JWTKey = newSecrets.JWTKey
+	if err := store.Save(deploymentState); err != nil {
+		return fmt.Errorf("failed to save deployment state: %w", err)
+	}
+
+	log.Info("Pushing new revalidation token to Vercel")
+	vercelService := vercel.NewProjectService(cfg.Vercel)
+	projectRef := deploymentState.ResourceNames.VercelProject
+	if deploymentState.VercelProjectID != "" {
+		projectRef = deploymentState.VercelProjectID
+	}
+	if err := vercelService.UpdateProjectEnvironmentVariables(ctx, projectRef, frontendEnvVars); err != nil {
+		return fmt.Errorf("failed to update Vercel environment variables: %w", err)
+	}
+
+	deploymentState.Secrets.RevalidationToken = newSecrets.RevalidationToken
+	if err := store.Save(deploymentState); err != nil {
+		return fmt.Errorf("failed to save deployment state: %w", err)
+	}
+
+	log.Info("Redeploying Vercel project to apply the new token")
+	client := types.Client{
+		Name:                deploymentState.ClientName,
+		SanitizedClientName: deploymentState.SanitizedName,
+		FrontendBranch:      deploymentState.FrontendBranch,
+	}
+	if err := vercelService.CreateDeployment(ctx, client, cfg); err != nil {
+		return fmt.Errorf("failed to create Vercel deployment: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/CaioDGallo/easy-cli/internal/config"
+	"github.com/CaioDGallo/easy-cli/internal/logger"
+	"github.com/CaioDGallo/easy-cli/internal/resources"
+	"github.com/CaioDGallo/easy-cli/internal/state"
+	"github.com/CaioDGallo/easy-cli/internal/status"
+	"github.com/CaioDGallo/easy-cli/internal/types"
+	"github.com/CaioDGallo/easy-cli/internal/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Command used to check the live health of a client's resources",
+	Long:  `This command queries S3, Postgres, DigitalOcean and Vercel for every resource of a client and exits with a non-zero status when anything is missing or has drifted.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load()
+		if err != nil {
+			logger.Fatalf("Failed to load configuration: %v", err)
+		}
+
+		clientName := cmd.Flag("client-name").Value.String()
+		output := cmd.Flag("output").Value.String()
+		sanitizedClientName := utils.SanitizeClientName(clientName)
+
+		if output != "table" && output != "json" {
+			logger.Fatalf("Unsupported output format %q (expected table or json)", output)
+		}
+		if output == "json" {
+			// Keep stdout clean for the JSON document.
+			logger.SetOutput(os.Stderr)
+		}
+
+		log := logger.WithFields(logrus.Fields{
+			"client":  clientName,
+			"command": "status",
+		})
+
+		store := state.NewStore(cfg.State.Dir)
+		deploymentState, err := store.Load(sanitizedClientName)
+		if err != nil {
+			if !errors.Is(err, state.ErrNotFound) {
+				logger.Fatalf("Failed to load deployment state: %v", err)
+			}
+			log.Warn("No deployment state found, falling back to derived resource names")
+			deploymentState = state.New(types.Client{
+				Name:                clientName,
+				SanitizedClientName: sanitizedClientName,
+			}, resources.GenerateResourceNames(sanitizedClientName, "", cfg))
+		}
+
+		report := status.Check(context.Background(), deploymentState, cfg)
+
+		if output == "json" {
+			err = report.WriteJSON(os.Stdout)
+		} else {
+			err = report.WriteTable(os.Stdout)
+		}
+		if err != nil {
+			logger.Fatalf("Failed to write status report: %v", err)
+		}
+
+		if !report.Healthy() {
+			logger.Fatalf("Client %s has missing or drifted resources", clientName)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+
+	statusCmd.Flags().StringP("client-name", "c", "", "The name of the client to check")
+	statusCmd.MarkFlagRequired("client-name")
+	statusCmd.Flags().StringP("output", "o", "table", "Output format (table or json)")
+}
@@ -167,19 +167,51 @@ DB_HOST=your-database-host.rds.amazonaws.com
 DB_USER=postgres
 DB_PASSWORD=your_database_password_here
 DB_NAME=postgres
+DB_PORT=5432
+# TLS mode: disable, require, verify-ca or verify-full
+DB_SSLMODE=disable
+# DB_SSLROOTCERT=/path/to/ca-certificate.crt
+# Template set new client databases are cloned from
+# DB_TEMPLATE=demo
 
 # Vercel Configuration
 VERCEL_TOKEN=your_vercel_token_here
 VERCEL_TEAM_ID=your_vercel_team_id_here
-VERCEL_FRONTEND_REPO_UUID={e4839c5c-d412-4c9d-88f7-c6209fef4b6a}
 
 # AWS Configuration
 AWS_REGION=us-east-1
 AWS_ACCESS_KEY_ID=your_aws_access_key_id_here
 AWS_SECRET_ACCESS_KEY=your_aws_secret_access_key_here
+# Optional S3-compatible endpoint (DigitalOcean Spaces, Cloudflare R2, MinIO)
+# S3_ENDPOINT=https://nyc3.digitaloceanspaces.com
+# S3_FORCE_PATH_STYLE=false
+# S3_PUBLIC_URL=https://{bucket}.nyc3.digitaloceanspaces.com
+# Per-client IAM users for bucket access (on by default on AWS)
+# S3_CLIENT_CREDENTIALS=true
+# IAM_ENDPOINT=http://localhost:4566
+# Optional bucket settings (CORS always allows the client's frontend)
+# S3_CORS_ORIGINS=http://localhost:3000
+# S3_VERSIONING=false
+# S3_TEMP_PREFIX=tmp/
+# S3_TEMP_EXPIRATION_DAYS=1
+# Optional assets copied into new client buckets (a directory or s3://bucket/prefix)
+# S3_SEED_SOURCE=./seed
+# S3_SEED_CACHE_CONTROL="public, max-age=86400"
+# Optional bucket for db backup --s3, so backups outlive the client buckets
+# S3_BACKUP_BUCKET=easy-backups
 
 # DigitalOcean Configuration
 DO_TOKEN=your_digitalocean_token_here
+# Optional App Platform defaults (tier: small, medium or large)
+# DO_APP_TIER=small
+# DO_REGION=sfo
+# DO_INSTANCE_SIZE=basic-xxs
+# DO_INSTANCE_COUNT=1
+
+# DNS Configuration (optional, publishes custom domain records)
+# DNS_PROVIDER=route53
+# ROUTE53_HOSTED_ZONE_ID=
+# DNS_TTL=300
 
 # SMTP Configuration
 SMTP_SERVER=your-smtp-server.com
@@ -187,12 +219,18 @@ SMTP_USERNAME=your-smtp-username@yourdomain.com
 SMTP_DO_NOT_REPLY_EMAIL=noreply@yourdomain.com
 SMTP_DEV_EMAIL=developer@yourdomain.com
 
-# Repository Configuration
+# Repository Configuration (providers: bitbucket, github or gitlab)
+BACKEND_REPO_PROVIDER=bitbucket
 BACKEND_REPO=your-org/your-backend-repo
+FRONTEND_REPO_PROVIDER=bitbucket
 FRONTEND_REPO=your-org/your-frontend-repo
+# Bitbucket repository UUID, GitLab project ID or (optional) GitHub repository ID
+FRONTEND_REPO_ID={e4839c5c-d412-4c9d-88f7-c6209fef4b6a}
 
 # Application Configuration
 APP_NAME_PREFIX=your-app-prefix
+# Environment recorded in resource labels (default: production)
+# APP_ENVIRONMENT=production
 EOF
     
     chmod 600 "$env_file"  # Make it readable only by owner
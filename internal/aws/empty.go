@@ -0,0 +1,210 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/CaioDGallo/easy-cli/internal/logger"
+	"github.com/CaioDGallo/easy-cli/internal/retry"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// deleteBatchSize is the most keys a DeleteObjects request accepts.
+	deleteBatchSize    = 1000
+	emptyBucketWorkers = 8
+	progressInterval   = 10 * time.Second
+)
+
+type deleteBatch struct {
+	objects []s3types.ObjectIdentifier
+	bytes   int64
+}
+
+// addObjectFunc queues an object version for deletion. It returns false once emptying has been
+// cancelled.
+type addObjectFunc func(key, versionID *string, size int64) bool
+
+// emptyBucket deletes every object version and delete marker, which versioned buckets need
+// before they can be deleted. The bucket is listed page by page while a bounded pool of workers
+// deletes full batches, and progress is logged as it goes.
+func (s *S3Service) emptyBucket(ctx context.Context, bucketName string) error {
+	log := logger.WithFields(logrus.Fields{
+		"bucket":  bucketName,
+		"service": "s3",
+		"action":  "empty",
+	})
+
+	log.Info("Emptying S3 bucket")
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		deletedObjects atomic.Int64
+		deletedBytes   atomic.Int64
+		failOnce       sync.Once
+		deleteErr      error
+	)
+
+	batches := make(chan deleteBatch, emptyBucketWorkers)
+	var wg sync.WaitGroup
+	for range emptyBucketWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				if err := s.deleteObjects(ctx, bucketName, batch.objects); err != nil {
+					failOnce.Do(func() {
+						deleteErr = err
+						cancel()
+					})
+					continue
+				}
+				deletedObjects.Add(int64(len(batch.objects)))
+				deletedBytes.Add(batch.bytes)
+			}
+		}()
+	}
+
+	progressDone := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				log.WithFields(logrus.Fields{
+					"objects": deletedObjects.Load(),
+					"bytes":   deletedBytes.Load(),
+				}).Info("Emptying S3 bucket in progress")
+			case <-progressDone:
+				return
+			}
+		}
+	}()
+
+	var batch deleteBatch
+	send := func() bool {
+		select {
+		case batches <- batch:
+			batch = deleteBatch{}
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	add := func(key, versionID *string, size int64) bool {
+		batch.objects = append(batch.objects, s3types.ObjectIdentifier{Key: key, VersionId: versionID})
+		batch.bytes += size
+		return len(batch.objects) < deleteBatchSize || send()
+	}
+
+	listErr := s.listObjectVersions(ctx, bucketName, add)
+	if errors.Is(listErr, ErrNotSupported) {
+		// Services without versioning may not list versions either.
+		listErr = s.listObjects(ctx, bucketName, add)
+	}
+	if listErr == nil && len(batch.objects) > 0 && !send() {
+		listErr = ctx.Err()
+	}
+
+	close(batches)
+	wg.Wait()
+	close(progressDone)
+
+	if deleteErr != nil {
+		return deleteErr
+	}
+	if listErr != nil {
+		return listErr
+	}
+
+	log.WithFields(logrus.Fields{
+		"objects": deletedObjects.Load(),
+		"bytes":   deletedBytes.Load(),
+	}).Info("S3 bucket emptied successfully")
+	return nil
+}
+
+// listObjectVersions queues every version and delete marker of the bucket.
+func (s *S3Service) listObjectVersions(ctx context.Context, bucketName string, add addObjectFunc) error {
+	paginator := s3.NewListObjectVersionsPaginator(s.client, &s3.ListObjectVersionsInput{
+		Bucket: aws.String(bucketName),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list object versions in bucket: %w", notSupported(err))
+		}
+
+		for _, version := range page.Versions {
+			if !add(version.Key, version.VersionId, aws.ToInt64(version.Size)) {
+				return ctx.Err()
+			}
+		}
+		for _, marker := range page.DeleteMarkers {
+			if !add(marker.Key, marker.VersionId, 0) {
+				return ctx.Err()
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *S3Service) listObjects(ctx context.Context, bucketName string, add addObjectFunc) error {
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucketName),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list objects in bucket: %w", err)
+		}
+
+		for _, object := range page.Contents {
+			if !add(object.Key, nil, aws.ToInt64(object.Size)) {
+				return ctx.Err()
+			}
+		}
+	}
+
+	return nil
+}
+
+// deleteObjects deletes a batch, retrying throttled requests. DeleteObjects reports failures
+// per key in a successful response, so those are checked too.
+func (s *S3Service) deleteObjects(ctx context.Context, bucketName string, objects []s3types.ObjectIdentifier) error {
+	var output *s3.DeleteObjectsOutput
+	err := retry.Do(ctx, retry.DefaultConfig(), func() error {
+		var err error
+		output, err = s.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(bucketName),
+			Delete: &s3types.Delete{
+				Objects: objects,
+				Quiet:   aws.Bool(true),
+			},
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete objects from bucket: %w", err)
+	}
+
+	if len(output.Errors) > 0 {
+		first := output.Errors[0]
+		return fmt.Errorf("failed to delete %d objects from bucket, first %s: %s", len(output.Errors), aws.ToString(first.Key), aws.ToString(first.Message))
+	}
+
+	return nil
+}
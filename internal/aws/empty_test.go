@@ -0,0 +1,169 @@
+package aws
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// newTestS3Service points an S3Service at a fake S3 served by handler, with path-style
+// addressing. It is built as for AWS itself, so regions are resolved as they would be there.
+func newTestS3Service(t *testing.T, handler http.Handler, region string) *S3Service {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := s3.New(s3.Options{
+		Region:       region,
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+		Credentials:  credentials.NewStaticCredentialsProvider("key", "secret", ""),
+	})
+	return &S3Service{client: client, region: region}
+}
+
+// fakeVersionedBucket lists its versions and delete markers a page at a time and records the
+// DeleteObjects batches it receives.
+type fakeVersionedBucket struct {
+	versions      int
+	deleteMarkers int
+	pageSize      int
+	// failKeys are reported as failed in the DeleteObjects response.
+	failKeys map[string]bool
+
+	mu      sync.Mutex
+	batches []int
+	deleted map[string]int
+}
+
+func (f *fakeVersionedBucket) key(i int) string {
+	if i < f.versions {
+		return fmt.Sprintf("media/%05d.jpg", i)
+	}
+	return fmt.Sprintf("deleted/%05d.jpg", i-f.versions)
+}
+
+func (f *fakeVersionedBucket) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	switch {
+	case r.Method == http.MethodGet && query.Has("versions"):
+		start, _ := strconv.Atoi(query.Get("key-marker"))
+		end := min(start+f.pageSize, f.versions+f.deleteMarkers)
+
+		fmt.Fprintf(w, `<ListVersionsResult><IsTruncated>%t</IsTruncated>`, end < f.versions+f.deleteMarkers)
+		if end < f.versions+f.deleteMarkers {
+			fmt.Fprintf(w, `<NextKeyMarker>%d</NextKeyMarker><NextVersionIdMarker>v</NextVersionIdMarker>`, end)
+		}
+		for i := start; i < end; i++ {
+			if i < f.versions {
+				fmt.Fprintf(w, `<Version><Key>%s</Key><VersionId>v%d</VersionId><Size>10</Size></Version>`, f.key(i), i)
+			} else {
+				fmt.Fprintf(w, `<DeleteMarker><Key>%s</Key><VersionId>v%d</VersionId></DeleteMarker>`, f.key(i), i)
+			}
+		}
+		fmt.Fprint(w, `</ListVersionsResult>`)
+
+	case r.Method == http.MethodPost && query.Has("delete"):
+		var request struct {
+			Objects []struct {
+				Key       string
+				VersionId string
+			} `xml:"Object"`
+		}
+		if err := xml.NewDecoder(r.Body).Decode(&request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		f.mu.Lock()
+		f.batches = append(f.batches, len(request.Objects))
+		fmt.Fprint(w, `<DeleteResult>`)
+		for _, object := range request.Objects {
+			if f.failKeys[object.Key] {
+				fmt.Fprintf(w, `<Error><Key>%s</Key><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`, object.Key)
+				continue
+			}
+			f.deleted[object.Key+"@"+object.VersionId]++
+		}
+		fmt.Fprint(w, `</DeleteResult>`)
+		f.mu.Unlock()
+
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func TestEmptyBucketBatchesVersions(t *testing.T) {
+	tests := []struct {
+		name          string
+		versions      int
+		deleteMarkers int
+		wantBatches   int
+	}{
+		{name: "empty bucket", wantBatches: 0},
+		{name: "partial batch", versions: 3, deleteMarkers: 2, wantBatches: 1},
+		{name: "exactly one batch", versions: deleteBatchSize, wantBatches: 1},
+		{name: "batches span pages", versions: 2300, deleteMarkers: 450, wantBatches: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeVersionedBucket{
+				versions:      tt.versions,
+				deleteMarkers: tt.deleteMarkers,
+				pageSize:      700,
+				deleted:       make(map[string]int),
+			}
+			s3Service := newTestS3Service(t, fake, "us-east-1")
+
+			if err := s3Service.emptyBucket(context.Background(), "easy-acme"); err != nil {
+				t.Fatalf("emptyBucket() error = %v", err)
+			}
+
+			if len(fake.batches) != tt.wantBatches {
+				t.Errorf("sent %d batches %v, want %d", len(fake.batches), fake.batches, tt.wantBatches)
+			}
+			for _, size := range fake.batches {
+				if size > deleteBatchSize {
+					t.Errorf("batch of %d objects exceeds %d", size, deleteBatchSize)
+				}
+			}
+
+			total := tt.versions + tt.deleteMarkers
+			if len(fake.deleted) != total {
+				t.Errorf("deleted %d object versions, want %d", len(fake.deleted), total)
+			}
+			for i := range total {
+				id := fmt.Sprintf("%s@v%d", fake.key(i), i)
+				if fake.deleted[id] != 1 {
+					t.Fatalf("%s deleted %d times, want once", id, fake.deleted[id])
+				}
+			}
+		})
+	}
+}
+
+func TestEmptyBucketReportsFailedKeys(t *testing.T) {
+	fake := &fakeVersionedBucket{
+		versions: 5,
+		pageSize: 1000,
+		failKeys: map[string]bool{"media/00003.jpg": true},
+		deleted:  make(map[string]int),
+	}
+	s3Service := newTestS3Service(t, fake, "us-east-1")
+
+	err := s3Service.emptyBucket(context.Background(), "easy-acme")
+	if err == nil || !strings.Contains(err.Error(), "failed to delete 1 objects from bucket, first media/00003.jpg") {
+		t.Fatalf("emptyBucket() error = %v, want the failed key reported", err)
+	}
+}
@@ -0,0 +1,297 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/CaioDGallo/easy-cli/internal/logger"
+	"github.com/CaioDGallo/easy-cli/internal/retry"
+	"github.com/CaioDGallo/easy-cli/internal/types"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// iamRegion signs IAM requests; IAM itself is global.
+	iamRegion = "us-east-1"
+	// clientUserPath groups the users easy-cli creates, so they are easy to tell apart.
+	clientUserPath   = "/easy-cli/"
+	bucketPolicyName = "bucket-access"
+)
+
+type IAMService struct {
+	client *iam.Client
+}
+
+// NewIAMService creates the IAM client. A custom endpoint points it at an IAM-compatible
+// stand-in such as LocalStack or moto.
+func NewIAMService(accessKeyID, secretAccessKey, endpoint string) (*IAMService, error) {
+	cfg, err := config.LoadDefaultConfig(
+		context.TODO(),
+		config.WithRegion(iamRegion),
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
+			accessKeyID,
+			secretAccessKey,
+			"",
+		)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	client := iam.NewFromConfig(cfg, func(o *iam.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+	})
+
+	return &IAMService{client: client}, nil
+}
+
+// EnsureBucketUser creates the IAM user of a client bucket, or updates an existing one, with an
+// inline policy that only grants access to that bucket. The access key recorded for the user is
+// kept when it still exists; otherwise the user's keys are replaced with a new one, which is
+// returned. The secret of a key cannot be read back, so keys nobody recorded are of no use. The
+// user is tagged with the client's labels.
+func (i *IAMService) EnsureBucketUser(ctx context.Context, userName, bucketName, accessKeyID string, labels types.ResourceLabels) (*types.AccessKey, error) {
+	log := logger.WithFields(logrus.Fields{
+		"user":    userName,
+		"bucket":  bucketName,
+		"service": "iam",
+	})
+
+	exists, err := i.UserExists(ctx, userName)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		log.Info("Creating IAM user")
+		_, err := i.client.CreateUser(ctx, &iam.CreateUserInput{
+			UserName: aws.String(userName),
+			Path:     aws.String(clientUserPath),
+			Tags:     iamTags(labels),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create IAM user %s: %w", userName, err)
+		}
+	} else if err := i.tagUser(ctx, userName, labels); err != nil {
+		return nil, err
+	}
+
+	policy, err := bucketPolicyDocument(bucketName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build bucket policy: %w", err)
+	}
+
+	log.Info("Limiting IAM user to the client bucket")
+	// A new user can take a moment to become visible to the rest of IAM.
+	err = retry.Do(ctx, retry.DefaultConfig(), func() error {
+		_, err := i.client.PutUserPolicy(ctx, &iam.PutUserPolicyInput{
+			UserName:       aws.String(userName),
+			PolicyName:     aws.String(bucketPolicyName),
+			PolicyDocument: aws.String(policy),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set policy of IAM user %s: %w", userName, err)
+	}
+
+	keys, err := i.accessKeyIDs(ctx, userName)
+	if err != nil {
+		return nil, err
+	}
+	for _, keyID := range keys {
+		if keyID == accessKeyID {
+			log.WithField("access_key_id", accessKeyID).Info("IAM user already has its access key")
+			return nil, nil
+		}
+	}
+
+	for _, keyID := range keys {
+		log.WithField("access_key_id", keyID).Warn("Deleting unrecorded access key")
+		if err := i.deleteAccessKey(ctx, userName, keyID); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Info("Creating access key")
+	output, err := i.client.CreateAccessKey(ctx, &iam.CreateAccessKeyInput{
+		UserName: aws.String(userName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create access key for IAM user %s: %w", userName, err)
+	}
+
+	return &types.AccessKey{
+		ID:     aws.ToString(output.AccessKey.AccessKeyId),
+		Secret: aws.ToString(output.AccessKey.SecretAccessKey),
+	}, nil
+}
+
+// AccessKeyExists reports whether the user still has the access key. It is false when the user
+// itself is gone.
+func (i *IAMService) AccessKeyExists(ctx context.Context, userName, accessKeyID string) (bool, error) {
+	keys, err := i.accessKeyIDs(ctx, userName)
+	if isNoSuchEntity(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	for _, keyID := range keys {
+		if keyID == accessKeyID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// DeleteBucketUser deletes the user with its access keys and inline policies, which IAM requires
+// to be removed first. A user that does not exist is not an error.
+func (i *IAMService) DeleteBucketUser(ctx context.Context, userName string) error {
+	log := logger.WithFields(logrus.Fields{
+		"user":    userName,
+		"service": "iam",
+		"action":  "delete",
+	})
+
+	log.Info("Starting IAM user deletion")
+
+	keys, err := i.accessKeyIDs(ctx, userName)
+	if isNoSuchEntity(err) {
+		log.Info("IAM user does not exist, skipping deletion")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, keyID := range keys {
+		if err := i.deleteAccessKey(ctx, userName, keyID); err != nil {
+			return err
+		}
+	}
+
+	policies, err := i.client.ListUserPolicies(ctx, &iam.ListUserPoliciesInput{
+		UserName: aws.String(userName),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list policies of IAM user %s: %w", userName, err)
+	}
+	for _, policyName := range policies.PolicyNames {
+		_, err := i.client.DeleteUserPolicy(ctx, &iam.DeleteUserPolicyInput{
+			UserName:   aws.String(userName),
+			PolicyName: aws.String(policyName),
+		})
+		if err != nil && !isNoSuchEntity(err) {
+			return fmt.Errorf("failed to delete policy %s of IAM user %s: %w", policyName, userName, err)
+		}
+	}
+
+	if _, err := i.client.DeleteUser(ctx, &iam.DeleteUserInput{UserName: aws.String(userName)}); err != nil {
+		if isNoSuchEntity(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to delete IAM user %s: %w", userName, err)
+	}
+
+	log.Info("IAM user deleted successfully")
+	return nil
+}
+
+func (i *IAMService) UserExists(ctx context.Context, userName string) (bool, error) {
+	_, err := i.client.GetUser(ctx, &iam.GetUserInput{UserName: aws.String(userName)})
+	if isNoSuchEntity(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get IAM user %s: %w", userName, err)
+	}
+	return true, nil
+}
+
+func (i *IAMService) accessKeyIDs(ctx context.Context, userName string) ([]string, error) {
+	var keyIDs []string
+
+	paginator := iam.NewListAccessKeysPaginator(i.client, &iam.ListAccessKeysInput{
+		UserName: aws.String(userName),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list access keys of IAM user %s: %w", userName, err)
+		}
+		for _, key := range page.AccessKeyMetadata {
+			keyIDs = append(keyIDs, aws.ToString(key.AccessKeyId))
+		}
+	}
+
+	return keyIDs, nil
+}
+
+func (i *IAMService) deleteAccessKey(ctx context.Context, userName, accessKeyID string) error {
+	_, err := i.client.DeleteAccessKey(ctx, &iam.DeleteAccessKeyInput{
+		UserName:    aws.String(userName),
+		AccessKeyId: aws.String(accessKeyID),
+	})
+	if err != nil && !isNoSuchEntity(err) {
+		return fmt.Errorf("failed to delete access key %s of IAM user %s: %w", accessKeyID, userName, err)
+	}
+	return nil
+}
+
+type policyDocument struct {
+	Version   string            `json:"Version"`
+	Statement []policyStatement `json:"Statement"`
+}
+
+type policyStatement struct {
+	Effect   string   `json:"Effect"`
+	Action   []string `json:"Action"`
+	Resource string   `json:"Resource"`
+}
+
+// bucketPolicyDocument allows listing the bucket and managing its objects, including multipart
+// uploads, and nothing else.
+func bucketPolicyDocument(bucketName string) (string, error) {
+	bucketARN := "arn:aws:s3:::" + bucketName
+
+	document, err := json.Marshal(policyDocument{
+		Version: "2012-10-17",
+		Statement: []policyStatement{
+			{
+				Effect:   "Allow",
+				Action:   []string{"s3:ListBucket", "s3:GetBucketLocation", "s3:ListBucketMultipartUploads"},
+				Resource: bucketARN,
+			},
+			{
+				Effect: "Allow",
+				Action: []string{
+					"s3:GetObject",
+					"s3:PutObject",
+					"s3:DeleteObject",
+					"s3:AbortMultipartUpload",
+					"s3:ListMultipartUploadParts",
+				},
+				Resource: bucketARN + "/*",
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return string(document), nil
+}
+
+func isNoSuchEntity(err error) bool {
+	var noSuchEntity *iamtypes.NoSuchEntityException
+	return errors.As(err, &noSuchEntity)
+}
@@ -0,0 +1,44 @@
+package aws
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestBucketPolicyDocument(t *testing.T) {
+	document, err := bucketPolicyDocument("easy-acme")
+	if err != nil {
+		t.Fatalf("bucketPolicyDocument() error = %v", err)
+	}
+
+	var policy policyDocument
+	if err := json.Unmarshal([]byte(document), &policy); err != nil {
+		t.Fatalf("policy is not valid JSON: %v", err)
+	}
+	if policy.Version != "2012-10-17" {
+		t.Errorf("Version = %q, want 2012-10-17", policy.Version)
+	}
+
+	// Each resource gets exactly these actions; anything more would reach beyond the bucket's
+	// objects, such as changing its policy or deleting it.
+	want := map[string][]string{
+		"arn:aws:s3:::easy-acme":   {"s3:ListBucket", "s3:GetBucketLocation", "s3:ListBucketMultipartUploads"},
+		"arn:aws:s3:::easy-acme/*": {"s3:GetObject", "s3:PutObject", "s3:DeleteObject", "s3:AbortMultipartUpload", "s3:ListMultipartUploadParts"},
+	}
+
+	got := make(map[string][]string, len(policy.Statement))
+	for _, statement := range policy.Statement {
+		if statement.Effect != "Allow" {
+			t.Errorf("statement on %s has effect %q, want Allow", statement.Resource, statement.Effect)
+		}
+		if _, ok := got[statement.Resource]; ok {
+			t.Errorf("more than one statement on %s", statement.Resource)
+		}
+		got[statement.Resource] = statement.Action
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("actions by resource = %v, want %v", got, want)
+	}
+}
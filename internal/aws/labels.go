@@ -0,0 +1,208 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/CaioDGallo/easy-cli/internal/types"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// LabeledResource is a bucket or IAM user with the labels easy-cli tagged it with.
+type LabeledResource struct {
+	Name   string
+	Region string
+	Labels types.ResourceLabels
+}
+
+// configureBucketTags adds the options' tags to the bucket. PutBucketTagging replaces the whole
+// tag set, so tags set by anyone else are read first and kept.
+func (s *S3Service) configureBucketTags(ctx context.Context, bucketName string, options types.BucketOptions) error {
+	if len(options.Tags) == 0 {
+		return nil
+	}
+
+	tags, err := s.bucketTags(ctx, bucketName, "")
+	if err != nil {
+		return err
+	}
+	maps.Copy(tags, options.Tags)
+
+	tagSet := make([]s3types.Tag, 0, len(tags))
+	for _, key := range slices.Sorted(maps.Keys(tags)) {
+		tagSet = append(tagSet, s3types.Tag{Key: aws.String(key), Value: aws.String(tagValue(tags[key]))})
+	}
+
+	_, err = s.client.PutBucketTagging(ctx, &s3.PutBucketTaggingInput{
+		Bucket:  aws.String(bucketName),
+		Tagging: &s3types.Tagging{TagSet: tagSet},
+	})
+	return notSupported(err)
+}
+
+// bucketTags returns the tags of a bucket, which are empty when it has none. An empty region
+// uses the service's own.
+func (s *S3Service) bucketTags(ctx context.Context, bucketName, region string) (map[string]string, error) {
+	output, err := s.client.GetBucketTagging(ctx, &s3.GetBucketTaggingInput{
+		Bucket: aws.String(bucketName),
+	}, s.inRegion(region))
+	if apiErrorCode(err) == "NoSuchTagSet" {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags of bucket %s: %w", bucketName, notSupported(err))
+	}
+
+	tags := make(map[string]string, len(output.TagSet))
+	for _, tag := range output.TagSet {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return tags, nil
+}
+
+// LabeledBuckets returns the buckets whose name starts with prefix and that carry easy-cli
+// labels, wherever their region.
+func (s *S3Service) LabeledBuckets(ctx context.Context, prefix string) ([]LabeledResource, error) {
+	var buckets []LabeledResource
+
+	paginator := s3.NewListBucketsPaginator(s.client, &s3.ListBucketsInput{
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list buckets: %w", err)
+		}
+
+		for _, bucket := range page.Buckets {
+			name := aws.ToString(bucket.Name)
+			// Not every compatible service filters by prefix.
+			if !strings.HasPrefix(name, prefix) {
+				continue
+			}
+
+			region := aws.ToString(bucket.BucketRegion)
+			tags, err := s.bucketTags(ctx, name, region)
+			if s.isBucketNotFoundError(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			if labels, ok := types.LabelsFromMap(tags); ok {
+				buckets = append(buckets, LabeledResource{Name: name, Region: region, Labels: labels})
+			}
+		}
+	}
+
+	return buckets, nil
+}
+
+// LabeledUsers returns the IAM users easy-cli created that carry its labels.
+func (i *IAMService) LabeledUsers(ctx context.Context) ([]LabeledResource, error) {
+	var users []LabeledResource
+
+	paginator := iam.NewListUsersPaginator(i.client, &iam.ListUsersInput{
+		PathPrefix: aws.String(clientUserPath),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list IAM users: %w", err)
+		}
+
+		for _, user := range page.Users {
+			userName := aws.ToString(user.UserName)
+			tags, err := i.userTags(ctx, userName)
+			if isNoSuchEntity(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			if labels, ok := types.LabelsFromMap(tags); ok {
+				users = append(users, LabeledResource{Name: userName, Labels: labels})
+			}
+		}
+	}
+
+	return users, nil
+}
+
+func (i *IAMService) userTags(ctx context.Context, userName string) (map[string]string, error) {
+	tags := make(map[string]string)
+
+	paginator := iam.NewListUserTagsPaginator(i.client, &iam.ListUserTagsInput{
+		UserName: aws.String(userName),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tags of IAM user %s: %w", userName, err)
+		}
+		for _, tag := range page.Tags {
+			tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+	}
+
+	return tags, nil
+}
+
+// tagUser adds the labels to an existing user. Tags it already has with other keys are kept.
+func (i *IAMService) tagUser(ctx context.Context, userName string, labels types.ResourceLabels) error {
+	if labels.IsZero() {
+		return nil
+	}
+
+	_, err := i.client.TagUser(ctx, &iam.TagUserInput{
+		UserName: aws.String(userName),
+		Tags:     iamTags(labels),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to tag IAM user %s: %w", userName, err)
+	}
+	return nil
+}
+
+func iamTags(labels types.ResourceLabels) []iamtypes.Tag {
+	if labels.IsZero() {
+		return nil
+	}
+
+	values := labels.Map()
+
+	tags := make([]iamtypes.Tag, 0, len(values))
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		tags = append(tags, iamtypes.Tag{Key: aws.String(key), Value: aws.String(tagValue(values[key]))})
+	}
+	return tags
+}
+
+// maxTagValueLength is the longest tag value S3 and IAM accept.
+const maxTagValueLength = 256
+
+// tagValue replaces the characters S3 and IAM do not allow in tag values, such as the "&" of a
+// client name. Labels are matched by sanitized name, which never needs replacing.
+func tagValue(value string) string {
+	value = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.IsSpace(r) || strings.ContainsRune("_.:/=+-@", r) {
+			return r
+		}
+		return '_'
+	}, value)
+
+	if runes := []rune(value); len(runes) > maxTagValueLength {
+		value = string(runes[:maxTagValueLength])
+	}
+	return value
+}
@@ -0,0 +1,327 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/CaioDGallo/easy-cli/internal/interfaces"
+	"github.com/CaioDGallo/easy-cli/internal/logger"
+	"github.com/CaioDGallo/easy-cli/internal/types"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"github.com/sirupsen/logrus"
+)
+
+var _ interfaces.DNSProvider = (*Route53Service)(nil)
+
+type Route53Service struct {
+	client       *route53.Client
+	hostedZoneID string
+	ttl          int64
+}
+
+// NewRoute53Service creates the Route53 DNS provider. Without a hosted zone ID, the zone of each
+// record is looked up by name. A custom endpoint points the client at a Route53-compatible
+// stand-in such as LocalStack or moto.
+func NewRoute53Service(region, accessKeyID, secretAccessKey, hostedZoneID, endpoint string, ttl int64) (*Route53Service, error) {
+	cfg, err := config.LoadDefaultConfig(
+		context.TODO(),
+		config.WithRegion(region),
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
+			accessKeyID,
+			secretAccessKey,
+			"",
+		)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	client := route53.NewFromConfig(cfg, func(o *route53.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+	})
+
+	return &Route53Service{
+		client:       client,
+		hostedZoneID: strings.TrimPrefix(hostedZoneID, "/hostedzone/"),
+		ttl:          ttl,
+	}, nil
+}
+
+func (r *Route53Service) Name() string {
+	return "route53"
+}
+
+// UpsertRecords creates or updates the records and returns them with the hosted zone they were
+// written to. A/CNAME records replace the existing value; TXT values are added next to the ones
+// already published, since several verifications can share a name.
+func (r *Route53Service) UpsertRecords(ctx context.Context, records []types.DNSRecord) ([]types.DNSRecord, error) {
+	applied := make([]types.DNSRecord, len(records))
+	changes := make(map[string][]route53types.Change)
+	var zoneOrder []string
+
+	for _, group := range groupRecords(records) {
+		zoneID, err := r.ZoneFor(ctx, group.name)
+		if err != nil {
+			return nil, err
+		}
+
+		values := group.values
+		if group.recordType == types.DNSRecordTypeTXT {
+			existing, _, err := r.recordValues(ctx, zoneID, group.name, group.recordType)
+			if err != nil {
+				return nil, err
+			}
+			values = union(existing, values)
+		}
+
+		if _, seen := changes[zoneID]; !seen {
+			zoneOrder = append(zoneOrder, zoneID)
+		}
+		changes[zoneID] = append(changes[zoneID], r.change(route53types.ChangeActionUpsert, group.name, group.recordType, values, r.ttl))
+
+		for _, index := range group.indexes {
+			applied[index] = records[index]
+			applied[index].Zone = zoneID
+		}
+	}
+
+	for _, zoneID := range zoneOrder {
+		if err := r.applyChanges(ctx, zoneID, changes[zoneID]); err != nil {
+			return nil, err
+		}
+	}
+
+	return applied, nil
+}
+
+// DeleteRecords removes the values easy-cli published. Values other tools added to the same
+// record set are kept.
+func (r *Route53Service) DeleteRecords(ctx context.Context, records []types.DNSRecord) error {
+	changes := make(map[string][]route53types.Change)
+	var zoneOrder []string
+
+	for _, group := range groupRecords(records) {
+		zoneID := group.zone
+		if zoneID == "" {
+			var err error
+			if zoneID, err = r.ZoneFor(ctx, group.name); err != nil {
+				return err
+			}
+		}
+
+		existing, ttl, err := r.recordValues(ctx, zoneID, group.name, group.recordType)
+		if err != nil {
+			return err
+		}
+		if len(existing) == 0 {
+			continue
+		}
+
+		remaining := difference(existing, group.values)
+		if len(remaining) == len(existing) {
+			// The record now points somewhere else, so it is no longer ours to delete.
+			continue
+		}
+
+		if _, seen := changes[zoneID]; !seen {
+			zoneOrder = append(zoneOrder, zoneID)
+		}
+		if len(remaining) == 0 {
+			// Route53 only deletes a record set that matches the current one exactly.
+			changes[zoneID] = append(changes[zoneID], r.change(route53types.ChangeActionDelete, group.name, group.recordType, existing, ttl))
+		} else {
+			changes[zoneID] = append(changes[zoneID], r.change(route53types.ChangeActionUpsert, group.name, group.recordType, remaining, ttl))
+		}
+	}
+
+	for _, zoneID := range zoneOrder {
+		if err := r.applyChanges(ctx, zoneID, changes[zoneID]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ZoneFor returns the ID of the hosted zone that serves the name.
+func (r *Route53Service) ZoneFor(ctx context.Context, name string) (string, error) {
+	if r.hostedZoneID != "" {
+		return r.hostedZoneID, nil
+	}
+
+	// Walk up the name until a public hosted zone matches, so the most specific zone wins.
+	labels := strings.Split(normalizeName(name), ".")
+	for i := 0; i < len(labels)-1; i++ {
+		candidate := strings.Join(labels[i:], ".")
+
+		output, err := r.client.ListHostedZonesByName(ctx, &route53.ListHostedZonesByNameInput{
+			DNSName:  aws.String(candidate),
+			MaxItems: aws.Int32(1),
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to look up Route53 hosted zone for %s: %w", name, err)
+		}
+
+		for _, zone := range output.HostedZones {
+			if normalizeName(aws.ToString(zone.Name)) != candidate {
+				continue
+			}
+			if zone.Config != nil && zone.Config.PrivateZone {
+				continue
+			}
+			return strings.TrimPrefix(aws.ToString(zone.Id), "/hostedzone/"), nil
+		}
+	}
+
+	return "", fmt.Errorf("no Route53 hosted zone found for %s", name)
+}
+
+// recordValues returns the values and TTL of a record set, or no values if it does not exist.
+func (r *Route53Service) recordValues(ctx context.Context, zoneID, name string, recordType types.DNSRecordType) ([]string, int64, error) {
+	output, err := r.client.ListResourceRecordSets(ctx, &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String(zoneID),
+		StartRecordName: aws.String(name),
+		StartRecordType: route53types.RRType(recordType),
+		MaxItems:        aws.Int32(1),
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list Route53 records of %s: %w", name, err)
+	}
+
+	for _, recordSet := range output.ResourceRecordSets {
+		if normalizeName(aws.ToString(recordSet.Name)) != normalizeName(name) || string(recordSet.Type) != string(recordType) {
+			continue
+		}
+		values := make([]string, 0, len(recordSet.ResourceRecords))
+		for _, record := range recordSet.ResourceRecords {
+			values = append(values, unquote(recordType, aws.ToString(record.Value)))
+		}
+		return values, aws.ToInt64(recordSet.TTL), nil
+	}
+
+	return nil, 0, nil
+}
+
+func (r *Route53Service) change(action route53types.ChangeAction, name string, recordType types.DNSRecordType, values []string, ttl int64) route53types.Change {
+	resourceRecords := make([]route53types.ResourceRecord, len(values))
+	for i, value := range values {
+		resourceRecords[i] = route53types.ResourceRecord{Value: aws.String(quote(recordType, value))}
+	}
+
+	return route53types.Change{
+		Action: action,
+		ResourceRecordSet: &route53types.ResourceRecordSet{
+			Name:            aws.String(name),
+			Type:            route53types.RRType(recordType),
+			TTL:             aws.Int64(ttl),
+			ResourceRecords: resourceRecords,
+		},
+	}
+}
+
+func (r *Route53Service) applyChanges(ctx context.Context, zoneID string, changes []route53types.Change) error {
+	log := logger.WithFields(logrus.Fields{
+		"hosted_zone": zoneID,
+		"changes":     len(changes),
+		"service":     "route53",
+	})
+
+	log.Info("Applying Route53 record changes")
+
+	_, err := r.client.ChangeResourceRecordSets(ctx, &route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: aws.String(zoneID),
+		ChangeBatch: &route53types.ChangeBatch{
+			Comment: aws.String("Managed by easy-cli"),
+			Changes: changes,
+		},
+	})
+	if err != nil {
+		log.WithError(err).Error("Failed to apply Route53 record changes")
+		return fmt.Errorf("failed to change Route53 records in zone %s: %w", zoneID, err)
+	}
+
+	return nil
+}
+
+// recordGroup gathers the values of records sharing a name and type, which Route53 manages as a
+// single record set.
+type recordGroup struct {
+	zone       string
+	name       string
+	recordType types.DNSRecordType
+	values     []string
+	indexes    []int
+}
+
+func groupRecords(records []types.DNSRecord) []*recordGroup {
+	var groups []*recordGroup
+	byKey := make(map[string]*recordGroup)
+
+	for i, record := range records {
+		key := normalizeName(record.Name) + "|" + string(record.Type)
+		group, ok := byKey[key]
+		if !ok {
+			group = &recordGroup{zone: record.Zone, name: normalizeName(record.Name), recordType: record.Type}
+			byKey[key] = group
+			groups = append(groups, group)
+		}
+		group.values = union(group.values, []string{record.Value})
+		group.indexes = append(group.indexes, i)
+	}
+
+	return groups
+}
+
+func normalizeName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(name), ".")
+}
+
+func quote(recordType types.DNSRecordType, value string) string {
+	if recordType == types.DNSRecordTypeTXT {
+		return `"` + value + `"`
+	}
+	return value
+}
+
+func unquote(recordType types.DNSRecordType, value string) string {
+	if recordType == types.DNSRecordTypeTXT {
+		return strings.Trim(value, `"`)
+	}
+	return strings.TrimSuffix(value, ".")
+}
+
+func union(values, additions []string) []string {
+	result := append([]string(nil), values...)
+	for _, addition := range additions {
+		if !contains(result, addition) {
+			result = append(result, addition)
+		}
+	}
+	return result
+}
+
+func difference(values, removals []string) []string {
+	var result []string
+	for _, value := range values {
+		if !contains(removals, strings.TrimSuffix(value, ".")) {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.TrimSuffix(candidate, ".") == strings.TrimSuffix(value, ".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package aws
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/CaioDGallo/easy-cli/internal/types"
+)
+
+func TestUnion(t *testing.T) {
+	tests := []struct {
+		name      string
+		values    []string
+		additions []string
+		want      []string
+	}{
+		{"empty", nil, []string{"a.example.com"}, []string{"a.example.com"}},
+		{"keeps order", []string{"b"}, []string{"a", "c"}, []string{"b", "a", "c"}},
+		{"drops duplicates", []string{"a"}, []string{"a", "b", "b"}, []string{"a", "b"}},
+		{"ignores trailing dot", []string{"a.example.com."}, []string{"a.example.com"}, []string{"a.example.com."}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := union(tt.values, tt.additions); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("union(%q, %q) = %q, want %q", tt.values, tt.additions, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnionDoesNotModifyValues(t *testing.T) {
+	values := make([]string, 1, 4)
+	values[0] = "a"
+
+	union(values, []string{"b"})
+
+	if extended := values[:2]; extended[1] != "" {
+		t.Errorf("union wrote %q into the backing array of its input", extended[1])
+	}
+}
+
+func TestDifference(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []string
+		removals []string
+		want     []string
+	}{
+		{"nothing to remove", []string{"a", "b"}, nil, []string{"a", "b"}},
+		{"removes matches", []string{"a", "b", "c"}, []string{"b"}, []string{"a", "c"}},
+		{"removes everything", []string{"a"}, []string{"a"}, nil},
+		{"ignores trailing dot", []string{"a.example.com.", "b.example.com."}, []string{"a.example.com"}, []string{"b.example.com."}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := difference(tt.values, tt.removals); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("difference(%q, %q) = %q, want %q", tt.values, tt.removals, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupRecords(t *testing.T) {
+	records := []types.DNSRecord{
+		{Name: "acme.example.com", Type: types.DNSRecordTypeA, Value: "76.76.21.21", Zone: "Z1"},
+		{Name: "_vercel.example.com", Type: types.DNSRecordTypeTXT, Value: "vc-domain-verify=a", Zone: "Z1"},
+		{Name: "ACME.example.com.", Type: types.DNSRecordTypeA, Value: "76.76.21.22", Zone: "Z1"},
+		{Name: "_vercel.example.com.", Type: types.DNSRecordTypeTXT, Value: "vc-domain-verify=b", Zone: "Z1"},
+		{Name: "acme.example.com", Type: types.DNSRecordTypeA, Value: "76.76.21.21", Zone: "Z1"},
+		{Name: "api.acme.example.com", Type: types.DNSRecordTypeCNAME, Value: "acme.ondigitalocean.app", Zone: "Z1"},
+	}
+
+	want := []*recordGroup{
+		{zone: "Z1", name: "acme.example.com", recordType: types.DNSRecordTypeA, values: []string{"76.76.21.21", "76.76.21.22"}, indexes: []int{0, 2, 4}},
+		{zone: "Z1", name: "_vercel.example.com", recordType: types.DNSRecordTypeTXT, values: []string{"vc-domain-verify=a", "vc-domain-verify=b"}, indexes: []int{1, 3}},
+		{zone: "Z1", name: "api.acme.example.com", recordType: types.DNSRecordTypeCNAME, values: []string{"acme.ondigitalocean.app"}, indexes: []int{5}},
+	}
+
+	got := groupRecords(records)
+	if len(got) != len(want) {
+		t.Fatalf("groupRecords() returned %d groups, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("group %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -4,26 +4,53 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"strings"
 
 	"github.com/CaioDGallo/easy-cli/internal/interfaces"
 	"github.com/CaioDGallo/easy-cli/internal/logger"
 	"github.com/CaioDGallo/easy-cli/internal/retry"
+	"github.com/CaioDGallo/easy-cli/internal/types"
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
 	"github.com/sirupsen/logrus"
 )
 
 var _ interfaces.CloudStorageProvider = (*S3Service)(nil)
 
+// ErrNotSupported is returned for bucket settings the S3 endpoint does not implement, which is
+// common with S3-compatible services.
+var ErrNotSupported = errors.New("not supported by the S3 endpoint")
+
+// BucketAvailability tells whether a bucket name can be used. Names are global across AWS
+// accounts and regions.
+type BucketAvailability string
+
+const (
+	BucketAvailable BucketAvailability = "available"
+	// BucketOwned means the bucket exists in the service's region and the credentials can use it.
+	BucketOwned BucketAvailability = "owned"
+	// BucketOtherRegion means the bucket exists in another region, in this or another account.
+	BucketOtherRegion BucketAvailability = "other-region"
+	BucketTaken       BucketAvailability = "taken"
+)
+
 type S3Service struct {
 	client *s3.Client
+	region string
+	// customEndpoint is set for S3-compatible services, whose region comes with the endpoint.
+	customEndpoint bool
 }
 
-func NewS3Service(region, accessKeyID, secretAccessKey string) (*S3Service, error) {
+// NewS3Service creates the S3 client. A custom endpoint points it at an S3-compatible service
+// such as DigitalOcean Spaces, Cloudflare R2 or MinIO, which may need path-style addressing.
+func NewS3Service(region, accessKeyID, secretAccessKey, endpoint string, usePathStyle bool) (*S3Service, error) {
 	cfg, err := config.LoadDefaultConfig(
 		context.TODO(),
 		config.WithRegion(region),
@@ -37,12 +64,24 @@ func NewS3Service(region, accessKeyID, secretAccessKey string) (*S3Service, erro
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
 
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+			// Not every compatible service accepts the checksums the SDK adds by default.
+			o.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenRequired
+			o.ResponseChecksumValidation = aws.ResponseChecksumValidationWhenRequired
+		}
+		o.UsePathStyle = usePathStyle
+	})
+
 	return &S3Service{
-		client: s3.NewFromConfig(cfg),
+		client:         client,
+		region:         region,
+		customEndpoint: endpoint != "",
 	}, nil
 }
 
-func (s *S3Service) CreateBucket(ctx context.Context, bucketName string) error {
+func (s *S3Service) CreateBucket(ctx context.Context, bucketName string, options types.BucketOptions) error {
 	log := logger.WithFields(logrus.Fields{
 		"bucket":  bucketName,
 		"service": "s3",
@@ -56,6 +95,12 @@ func (s *S3Service) CreateBucket(ctx context.Context, bucketName string) error {
 		createBucketInput := s3.CreateBucketInput{
 			Bucket: aws.String(bucketName),
 		}
+		// us-east-1 is the default location and must not be given as a constraint.
+		if s.region != "us-east-1" && !s.customEndpoint {
+			createBucketInput.CreateBucketConfiguration = &s3types.CreateBucketConfiguration{
+				LocationConstraint: s3types.BucketLocationConstraint(s.region),
+			}
+		}
 
 		_, err := s.client.CreateBucket(ctx, &createBucketInput)
 		return err
@@ -65,14 +110,149 @@ func (s *S3Service) CreateBucket(ctx context.Context, bucketName string) error {
 		return fmt.Errorf("failed to create bucket %s: %w", bucketName, err)
 	}
 
+	if err := s.configureBucket(ctx, bucketName, options); err != nil {
+		return err
+	}
+
+	log.Info("S3 bucket created successfully")
+	return nil
+}
+
+// EnsureBucket creates the bucket if it does not exist yet and (re)applies its configuration.
+// It is used when resuming an install whose bucket step did not finish.
+func (s *S3Service) EnsureBucket(ctx context.Context, bucketName string, options types.BucketOptions) error {
+	exists, err := s.BucketExists(ctx, bucketName)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return s.CreateBucket(ctx, bucketName, options)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"bucket":  bucketName,
+		"service": "s3",
+	}).Info("S3 bucket already exists, reapplying configuration")
+
+	return s.configureBucket(ctx, bucketName, options)
+}
+
+func (s *S3Service) BucketExists(ctx context.Context, bucketName string) (bool, error) {
+	_, err := s.client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(bucketName),
+	})
+	if err != nil {
+		if s.isBucketNotFoundError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check bucket %s: %w", bucketName, err)
+	}
+
+	return true, nil
+}
+
+// BucketAvailability checks whether the bucket name is free, held by these credentials in the
+// service's region, or held elsewhere. Another account's bucket answers with 403 and a bucket
+// in another region with a redirect naming its region.
+func (s *S3Service) BucketAvailability(ctx context.Context, bucketName string) (BucketAvailability, string, error) {
+	output, err := s.client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(bucketName),
+	})
+	if err == nil {
+		region := aws.ToString(output.BucketRegion)
+		if region != "" && region != s.region && !s.customEndpoint {
+			return BucketOtherRegion, region, nil
+		}
+		return BucketOwned, region, nil
+	}
+
+	if s.isBucketNotFoundError(err) {
+		return BucketAvailable, "", nil
+	}
+
+	var responseErr *awshttp.ResponseError
+	if errors.As(err, &responseErr) {
+		switch responseErr.HTTPStatusCode() {
+		case http.StatusForbidden:
+			return BucketTaken, "", nil
+		case http.StatusMovedPermanently:
+			return BucketOtherRegion, responseErr.Response.Header.Get("X-Amz-Bucket-Region"), nil
+		}
+	}
+
+	return "", "", fmt.Errorf("failed to check bucket %s: %w", bucketName, err)
+}
+
+// inRegion sends a request to the region of a bucket other than the service's own. Compatible
+// services take their region from the endpoint.
+func (s *S3Service) inRegion(region string) func(*s3.Options) {
+	return func(o *s3.Options) {
+		if region != "" && !s.customEndpoint {
+			o.Region = region
+		}
+	}
+}
+
+// BucketEncryptionEnabled reports whether default server-side encryption is configured.
+func (s *S3Service) BucketEncryptionEnabled(ctx context.Context, bucketName string) (bool, error) {
+	output, err := s.client.GetBucketEncryption(ctx, &s3.GetBucketEncryptionInput{
+		Bucket: aws.String(bucketName),
+	})
+	if err != nil {
+		if apiErrorCode(err) == "ServerSideEncryptionConfigurationNotFoundError" {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get bucket encryption: %w", notSupported(err))
+	}
+
+	if output.ServerSideEncryptionConfiguration == nil {
+		return false, nil
+	}
+
+	for _, rule := range output.ServerSideEncryptionConfiguration.Rules {
+		if rule.ApplyServerSideEncryptionByDefault != nil && rule.ApplyServerSideEncryptionByDefault.SSEAlgorithm != "" {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// BucketHasPublicPolicy reports whether the bucket policy grants public read on the public/ prefix.
+func (s *S3Service) BucketHasPublicPolicy(ctx context.Context, bucketName string) (bool, error) {
+	output, err := s.client.GetBucketPolicy(ctx, &s3.GetBucketPolicyInput{
+		Bucket: aws.String(bucketName),
+	})
+	if err != nil {
+		if apiErrorCode(err) == "NoSuchBucketPolicy" {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get bucket policy: %w", notSupported(err))
+	}
+
+	return strings.Contains(aws.ToString(output.Policy), fmt.Sprintf("arn:aws:s3:::%s/public/*", bucketName)), nil
+}
+
+func (s *S3Service) configureBucket(ctx context.Context, bucketName string, options types.BucketOptions) error {
+	log := logger.WithFields(logrus.Fields{
+		"bucket":  bucketName,
+		"service": "s3",
+	})
+
+	// Services that do not implement a setting are left with their own defaults.
 	log.Info("Configuring bucket encryption")
-	if err := s.configureBucketEncryption(ctx, bucketName); err != nil {
+	if err := s.configureBucketEncryption(ctx, bucketName); errors.Is(err, ErrNotSupported) {
+		log.WithError(err).Warn("Skipping bucket encryption")
+	} else if err != nil {
 		log.WithError(err).Error("Failed to configure bucket encryption")
 		return fmt.Errorf("failed to configure bucket encryption: %w", err)
 	}
 
 	log.Info("Configuring bucket public access")
-	if err := s.configureBucketPublicAccess(ctx, bucketName); err != nil {
+	if err := s.configureBucketPublicAccess(ctx, bucketName); errors.Is(err, ErrNotSupported) {
+		log.WithError(err).Warn("Skipping bucket public access, public/ must be made readable on the provider")
+	} else if err != nil {
 		log.WithError(err).Error("Failed to configure bucket public access")
 		return fmt.Errorf("failed to configure bucket public access: %w", err)
 	}
@@ -83,10 +263,122 @@ func (s *S3Service) CreateBucket(ctx context.Context, bucketName string) error {
 		return fmt.Errorf("failed to create public folder: %w", err)
 	}
 
-	log.Info("S3 bucket created successfully")
+	for _, setting := range []struct {
+		name      string
+		configure func(context.Context, string, types.BucketOptions) error
+	}{
+		{"CORS", s.configureBucketCORS},
+		{"versioning", s.configureBucketVersioning},
+		{"lifecycle", s.configureBucketLifecycle},
+		{"tags", s.configureBucketTags},
+	} {
+		log.Infof("Configuring bucket %s", setting.name)
+		if err := setting.configure(ctx, bucketName, options); errors.Is(err, ErrNotSupported) {
+			log.WithError(err).Warnf("Skipping bucket %s", setting.name)
+		} else if err != nil {
+			log.WithError(err).Errorf("Failed to configure bucket %s", setting.name)
+			return fmt.Errorf("failed to configure bucket %s: %w", setting.name, err)
+		}
+	}
+
 	return nil
 }
 
+func (s *S3Service) configureBucketCORS(ctx context.Context, bucketName string, options types.BucketOptions) error {
+	cors := options.CORS
+	_, err := s.client.PutBucketCors(ctx, &s3.PutBucketCorsInput{
+		Bucket: aws.String(bucketName),
+		CORSConfiguration: &s3types.CORSConfiguration{
+			CORSRules: []s3types.CORSRule{
+				{
+					ID:             aws.String("frontend-uploads"),
+					AllowedOrigins: cors.AllowedOrigins,
+					AllowedMethods: cors.AllowedMethods,
+					AllowedHeaders: cors.AllowedHeaders,
+					ExposeHeaders:  cors.ExposeHeaders,
+					MaxAgeSeconds:  aws.Int32(cors.MaxAgeSeconds),
+				},
+			},
+		},
+	})
+	return notSupported(err)
+}
+
+// configureBucketVersioning enables versioning, or suspends it when it was turned off in the
+// configuration. Versioning can never be removed from a bucket once enabled.
+func (s *S3Service) configureBucketVersioning(ctx context.Context, bucketName string, options types.BucketOptions) error {
+	status := s3types.BucketVersioningStatusEnabled
+	if !options.Versioning {
+		output, err := s.client.GetBucketVersioning(ctx, &s3.GetBucketVersioningInput{
+			Bucket: aws.String(bucketName),
+		})
+		if err != nil {
+			return notSupported(err)
+		}
+		if output.Status != s3types.BucketVersioningStatusEnabled {
+			return nil
+		}
+		status = s3types.BucketVersioningStatusSuspended
+	}
+
+	_, err := s.client.PutBucketVersioning(ctx, &s3.PutBucketVersioningInput{
+		Bucket: aws.String(bucketName),
+		VersioningConfiguration: &s3types.VersioningConfiguration{
+			Status: status,
+		},
+	})
+	return notSupported(err)
+}
+
+// configureBucketLifecycle replaces the bucket's lifecycle rules with the configured ones and
+// removes them when none are left.
+func (s *S3Service) configureBucketLifecycle(ctx context.Context, bucketName string, options types.BucketOptions) error {
+	var rules []s3types.LifecycleRule
+	if options.TempExpirationDays > 0 {
+		rules = append(rules, s3types.LifecycleRule{
+			ID:         aws.String("expire-temp-uploads"),
+			Status:     s3types.ExpirationStatusEnabled,
+			Filter:     &s3types.LifecycleRuleFilter{Prefix: aws.String(options.TempPrefix)},
+			Expiration: &s3types.LifecycleExpiration{Days: aws.Int32(options.TempExpirationDays)},
+		})
+	}
+	if options.Versioning && options.NoncurrentExpirationDays > 0 {
+		rules = append(rules, s3types.LifecycleRule{
+			ID:     aws.String("expire-noncurrent-versions"),
+			Status: s3types.ExpirationStatusEnabled,
+			Filter: &s3types.LifecycleRuleFilter{Prefix: aws.String("")},
+			NoncurrentVersionExpiration: &s3types.NoncurrentVersionExpiration{
+				NoncurrentDays: aws.Int32(options.NoncurrentExpirationDays),
+			},
+		})
+	}
+	if options.AbortIncompleteUploadDays > 0 {
+		rules = append(rules, s3types.LifecycleRule{
+			ID:     aws.String("abort-incomplete-uploads"),
+			Status: s3types.ExpirationStatusEnabled,
+			Filter: &s3types.LifecycleRuleFilter{Prefix: aws.String("")},
+			AbortIncompleteMultipartUpload: &s3types.AbortIncompleteMultipartUpload{
+				DaysAfterInitiation: aws.Int32(options.AbortIncompleteUploadDays),
+			},
+		})
+	}
+
+	if len(rules) == 0 {
+		_, err := s.client.DeleteBucketLifecycle(ctx, &s3.DeleteBucketLifecycleInput{
+			Bucket: aws.String(bucketName),
+		})
+		return notSupported(err)
+	}
+
+	_, err := s.client.PutBucketLifecycleConfiguration(ctx, &s3.PutBucketLifecycleConfigurationInput{
+		Bucket: aws.String(bucketName),
+		LifecycleConfiguration: &s3types.BucketLifecycleConfiguration{
+			Rules: rules,
+		},
+	})
+	return notSupported(err)
+}
+
 func (s *S3Service) configureBucketEncryption(ctx context.Context, bucketName string) error {
 	encryptionInput := &s3.PutBucketEncryptionInput{
 		Bucket: aws.String(bucketName),
@@ -103,7 +395,7 @@ func (s *S3Service) configureBucketEncryption(ctx context.Context, bucketName st
 	}
 
 	_, err := s.client.PutBucketEncryption(ctx, encryptionInput)
-	return err
+	return notSupported(err)
 }
 
 func (s *S3Service) configureBucketPublicAccess(ctx context.Context, bucketName string) error {
@@ -117,7 +409,8 @@ func (s *S3Service) configureBucketPublicAccess(ctx context.Context, bucketName
 		},
 	}
 
-	if _, err := s.client.PutPublicAccessBlock(ctx, publicAccessInput); err != nil {
+	// Services without public access blocks have nothing standing in the way of the policy.
+	if _, err := s.client.PutPublicAccessBlock(ctx, publicAccessInput); err != nil && apiErrorCode(err) != "NotImplemented" {
 		return err
 	}
 
@@ -143,7 +436,7 @@ func (s *S3Service) configureBucketPublicAccess(ctx context.Context, bucketName
 	}
 
 	_, err := s.client.PutBucketPolicy(ctx, policyInput)
-	return err
+	return notSupported(err)
 }
 
 func (s *S3Service) createPublicFolder(ctx context.Context, bucketName string) error {
@@ -155,6 +448,63 @@ func (s *S3Service) createPublicFolder(ctx context.Context, bucketName string) e
 	return err
 }
 
+// UploadObject stores body under key. The body must be seekable so the request can be signed
+// and retried.
+func (s *S3Service) UploadObject(ctx context.Context, bucketName, key string, body io.ReadSeeker) error {
+	log := logger.WithFields(logrus.Fields{
+		"bucket":  bucketName,
+		"key":     key,
+		"service": "s3",
+	})
+
+	log.Info("Uploading object")
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+		Body:   body,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload %s to bucket %s: %w", key, bucketName, err)
+	}
+
+	return nil
+}
+
+// DownloadObject writes the object stored under key to w.
+func (s *S3Service) DownloadObject(ctx context.Context, bucketName, key string, w io.Writer) error {
+	output, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to download %s from bucket %s: %w", key, bucketName, err)
+	}
+	defer output.Body.Close()
+
+	if _, err := io.Copy(w, output.Body); err != nil {
+		return fmt.Errorf("failed to download %s from bucket %s: %w", key, bucketName, err)
+	}
+
+	return nil
+}
+
+// HasObjects reports whether any object is stored under prefix. A missing bucket holds none.
+func (s *S3Service) HasObjects(ctx context.Context, bucketName, prefix string) (bool, error) {
+	output, err := s.client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+		Bucket:  aws.String(bucketName),
+		Prefix:  aws.String(prefix),
+		MaxKeys: aws.Int32(1),
+	})
+	if err != nil {
+		if s.isBucketNotFoundError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to list objects in bucket %s: %w", bucketName, err)
+	}
+
+	return len(output.Contents) > 0, nil
+}
+
 func (s *S3Service) DeleteBucket(ctx context.Context, bucketName string) error {
 	log := logger.WithFields(logrus.Fields{
 		"bucket":  bucketName,
@@ -193,60 +543,6 @@ func (s *S3Service) DeleteBucket(ctx context.Context, bucketName string) error {
 	return nil
 }
 
-func (s *S3Service) emptyBucket(ctx context.Context, bucketName string) error {
-	log := logger.WithFields(logrus.Fields{
-		"bucket":  bucketName,
-		"service": "s3",
-		"action":  "empty",
-	})
-
-	log.Info("Emptying S3 bucket")
-
-	listInput := &s3.ListObjectsV2Input{
-		Bucket: aws.String(bucketName),
-	}
-
-	for {
-		listOutput, err := s.client.ListObjectsV2(ctx, listInput)
-		if err != nil {
-			return fmt.Errorf("failed to list objects in bucket: %w", err)
-		}
-
-		if len(listOutput.Contents) == 0 {
-			break
-		}
-
-		objectIds := make([]s3types.ObjectIdentifier, len(listOutput.Contents))
-		for i, obj := range listOutput.Contents {
-			objectIds[i] = s3types.ObjectIdentifier{
-				Key: obj.Key,
-			}
-		}
-
-		deleteInput := &s3.DeleteObjectsInput{
-			Bucket: aws.String(bucketName),
-			Delete: &s3types.Delete{
-				Objects: objectIds,
-			},
-		}
-
-		_, err = s.client.DeleteObjects(ctx, deleteInput)
-		if err != nil {
-			return fmt.Errorf("failed to delete objects from bucket: %w", err)
-		}
-
-		log.Infof("Deleted %d objects from bucket", len(objectIds))
-
-		if listOutput.IsTruncated == nil || !*listOutput.IsTruncated {
-			break
-		}
-		listInput.ContinuationToken = listOutput.NextContinuationToken
-	}
-
-	log.Info("S3 bucket emptied successfully")
-	return nil
-}
-
 func (s *S3Service) isBucketNotFoundError(err error) bool {
 	if err == nil {
 		return false
@@ -264,3 +560,19 @@ func (s *S3Service) isBucketNotFoundError(err error) bool {
 
 	return false
 }
+
+// notSupported marks errors of operations the endpoint does not implement with ErrNotSupported.
+func notSupported(err error) error {
+	if apiErrorCode(err) == "NotImplemented" {
+		return fmt.Errorf("%w: %w", ErrNotSupported, err)
+	}
+	return err
+}
+
+func apiErrorCode(err error) string {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode()
+	}
+	return ""
+}
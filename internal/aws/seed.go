@@ -0,0 +1,280 @@
+package aws
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/CaioDGallo/easy-cli/internal/logger"
+	"github.com/CaioDGallo/easy-cli/internal/retry"
+	"github.com/CaioDGallo/easy-cli/internal/types"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultContentType = "application/octet-stream"
+
+// SeedBucket copies the files of source into the bucket, keeping their relative keys. Objects
+// whose ETag already matches are skipped, so seeding again only writes what changed. Content
+// types come from the file extensions and every object gets cacheControl.
+func (s *S3Service) SeedBucket(ctx context.Context, bucketName string, source types.SeedSource, cacheControl string) error {
+	log := logger.WithFields(logrus.Fields{
+		"bucket":  bucketName,
+		"source":  source.String(),
+		"service": "s3",
+		"action":  "seed",
+	})
+
+	log.Info("Seeding S3 bucket")
+
+	existing, err := s.objectETags(ctx, bucketName, "")
+	if err != nil {
+		return err
+	}
+
+	var written, unchanged int
+	if source.Bucket != "" {
+		written, unchanged, err = s.seedFromBucket(ctx, bucketName, source, cacheControl, existing)
+	} else {
+		written, unchanged, err = s.seedFromDir(ctx, bucketName, source.Dir, cacheControl, existing)
+	}
+	if err != nil {
+		log.WithError(err).Error("Failed to seed bucket")
+		return err
+	}
+
+	log.WithFields(logrus.Fields{
+		"written":   written,
+		"unchanged": unchanged,
+	}).Info("S3 bucket seeded successfully")
+	return nil
+}
+
+// SeedSourceExists reports whether a seed source can be read: a directory on disk, or a bucket
+// the credentials can reach, in any region.
+func (s *S3Service) SeedSourceExists(ctx context.Context, source types.SeedSource) (bool, error) {
+	if source.Bucket == "" {
+		info, err := os.Stat(source.Dir)
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return info.IsDir(), nil
+	}
+
+	_, exists, err := s.seedBucketRegion(ctx, source.Bucket)
+	return exists, err
+}
+
+// seedBucketRegion returns the region of a template bucket, which need not be the region of the
+// client buckets the service is built for.
+func (s *S3Service) seedBucketRegion(ctx context.Context, bucketName string) (string, bool, error) {
+	availability, region, err := s.BucketAvailability(ctx, bucketName)
+	if err != nil {
+		return "", false, err
+	}
+
+	switch availability {
+	case BucketAvailable:
+		return "", false, nil
+	case BucketTaken:
+		return "", false, fmt.Errorf("access to seed bucket %s is denied", bucketName)
+	}
+	return region, true, nil
+}
+
+func (s *S3Service) seedFromDir(ctx context.Context, bucketName, dir, cacheControl string, existing map[string]string) (int, int, error) {
+	var written, unchanged int
+
+	err := filepath.WalkDir(dir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// Dotfiles such as .DS_Store or .gitkeep are never part of the seed.
+		if filePath != dir && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+
+		file, err := os.Open(filePath)
+		if err != nil {
+			return fmt.Errorf("failed to open seed file: %w", err)
+		}
+		defer file.Close()
+
+		etag, err := fileETag(file)
+		if err != nil {
+			return fmt.Errorf("failed to read seed file %s: %w", filePath, err)
+		}
+		if existing[key] == etag {
+			unchanged++
+			return nil
+		}
+
+		err = retry.Do(ctx, retry.DefaultConfig(), func() error {
+			if _, err := file.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+				Bucket:       aws.String(bucketName),
+				Key:          aws.String(key),
+				Body:         file,
+				ContentType:  aws.String(contentType(key)),
+				CacheControl: cacheControlOrNil(cacheControl),
+			})
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to upload %s to bucket %s: %w", key, bucketName, err)
+		}
+
+		written++
+		return nil
+	})
+	if err != nil {
+		return written, unchanged, fmt.Errorf("failed to seed bucket from %s: %w", dir, err)
+	}
+
+	return written, unchanged, nil
+}
+
+// seedFromBucket copies objects server-side, so nothing passes through this machine. The
+// template bucket is listed in its own region; copies are requests to the client's bucket, which
+// S3 accepts from a source in any region. Metadata is replaced rather than copied so the seeded
+// objects get the same headers as uploaded ones.
+func (s *S3Service) seedFromBucket(ctx context.Context, bucketName string, source types.SeedSource, cacheControl string, existing map[string]string) (int, int, error) {
+	var written, unchanged int
+
+	sourceRegion, exists, err := s.seedBucketRegion(ctx, source.Bucket)
+	if err != nil {
+		return written, unchanged, err
+	}
+	if !exists {
+		return written, unchanged, fmt.Errorf("seed bucket %s does not exist", source.Bucket)
+	}
+
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(source.Bucket),
+		Prefix: aws.String(source.Prefix),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx, s.inRegion(sourceRegion))
+		if err != nil {
+			return written, unchanged, fmt.Errorf("failed to list seed objects in bucket %s: %w", source.Bucket, err)
+		}
+
+		for _, object := range page.Contents {
+			sourceKey := aws.ToString(object.Key)
+			key := strings.TrimPrefix(sourceKey, source.Prefix)
+			// Folder placeholders and dotfiles are skipped, as with a local directory.
+			if key == "" || strings.HasSuffix(key, "/") || isHidden(key) {
+				continue
+			}
+			if existing[key] == aws.ToString(object.ETag) {
+				unchanged++
+				continue
+			}
+
+			copySource := (&url.URL{Path: source.Bucket + "/" + sourceKey}).EscapedPath()
+			err := retry.Do(ctx, retry.DefaultConfig(), func() error {
+				_, err := s.client.CopyObject(ctx, &s3.CopyObjectInput{
+					Bucket:            aws.String(bucketName),
+					Key:               aws.String(key),
+					CopySource:        aws.String(copySource),
+					MetadataDirective: s3types.MetadataDirectiveReplace,
+					ContentType:       aws.String(contentType(key)),
+					CacheControl:      cacheControlOrNil(cacheControl),
+				})
+				return err
+			})
+			if err != nil {
+				return written, unchanged, fmt.Errorf("failed to copy %s from bucket %s: %w", sourceKey, source.Bucket, err)
+			}
+
+			written++
+		}
+	}
+
+	return written, unchanged, nil
+}
+
+// objectETags maps the keys under prefix to their ETags.
+func (s *S3Service) objectETags(ctx context.Context, bucketName, prefix string) (map[string]string, error) {
+	etags := make(map[string]string)
+
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucketName),
+		Prefix: aws.String(prefix),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects in bucket %s: %w", bucketName, err)
+		}
+
+		for _, object := range page.Contents {
+			etags[strings.TrimPrefix(aws.ToString(object.Key), prefix)] = aws.ToString(object.ETag)
+		}
+	}
+
+	return etags, nil
+}
+
+// fileETag returns the ETag S3 gives the file when it is uploaded in a single request: its
+// quoted MD5.
+func fileETag(r io.Reader) (string, error) {
+	hash := md5.New()
+	if _, err := io.Copy(hash, r); err != nil {
+		return "", err
+	}
+	return `"` + hex.EncodeToString(hash.Sum(nil)) + `"`, nil
+}
+
+func contentType(key string) string {
+	if t := mime.TypeByExtension(path.Ext(key)); t != "" {
+		return t
+	}
+	return defaultContentType
+}
+
+func cacheControlOrNil(cacheControl string) *string {
+	if cacheControl == "" {
+		return nil
+	}
+	return aws.String(cacheControl)
+}
+
+func isHidden(key string) bool {
+	for _, part := range strings.Split(key, "/") {
+		if strings.HasPrefix(part, ".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/CaioDGallo/easy-cli/internal/types"
+)
+
+// fakeRegionalS3 answers like S3 for a client bucket and a template bucket in different regions:
+// requests signed for the wrong region of a bucket are redirected.
+type fakeRegionalS3 struct {
+	regions map[string]string
+	objects map[string][]string
+
+	mu     sync.Mutex
+	copied []string
+}
+
+func (f *fakeRegionalS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	region, ok := f.regions[bucket]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if !strings.Contains(r.Header.Get("Authorization"), "/"+region+"/s3/") {
+		w.Header().Set("X-Amz-Bucket-Region", region)
+		w.WriteHeader(http.StatusMovedPermanently)
+		fmt.Fprint(w, `<Error><Code>PermanentRedirect</Code></Error>`)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodHead:
+		w.Header().Set("X-Amz-Bucket-Region", region)
+	case r.Method == http.MethodGet && key == "":
+		fmt.Fprint(w, `<ListBucketResult><IsTruncated>false</IsTruncated>`)
+		for _, objectKey := range f.objects[bucket] {
+			fmt.Fprintf(w, `<Contents><Key>%s</Key><ETag>"etag"</ETag></Contents>`, objectKey)
+		}
+		fmt.Fprint(w, `</ListBucketResult>`)
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "":
+		f.mu.Lock()
+		f.copied = append(f.copied, r.Header.Get("X-Amz-Copy-Source")+" -> "+bucket+"/"+key)
+		f.mu.Unlock()
+		fmt.Fprint(w, `<CopyObjectResult><ETag>"etag"</ETag></CopyObjectResult>`)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func TestSeedFromBucketInAnotherRegion(t *testing.T) {
+	fake := &fakeRegionalS3{
+		regions: map[string]string{"easy-acme": "sa-east-1", "easy-templates": "us-east-1"},
+		objects: map[string][]string{"easy-templates": {"pro/logo.png", "pro/.DS_Store"}},
+	}
+	s3Service := newTestS3Service(t, fake, "sa-east-1")
+	source := types.SeedSource{Bucket: "easy-templates", Prefix: "pro/"}
+
+	exists, err := s3Service.SeedSourceExists(context.Background(), source)
+	if err != nil || !exists {
+		t.Fatalf("SeedSourceExists() = %t, %v, want true", exists, err)
+	}
+
+	if err := s3Service.SeedBucket(context.Background(), "easy-acme", source, ""); err != nil {
+		t.Fatalf("SeedBucket() error = %v", err)
+	}
+
+	want := []string{"easy-templates/pro/logo.png -> easy-acme/logo.png"}
+	if strings.Join(fake.copied, ",") != strings.Join(want, ",") {
+		t.Errorf("copied %q, want %q", fake.copied, want)
+	}
+}
+
+func TestSeedSourceExistsMissingBucket(t *testing.T) {
+	fake := &fakeRegionalS3{regions: map[string]string{"easy-acme": "sa-east-1"}}
+	s3Service := newTestS3Service(t, fake, "sa-east-1")
+
+	exists, err := s3Service.SeedSourceExists(context.Background(), types.SeedSource{Bucket: "easy-templates"})
+	if err != nil || exists {
+		t.Errorf("SeedSourceExists() = %t, %v, want false", exists, err)
+	}
+}
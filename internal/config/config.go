@@ -2,9 +2,16 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 
+	"github.com/CaioDGallo/easy-cli/internal/gitprovider"
+	"github.com/CaioDGallo/easy-cli/internal/types"
 	"github.com/joho/godotenv"
 )
 
@@ -16,6 +23,8 @@ type Config struct {
 	SMTP        SMTPConfig
 	Repository  RepositoryConfig
 	Application ApplicationConfig
+	State       StateConfig
+	DNS         DNSConfig
 }
 
 type DatabaseConfig struct {
@@ -24,22 +33,147 @@ type DatabaseConfig struct {
 	User     string
 	Password string
 	DBName   string
+	// SSLMode is a libpq sslmode: disable, require, verify-ca or verify-full. SSLRootCert is the
+	// CA certificate the server is verified against.
+	SSLMode     string
+	SSLRootCert string
+	// ConnectTimeout is in seconds; 0 waits indefinitely.
+	ConnectTimeout  int64
+	ApplicationName string
+	// Template names the template set new client databases are cloned from by default.
+	Template string
 }
 
+var DatabaseSSLModes = []string{"disable", "require", "verify-ca", "verify-full"}
+
 type VercelConfig struct {
-	Token            string
-	TeamID           string
-	FrontendRepoUuid string
+	Token  string
+	TeamID string
 }
 
 type AWSConfig struct {
 	Region          string
 	AccessKeyID     string
 	SecretAccessKey string
+	// S3Endpoint points S3 at a compatible service such as DigitalOcean Spaces, Cloudflare R2 or
+	// MinIO. S3PublicURL is the public base URL of a bucket, with {bucket} standing for its name.
+	S3Endpoint     string
+	S3UsePathStyle bool
+	S3PublicURL    string
+	// Bucket holds the settings applied to every client bucket; CORS also allows each client's
+	// own frontend origins.
+	Bucket types.BucketOptions
+	// SeedSource is copied into new client buckets: a local directory or s3://bucket/prefix.
+	// Clients can override it, and it is empty when buckets start out empty.
+	SeedSource       string
+	SeedCacheControl string
+	// ClientCredentials gives each client an IAM user limited to its own bucket, whose access
+	// keys the backend uses instead of ours. IAMEndpoint points IAM at a local stand-in.
+	ClientCredentials bool
+	IAMEndpoint       string
+	// BackupBucket receives `db backup --s3` archives instead of the client's own bucket, so they
+	// outlive the client. It is in Region.
+	BackupBucket string
+}
+
+// S3_PUBLIC_URL placeholders, replaced with the bucket name and region.
+const (
+	bucketPlaceholder = "{bucket}"
+	regionPlaceholder = "{region}"
+)
+
+var regionRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// CORSMethods are the methods S3 accepts in a CORS rule.
+var CORSMethods = []string{"GET", "PUT", "POST", "DELETE", "HEAD"}
+
+// BucketRegion returns the region of a client bucket, which defaults to AWS_REGION.
+func (a AWSConfig) BucketRegion(region string) string {
+	if region == "" {
+		return a.Region
+	}
+	return region
+}
+
+// BucketURL returns the public base URL of a bucket in a region. Without S3_PUBLIC_URL, it is
+// the regional virtual-hosted URL on AWS, or derived from the endpoint and addressing style.
+func (a AWSConfig) BucketURL(bucket, region string) string {
+	region = a.BucketRegion(region)
+	if a.S3PublicURL != "" {
+		publicURL := strings.NewReplacer(bucketPlaceholder, bucket, regionPlaceholder, region).Replace(a.S3PublicURL)
+		return strings.TrimSuffix(publicURL, "/")
+	}
+	if a.S3Endpoint == "" {
+		// us-east-1 keeps the legacy global host that existing clients were set up with.
+		if region == "us-east-1" {
+			return fmt.Sprintf("https://%s.s3.amazonaws.com", bucket)
+		}
+		return fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, region)
+	}
+
+	endpoint, err := url.Parse(strings.TrimSuffix(a.S3Endpoint, "/"))
+	if err != nil {
+		return ""
+	}
+	if a.S3UsePathStyle {
+		return endpoint.JoinPath(bucket).String()
+	}
+	endpoint.Host = bucket + "." + endpoint.Host
+	return endpoint.String()
+}
+
+func (a AWSConfig) Validate() error {
+	if a.AccessKeyID == "" {
+		return fmt.Errorf("AWS_ACCESS_KEY_ID environment variable is required")
+	}
+	if a.SecretAccessKey == "" {
+		return fmt.Errorf("AWS_SECRET_ACCESS_KEY environment variable is required")
+	}
+	if err := ValidateRegion(a.Region); err != nil {
+		return fmt.Errorf("AWS_REGION: %w", err)
+	}
+	if a.S3Endpoint != "" {
+		endpoint, err := url.Parse(a.S3Endpoint)
+		if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			return fmt.Errorf("S3_ENDPOINT must be an http or https URL, got %q", a.S3Endpoint)
+		}
+	}
+	if a.S3PublicURL != "" && !strings.Contains(a.S3PublicURL, bucketPlaceholder) {
+		return fmt.Errorf("S3_PUBLIC_URL must contain %s, since every client has its own bucket", bucketPlaceholder)
+	}
+	if a.ClientCredentials && a.S3Endpoint != "" && a.IAMEndpoint == "" {
+		return fmt.Errorf("S3_CLIENT_CREDENTIALS needs IAM_ENDPOINT when S3_ENDPOINT is set, since S3-compatible services have no IAM")
+	}
+
+	bucket := a.Bucket
+	for key, value := range map[string]int32{
+		"S3_CORS_MAX_AGE":               bucket.CORS.MaxAgeSeconds,
+		"S3_TEMP_EXPIRATION_DAYS":       bucket.TempExpirationDays,
+		"S3_NONCURRENT_EXPIRATION_DAYS": bucket.NoncurrentExpirationDays,
+		"S3_ABORT_UPLOAD_DAYS":          bucket.AbortIncompleteUploadDays,
+	} {
+		if value < 0 {
+			return fmt.Errorf("%s cannot be negative", key)
+		}
+	}
+	for _, method := range bucket.CORS.AllowedMethods {
+		if !slices.Contains(CORSMethods, method) {
+			return fmt.Errorf("unsupported S3_CORS_METHODS method %q (expected %s)", method, strings.Join(CORSMethods, ", "))
+		}
+	}
+	if len(bucket.CORS.AllowedMethods) == 0 {
+		return fmt.Errorf("S3_CORS_METHODS cannot be empty")
+	}
+	if !strings.HasSuffix(bucket.TempPrefix, "/") || bucket.TempPrefix == "/" {
+		return fmt.Errorf("S3_TEMP_PREFIX must be a folder such as tmp/, got %q", bucket.TempPrefix)
+	}
+	return nil
 }
 
 type DOConfig struct {
 	Token string
+	// App holds the global App Platform settings; clients can override each of them.
+	App types.AppSpecOptions
 }
 
 type SMTPConfig struct {
@@ -50,12 +184,70 @@ type SMTPConfig struct {
 }
 
 type RepositoryConfig struct {
-	Backend  string
-	Frontend string
+	Backend  types.Repository
+	Frontend types.Repository
+}
+
+func ValidateRegion(region string) error {
+	if !regionRegex.MatchString(region) {
+		return fmt.Errorf("invalid region %q", region)
+	}
+	return nil
+}
+
+func (d DatabaseConfig) Validate() error {
+	if d.Port < 1 || d.Port > 65535 {
+		return fmt.Errorf("DB_PORT must be between 1 and 65535")
+	}
+	if !slices.Contains(DatabaseSSLModes, d.SSLMode) {
+		return fmt.Errorf("unsupported DB_SSLMODE %q (expected %s)", d.SSLMode, strings.Join(DatabaseSSLModes, ", "))
+	}
+	if d.SSLRootCert != "" {
+		if d.SSLMode == "disable" {
+			return fmt.Errorf("DB_SSLROOTCERT cannot be used with DB_SSLMODE=disable")
+		}
+		if _, err := os.Stat(d.SSLRootCert); err != nil {
+			return fmt.Errorf("DB_SSLROOTCERT: %w", err)
+		}
+	}
+	if d.ConnectTimeout < 0 {
+		return fmt.Errorf("DB_CONNECT_TIMEOUT cannot be negative")
+	}
+	return nil
+}
+
+// Validate checks both repositories against the rules of their git provider. The frontend
+// repository is deployed by Vercel, which needs its ID on some providers.
+func (r RepositoryConfig) Validate() error {
+	if err := gitprovider.Validate(r.Backend, false); err != nil {
+		return fmt.Errorf("invalid backend repository: %w", err)
+	}
+	if err := gitprovider.Validate(r.Frontend, true); err != nil {
+		return fmt.Errorf("invalid frontend repository: %w", err)
+	}
+	return nil
+}
+
+// DNSConfig selects the provider that publishes custom domain records. Without one, the records
+// are only printed.
+type DNSConfig struct {
+	Provider string
+	// Route53 uses the AWS credentials. Without a hosted zone ID, the zone is looked up by name;
+	// Endpoint points it at a Route53-compatible stand-in.
+	Route53HostedZoneID string
+	Route53Endpoint     string
+	TTL                 int64
 }
 
 type ApplicationConfig struct {
 	NamePrefix string
+	// Environment labels every resource, so the clients of several environments sharing an
+	// account can be told apart.
+	Environment string
+}
+
+type StateConfig struct {
+	Dir string
 }
 
 func Load() (*Config, error) {
@@ -68,26 +260,131 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("error loading .env file from %s: %w", envFile, err)
 	}
 
+	instanceCount, err := getEnvInt64("DO_INSTANCE_COUNT")
+	if err != nil {
+		return nil, err
+	}
+
+	httpPort, err := getEnvInt64("DO_HTTP_PORT")
+	if err != nil {
+		return nil, err
+	}
+
+	dbPort, err := getEnvInt64("DB_PORT")
+	if err != nil {
+		return nil, err
+	}
+	if dbPort == 0 {
+		dbPort = 5432
+	}
+
+	dbConnectTimeout := int64(10)
+	if os.Getenv("DB_CONNECT_TIMEOUT") != "" {
+		if dbConnectTimeout, err = getEnvInt64("DB_CONNECT_TIMEOUT"); err != nil {
+			return nil, err
+		}
+	}
+
+	s3UsePathStyle := false
+	if value := os.Getenv("S3_FORCE_PATH_STYLE"); value != "" {
+		if s3UsePathStyle, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("S3_FORCE_PATH_STYLE must be true or false: %w", err)
+		}
+	}
+
+	// Client credentials are on by default on AWS, where IAM is available.
+	clientCredentials := os.Getenv("S3_ENDPOINT") == "" || os.Getenv("IAM_ENDPOINT") != ""
+	if value := os.Getenv("S3_CLIENT_CREDENTIALS"); value != "" {
+		if clientCredentials, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("S3_CLIENT_CREDENTIALS must be true or false: %w", err)
+		}
+	}
+
+	bucketVersioning := false
+	if value := os.Getenv("S3_VERSIONING"); value != "" {
+		if bucketVersioning, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("S3_VERSIONING must be true or false: %w", err)
+		}
+	}
+
+	bucketDays := map[string]int64{
+		"S3_CORS_MAX_AGE":               3000,
+		"S3_TEMP_EXPIRATION_DAYS":       1,
+		"S3_NONCURRENT_EXPIRATION_DAYS": 30,
+		"S3_ABORT_UPLOAD_DAYS":          7,
+	}
+	for key := range bucketDays {
+		if os.Getenv(key) == "" {
+			continue
+		}
+		if bucketDays[key], err = getEnvInt64(key); err != nil {
+			return nil, err
+		}
+	}
+
+	dnsTTL, err := getEnvInt64("DNS_TTL")
+	if err != nil {
+		return nil, err
+	}
+	if dnsTTL == 0 {
+		dnsTTL = 300
+	}
+
 	config := &Config{
 		Database: DatabaseConfig{
-			Host:     getEnvOrDefault("DB_HOST", "your-database-host.rds.amazonaws.com"),
-			Port:     5432,
-			User:     getEnvOrDefault("DB_USER", "postgres"),
-			Password: os.Getenv("DB_PASSWORD"),
-			DBName:   getEnvOrDefault("DB_NAME", "postgres"),
+			Host:            getEnvOrDefault("DB_HOST", "your-database-host.rds.amazonaws.com"),
+			Port:            int(dbPort),
+			User:            getEnvOrDefault("DB_USER", "postgres"),
+			Password:        os.Getenv("DB_PASSWORD"),
+			DBName:          getEnvOrDefault("DB_NAME", "postgres"),
+			SSLMode:         getEnvOrDefault("DB_SSLMODE", "disable"),
+			SSLRootCert:     os.Getenv("DB_SSLROOTCERT"),
+			ConnectTimeout:  dbConnectTimeout,
+			ApplicationName: getEnvOrDefault("DB_APPLICATION_NAME", "easy-cli"),
+			Template:        getEnvOrDefault("DB_TEMPLATE", "demo"),
 		},
 		Vercel: VercelConfig{
-			Token:            os.Getenv("VERCEL_TOKEN"),
-			TeamID:           os.Getenv("VERCEL_TEAM_ID"),
-			FrontendRepoUuid: os.Getenv("VERCEL_FRONTEND_REPO_UUID"),
+			Token:  os.Getenv("VERCEL_TOKEN"),
+			TeamID: os.Getenv("VERCEL_TEAM_ID"),
 		},
 		AWS: AWSConfig{
 			Region:          getEnvOrDefault("AWS_REGION", "us-east-1"),
 			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
 			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			S3Endpoint:      os.Getenv("S3_ENDPOINT"),
+			S3UsePathStyle:  s3UsePathStyle,
+			S3PublicURL:     os.Getenv("S3_PUBLIC_URL"),
+			Bucket: types.BucketOptions{
+				CORS: types.CORSOptions{
+					AllowedOrigins: getEnvList("S3_CORS_ORIGINS", ""),
+					AllowedMethods: getEnvList("S3_CORS_METHODS", "GET,PUT,POST,HEAD"),
+					AllowedHeaders: getEnvList("S3_CORS_HEADERS", "*"),
+					ExposeHeaders:  getEnvList("S3_CORS_EXPOSE_HEADERS", "ETag"),
+					MaxAgeSeconds:  int32(bucketDays["S3_CORS_MAX_AGE"]),
+				},
+				Versioning:                bucketVersioning,
+				TempPrefix:                getEnvOrDefault("S3_TEMP_PREFIX", "tmp/"),
+				TempExpirationDays:        int32(bucketDays["S3_TEMP_EXPIRATION_DAYS"]),
+				NoncurrentExpirationDays:  int32(bucketDays["S3_NONCURRENT_EXPIRATION_DAYS"]),
+				AbortIncompleteUploadDays: int32(bucketDays["S3_ABORT_UPLOAD_DAYS"]),
+			},
+			SeedSource:        os.Getenv("S3_SEED_SOURCE"),
+			SeedCacheControl:  getEnvOrDefault("S3_SEED_CACHE_CONTROL", "public, max-age=86400"),
+			ClientCredentials: clientCredentials,
+			IAMEndpoint:       os.Getenv("IAM_ENDPOINT"),
+			BackupBucket:      os.Getenv("S3_BACKUP_BUCKET"),
 		},
 		DO: DOConfig{
 			Token: os.Getenv("DO_TOKEN"),
+			App: types.AppSpecOptions{
+				Tier:             os.Getenv("DO_APP_TIER"),
+				Region:           os.Getenv("DO_REGION"),
+				InstanceSizeSlug: os.Getenv("DO_INSTANCE_SIZE"),
+				InstanceCount:    instanceCount,
+				HTTPPort:         httpPort,
+				SourceDir:        os.Getenv("DO_SOURCE_DIR"),
+				DockerfilePath:   os.Getenv("DO_DOCKERFILE_PATH"),
+			},
 		},
 		SMTP: SMTPConfig{
 			Server:          getEnvOrDefault("SMTP_SERVER", "your-smtp-server.com"),
@@ -96,11 +393,30 @@ func Load() (*Config, error) {
 			DevEmail:        getEnvOrDefault("SMTP_DEV_EMAIL", "developer@yourdomain.com"),
 		},
 		Repository: RepositoryConfig{
-			Backend:  getEnvOrDefault("BACKEND_REPO", "your-org/your-backend-repo"),
-			Frontend: getEnvOrDefault("FRONTEND_REPO", "your-org/your-frontend-repo"),
+			Backend: types.Repository{
+				Provider: types.GitProvider(getEnvOrDefault("BACKEND_REPO_PROVIDER", string(types.GitProviderBitbucket))),
+				Name:     getEnvOrDefault("BACKEND_REPO", "your-org/your-backend-repo"),
+				ID:       os.Getenv("BACKEND_REPO_ID"),
+			},
+			Frontend: types.Repository{
+				Provider: types.GitProvider(getEnvOrDefault("FRONTEND_REPO_PROVIDER", string(types.GitProviderBitbucket))),
+				Name:     getEnvOrDefault("FRONTEND_REPO", "your-org/your-frontend-repo"),
+				// VERCEL_FRONTEND_REPO_UUID predates GitHub and GitLab support.
+				ID: getEnvOrDefault("FRONTEND_REPO_ID", os.Getenv("VERCEL_FRONTEND_REPO_UUID")),
+			},
 		},
 		Application: ApplicationConfig{
-			NamePrefix: getEnvOrDefault("APP_NAME_PREFIX", "your-app-prefix"),
+			NamePrefix:  getEnvOrDefault("APP_NAME_PREFIX", "your-app-prefix"),
+			Environment: getEnvOrDefault("APP_ENVIRONMENT", "production"),
+		},
+		State: StateConfig{
+			Dir: getEnvOrDefault("EASY_CLI_STATE_DIR", defaultStateDir()),
+		},
+		DNS: DNSConfig{
+			Provider:            os.Getenv("DNS_PROVIDER"),
+			Route53HostedZoneID: os.Getenv("ROUTE53_HOSTED_ZONE_ID"),
+			Route53Endpoint:     os.Getenv("ROUTE53_ENDPOINT"),
+			TTL:                 dnsTTL,
 		},
 	}
 
@@ -115,25 +431,31 @@ func (c *Config) Validate() error {
 	if c.Database.Password == "" {
 		return fmt.Errorf("DB_PASSWORD environment variable is required")
 	}
+	if err := c.Database.Validate(); err != nil {
+		return err
+	}
 	if c.Vercel.Token == "" {
 		return fmt.Errorf("VERCEL_TOKEN environment variable is required")
 	}
 	if c.Vercel.TeamID == "" {
 		return fmt.Errorf("VERCEL_TEAM_ID environment variable is required")
 	}
-	if c.Vercel.FrontendRepoUuid == "" {
-		return fmt.Errorf("VERCEL_FRONTEND_REPO_UUID environment variable is required")
-	}
 	if c.DO.Token == "" {
 		return fmt.Errorf("DO_TOKEN environment variable is required")
 	}
-	if c.AWS.AccessKeyID == "" {
-		return fmt.Errorf("AWS_ACCESS_KEY_ID environment variable is required")
+	if err := c.AWS.Validate(); err != nil {
+		return err
 	}
-	if c.AWS.SecretAccessKey == "" {
-		return fmt.Errorf("AWS_SECRET_ACCESS_KEY environment variable is required")
+	if err := c.Repository.Validate(); err != nil {
+		return err
 	}
-	// Note: SMTP, Repository, and Application configs are optional and have defaults
+	if c.DNS.Provider != "" && c.DNS.Provider != "route53" {
+		return fmt.Errorf("unsupported DNS_PROVIDER %q (expected route53 or empty)", c.DNS.Provider)
+	}
+	if c.DNS.TTL < 1 {
+		return fmt.Errorf("DNS_TTL must be at least 1")
+	}
+	// Note: SMTP and Application configs are optional and have defaults
 	return nil
 }
 
@@ -171,9 +493,41 @@ func findEnvFile() string {
 	return "" // No .env file found
 }
 
+func defaultStateDir() string {
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(homeDir, ".easy-cli", "state")
+	}
+	return filepath.Join(".easy-cli", "state")
+}
+
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+// getEnvList splits a comma-separated variable, dropping empty items.
+func getEnvList(key, defaultValue string) []string {
+	var items []string
+	for _, item := range strings.Split(getEnvOrDefault(key, defaultValue), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func getEnvInt64(key string) (int64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0, nil
+	}
+
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number: %w", key, err)
+	}
+
+	return parsed, nil
+}
@@ -0,0 +1,59 @@
+package config
+
+import "testing"
+
+func TestBucketURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		config AWSConfig
+		region string
+		want   string
+	}{
+		{
+			name:   "default region us-east-1 keeps the global host",
+			config: AWSConfig{Region: "us-east-1"},
+			want:   "https://acme.s3.amazonaws.com",
+		},
+		{
+			name:   "default region",
+			config: AWSConfig{Region: "eu-west-1"},
+			want:   "https://acme.s3.eu-west-1.amazonaws.com",
+		},
+		{
+			name:   "client region overrides the default",
+			config: AWSConfig{Region: "us-east-1"},
+			region: "sa-east-1",
+			want:   "https://acme.s3.sa-east-1.amazonaws.com",
+		},
+		{
+			name:   "client region us-east-1",
+			config: AWSConfig{Region: "eu-west-1"},
+			region: "us-east-1",
+			want:   "https://acme.s3.amazonaws.com",
+		},
+		{
+			name:   "virtual-hosted endpoint",
+			config: AWSConfig{Region: "nyc3", S3Endpoint: "https://nyc3.digitaloceanspaces.com"},
+			want:   "https://acme.nyc3.digitaloceanspaces.com",
+		},
+		{
+			name:   "path-style endpoint",
+			config: AWSConfig{Region: "us-east-1", S3Endpoint: "http://localhost:9000/", S3UsePathStyle: true},
+			want:   "http://localhost:9000/acme",
+		},
+		{
+			name:   "public URL template",
+			config: AWSConfig{Region: "us-east-1", S3PublicURL: "https://{bucket}.cdn.example.com/{region}/"},
+			region: "eu-central-1",
+			want:   "https://acme.cdn.example.com/eu-central-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.BucketURL("acme", tt.region); got != tt.want {
+				t.Errorf("BucketURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,313 @@
+package database
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/CaioDGallo/easy-cli/internal/logger"
+	"github.com/CaioDGallo/easy-cli/internal/types"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	backupManifestName = "manifest.json"
+	backupDumpFormat   = "pg_dump-custom"
+)
+
+// BackupSource identifies the database to back up and the client it belongs to.
+type BackupSource struct {
+	Client   string
+	Database string
+	Role     string
+}
+
+type RestoreOptions struct {
+	// Database is the database to recreate; it defaults to the one the archive was taken from.
+	Database string
+	// Role is given ownership of the restored database. It defaults to the role recorded in the
+	// archive and is skipped when that role does not exist.
+	Role string
+	// Replace drops an existing database first, disconnecting its sessions.
+	Replace bool
+}
+
+// BackupDatabase writes a gzip-compressed tar archive of the database to w. The archive holds a
+// pg_dump of the database and a manifest with its checksum and where it was taken from.
+func (p *PostgresService) BackupDatabase(source BackupSource, w io.Writer) (*types.BackupManifest, error) {
+	log := logger.WithFields(logrus.Fields{
+		"database": source.Database,
+		"service":  "postgres",
+		"action":   "backup",
+	})
+
+	db, err := p.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	exists, err := p.databaseExists(db, source.Database)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("database %s does not exist", source.Database)
+	}
+
+	var serverVersion string
+	if err := db.QueryRow(`SHOW server_version`).Scan(&serverVersion); err != nil {
+		return nil, fmt.Errorf("failed to get server version: %w", err)
+	}
+
+	dumpFile, err := os.CreateTemp("", "easy-cli-dump-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary dump file: %w", err)
+	}
+	defer os.Remove(dumpFile.Name())
+	defer dumpFile.Close()
+
+	// The archive compresses the dump, so pg_dump does not.
+	dump, err := p.command("pg_dump", source.Database, "--format=custom", "--compress=0", "--no-owner", "--no-privileges")
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("Dumping database")
+	hash := sha256.New()
+	dump.Stdout = io.MultiWriter(dumpFile, hash)
+	if err := run(dump); err != nil {
+		return nil, fmt.Errorf("failed to dump database %s: %w", source.Database, err)
+	}
+
+	size, err := dumpFile.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dump size: %w", err)
+	}
+	if _, err := dumpFile.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind dump file: %w", err)
+	}
+
+	manifest := &types.BackupManifest{
+		Version:       types.BackupManifestVersion,
+		Client:        source.Client,
+		Database:      source.Database,
+		Role:          source.Role,
+		Host:          p.config.Host,
+		ServerVersion: serverVersion,
+		CreatedAt:     time.Now().UTC(),
+		Dump: types.BackupFile{
+			Name:   source.Database + ".dump",
+			Format: backupDumpFormat,
+			Size:   size,
+			SHA256: hex.EncodeToString(hash.Sum(nil)),
+		},
+	}
+
+	log.WithField("size", size).Info("Writing backup archive")
+	if err := writeArchive(w, manifest, dumpFile); err != nil {
+		return nil, fmt.Errorf("failed to write backup archive: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// RestoreDatabase recreates a database from an archive written by BackupDatabase. The dump is
+// checked against the manifest before anything is changed.
+func (p *PostgresService) RestoreDatabase(r io.Reader, opts RestoreOptions) (*types.BackupManifest, error) {
+	manifest, dumpPath, err := extractArchive(r)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(dumpPath)
+
+	target := opts.Database
+	if target == "" {
+		target = manifest.Database
+	}
+	role := opts.Role
+	if role == "" {
+		role = manifest.Role
+	}
+
+	log := logger.WithFields(logrus.Fields{
+		"database": target,
+		"source":   manifest.Database,
+		"service":  "postgres",
+		"action":   "restore",
+	})
+
+	db, err := p.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	exists, err := p.databaseExists(db, target)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		if !opts.Replace {
+			return nil, fmt.Errorf("database %s already exists", target)
+		}
+		log.Warn("Dropping existing database")
+		if err := p.deleteDatabase(db, target); err != nil {
+			return nil, err
+		}
+	}
+
+	restore, err := p.command("pg_restore", target, "--no-owner", "--no-privileges", "--exit-on-error", "--single-transaction", dumpPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Exec(fmt.Sprintf(`CREATE DATABASE %s WITH TEMPLATE template0`, pq.QuoteIdentifier(target))); err != nil {
+		return nil, fmt.Errorf("failed to create database %s: %w", target, err)
+	}
+
+	log.Info("Restoring database")
+	if err := run(restore); err != nil {
+		if _, dropErr := db.Exec(fmt.Sprintf(`DROP DATABASE IF EXISTS %s`, pq.QuoteIdentifier(target))); dropErr != nil {
+			log.WithError(dropErr).Warn("Failed to drop partially restored database")
+		}
+		return nil, fmt.Errorf("failed to restore database %s: %w", target, err)
+	}
+
+	if role != "" {
+		roleExists, err := p.roleExists(db, role)
+		if err != nil {
+			return nil, err
+		}
+		if roleExists {
+			log.WithField("role", role).Info("Handing restored database to role")
+			if err := p.assignDatabase(db, target, role); err != nil {
+				return nil, err
+			}
+		} else {
+			log.WithField("role", role).Warn("Role does not exist, the restored database stays owned by the admin user")
+		}
+	}
+
+	log.Info("Database restored successfully")
+	return manifest, nil
+}
+
+func writeArchive(w io.Writer, manifest *types.BackupManifest, dump io.Reader) error {
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	// The manifest comes first so it can be read without going through the dump.
+	if err := tarWriter.WriteHeader(&tar.Header{
+		Name:    backupManifestName,
+		Mode:    0600,
+		Size:    int64(len(manifestData)),
+		ModTime: manifest.CreatedAt,
+	}); err != nil {
+		return err
+	}
+	if _, err := tarWriter.Write(manifestData); err != nil {
+		return err
+	}
+
+	if err := tarWriter.WriteHeader(&tar.Header{
+		Name:    manifest.Dump.Name,
+		Mode:    0600,
+		Size:    manifest.Dump.Size,
+		ModTime: manifest.CreatedAt,
+	}); err != nil {
+		return err
+	}
+	if _, err := io.Copy(tarWriter, dump); err != nil {
+		return err
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
+
+// openArchive reads the manifest of an archive and leaves the reader at the dump.
+func openArchive(r io.Reader) (*types.BackupManifest, *tar.Reader, error) {
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("not a backup archive: %w", err)
+	}
+	tarReader := tar.NewReader(gzipReader)
+
+	header, err := tarReader.Next()
+	if err != nil {
+		return nil, nil, fmt.Errorf("not a backup archive: %w", err)
+	}
+	if header.Name != backupManifestName {
+		return nil, nil, fmt.Errorf("not a backup archive: expected %s, found %s", backupManifestName, header.Name)
+	}
+
+	var manifest types.BackupManifest
+	if err := json.NewDecoder(tarReader).Decode(&manifest); err != nil {
+		return nil, nil, fmt.Errorf("invalid backup manifest: %w", err)
+	}
+	if manifest.Version != types.BackupManifestVersion {
+		return nil, nil, fmt.Errorf("unsupported backup manifest version %d (expected %d)", manifest.Version, types.BackupManifestVersion)
+	}
+	if manifest.Dump.Format != backupDumpFormat {
+		return nil, nil, fmt.Errorf("unsupported dump format %q", manifest.Dump.Format)
+	}
+
+	return &manifest, tarReader, nil
+}
+
+// extractArchive writes the dump of an archive to a temporary file and verifies its size and
+// checksum against the manifest. The caller removes the file.
+func extractArchive(r io.Reader) (*types.BackupManifest, string, error) {
+	manifest, tarReader, err := openArchive(r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	header, err := tarReader.Next()
+	if errors.Is(err, io.EOF) {
+		return nil, "", fmt.Errorf("backup archive has no dump")
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read backup archive: %w", err)
+	}
+	if header.Name != manifest.Dump.Name {
+		return nil, "", fmt.Errorf("backup archive holds %s, but its manifest describes %s", header.Name, manifest.Dump.Name)
+	}
+
+	dumpFile, err := os.CreateTemp("", "easy-cli-restore-*")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create temporary dump file: %w", err)
+	}
+	defer dumpFile.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(dumpFile, hash), tarReader)
+	if err != nil {
+		os.Remove(dumpFile.Name())
+		return nil, "", fmt.Errorf("failed to extract dump: %w", err)
+	}
+
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	if size != manifest.Dump.Size || checksum != manifest.Dump.SHA256 {
+		os.Remove(dumpFile.Name())
+		return nil, "", fmt.Errorf("dump %s does not match its manifest (size %d, sha256 %s)", manifest.Dump.Name, size, checksum)
+	}
+
+	return manifest, dumpFile.Name(), nil
+}
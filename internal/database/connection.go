@@ -0,0 +1,63 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// connectionString builds the libpq connection string of a database of the cluster. The driver
+// and the PostgreSQL client tools share it; the tools get the password through PGPASSWORD
+// instead, so it never shows up in the process list.
+func (p *PostgresService) connectionString(dbName string, includePassword bool) string {
+	params := [][2]string{
+		{"host", p.config.Host},
+		{"port", strconv.Itoa(p.config.Port)},
+		{"user", p.config.User},
+		{"dbname", dbName},
+		{"sslmode", p.config.SSLMode},
+		{"sslrootcert", p.config.SSLRootCert},
+		{"connect_timeout", strconv.FormatInt(p.config.ConnectTimeout, 10)},
+		{"application_name", p.config.ApplicationName},
+	}
+	if includePassword {
+		params = append(params, [2]string{"password", p.config.Password})
+	}
+
+	parts := make([]string, 0, len(params))
+	for _, param := range params {
+		if param[1] == "" {
+			continue
+		}
+		parts = append(parts, param[0]+"="+quoteConnectionValue(param[1]))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// quoteConnectionValue quotes a value for a key=value connection string.
+func quoteConnectionValue(value string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value)
+	return "'" + escaped + "'"
+}
+
+func (p *PostgresService) connect() (*sql.DB, error) {
+	return p.connectTo(p.config.DBName)
+}
+
+// connectTo opens a connection to a specific database of the cluster, for statements that only
+// apply to the database they run in.
+func (p *PostgresService) connectTo(dbName string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", p.connectionString(dbName, true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return db, nil
+}
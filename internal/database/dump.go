@@ -0,0 +1,117 @@
+package database
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/CaioDGallo/easy-cli/internal/logger"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// copyDatabase copies source into a new target database with pg_dump and pg_restore. Unlike
+// CREATE DATABASE ... TEMPLATE, the dump only takes a snapshot of the source, so sessions
+// connected to it are left alone. Objects are restored as owned by the admin user.
+func (p *PostgresService) copyDatabase(db *sql.DB, source, target string) error {
+	log := logger.WithFields(logrus.Fields{
+		"source":  source,
+		"target":  target,
+		"service": "postgres",
+	})
+
+	dump, err := p.command("pg_dump", source, "--format=custom", "--no-owner", "--no-privileges")
+	if err != nil {
+		return err
+	}
+	restore, err := p.command("pg_restore", target, "--no-owner", "--no-privileges", "--exit-on-error", "--single-transaction")
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(fmt.Sprintf(`CREATE DATABASE %s WITH TEMPLATE template0`, pq.QuoteIdentifier(target))); err != nil {
+		return fmt.Errorf("failed to create database %s: %w", target, err)
+	}
+
+	log.Info("Copying database with pg_dump")
+	if err := pipe(dump, restore); err != nil {
+		if _, dropErr := db.Exec(fmt.Sprintf(`DROP DATABASE IF EXISTS %s`, pq.QuoteIdentifier(target))); dropErr != nil {
+			log.WithError(dropErr).Warn("Failed to drop partially copied database")
+		}
+		return fmt.Errorf("failed to copy database %s into %s: %w", source, target, err)
+	}
+
+	return nil
+}
+
+// command prepares a PostgreSQL client tool that connects to dbName with the admin credentials.
+func (p *PostgresService) command(name, dbName string, args ...string) (*exec.Cmd, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s is required but was not found in PATH: %w", name, err)
+	}
+
+	cmd := exec.Command(path, append(args, "--dbname", p.connectionString(dbName, false))...)
+	cmd.Env = append(os.Environ(), "PGPASSWORD="+p.config.Password)
+
+	return cmd, nil
+}
+
+// pipe streams the output of producer into consumer and waits for both to exit.
+func pipe(producer, consumer *exec.Cmd) error {
+	var producerErr, consumerErr bytes.Buffer
+	producer.Stderr = &producerErr
+	consumer.Stderr = &consumerErr
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	producer.Stdout = writer
+	consumer.Stdin = reader
+
+	consumerStartErr := consumer.Start()
+	var producerStartErr error
+	if consumerStartErr == nil {
+		producerStartErr = producer.Start()
+	}
+
+	// Only the children keep the pipe open, so one side exiting unblocks the other.
+	reader.Close()
+	writer.Close()
+
+	if consumerStartErr != nil {
+		return fmt.Errorf("failed to start %s: %w", consumer.Path, consumerStartErr)
+	}
+	if producerStartErr != nil {
+		consumer.Wait()
+		return fmt.Errorf("failed to start %s: %w", producer.Path, producerStartErr)
+	}
+
+	producerWaitErr := producer.Wait()
+	consumerWaitErr := consumer.Wait()
+
+	if consumerWaitErr != nil {
+		return fmt.Errorf("%s failed: %w: %s", consumer.Path, consumerWaitErr, strings.TrimSpace(consumerErr.String()))
+	}
+	if producerWaitErr != nil {
+		return fmt.Errorf("%s failed: %w: %s", producer.Path, producerWaitErr, strings.TrimSpace(producerErr.String()))
+	}
+
+	return nil
+}
+
+// run runs a client tool and includes what it wrote to stderr in the error.
+func run(cmd *exec.Cmd) error {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", cmd.Path, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/CaioDGallo/easy-cli/internal/logger"
+	"github.com/CaioDGallo/easy-cli/internal/types"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// LabeledObject is a database or role whose comment holds easy-cli labels.
+type LabeledObject struct {
+	// Kind is "database" or "role".
+	Kind   string
+	Name   string
+	Labels types.ResourceLabels
+}
+
+// LabelDatabases records the labels as JSON in the comment of each database, replacing any
+// comment they had.
+func (p *PostgresService) LabelDatabases(labels types.ResourceLabels, dbNames ...string) error {
+	return p.comment("DATABASE", labels, dbNames...)
+}
+
+// LabelRole records the labels as JSON in the comment of the role.
+func (p *PostgresService) LabelRole(roleName string, labels types.ResourceLabels) error {
+	return p.comment("ROLE", labels, roleName)
+}
+
+func (p *PostgresService) comment(objectType string, labels types.ResourceLabels, names ...string) error {
+	if labels.IsZero() {
+		return nil
+	}
+
+	comment, err := json.Marshal(labels)
+	if err != nil {
+		return fmt.Errorf("failed to encode labels: %w", err)
+	}
+
+	db, err := p.connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	for _, name := range names {
+		logger.WithFields(logrus.Fields{
+			"object":  name,
+			"type":    objectType,
+			"service": "postgres",
+		}).Info("Labeling database object")
+
+		query := fmt.Sprintf(`COMMENT ON %s %s IS %s`, objectType, pq.QuoteIdentifier(name), pq.QuoteLiteral(string(comment)))
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to label %s %s: %w", objectType, name, err)
+		}
+	}
+
+	return nil
+}
+
+// LabeledObjects returns the databases and roles of the cluster that carry easy-cli labels.
+// Comments that are not labels are ignored.
+func (p *PostgresService) LabeledObjects() ([]LabeledObject, error) {
+	db, err := p.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var objects []LabeledObject
+	for _, source := range []struct{ kind, query string }{
+		{"database", `SELECT datname, shobj_description(oid, 'pg_database') FROM pg_database ORDER BY datname`},
+		{"role", `SELECT rolname, shobj_description(oid, 'pg_authid') FROM pg_roles ORDER BY rolname`},
+	} {
+		labeled, err := labeledObjects(db, source.kind, source.query)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, labeled...)
+	}
+
+	return objects, nil
+}
+
+func labeledObjects(db *sql.DB, kind, query string) ([]LabeledObject, error) {
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s comments: %w", kind, err)
+	}
+	defer rows.Close()
+
+	var objects []LabeledObject
+	for rows.Next() {
+		var name string
+		var comment sql.NullString
+		if err := rows.Scan(&name, &comment); err != nil {
+			return nil, fmt.Errorf("failed to read %s comment: %w", kind, err)
+		}
+		if !comment.Valid {
+			continue
+		}
+
+		var labels types.ResourceLabels
+		if err := json.Unmarshal([]byte(comment.String), &labels); err != nil || labels.IsZero() {
+			continue
+		}
+		objects = append(objects, LabeledObject{Kind: kind, Name: name, Labels: labels})
+	}
+
+	return objects, rows.Err()
+}
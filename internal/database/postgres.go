@@ -2,13 +2,14 @@ package database
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/CaioDGallo/easy-cli/internal/config"
 	"github.com/CaioDGallo/easy-cli/internal/interfaces"
 	"github.com/CaioDGallo/easy-cli/internal/logger"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 )
 
@@ -25,40 +26,140 @@ func NewPostgresService(cfg config.DatabaseConfig) *PostgresService {
 }
 
 func (p *PostgresService) CreateClientDatabase(mainDBName string) error {
-	return p.CreateClientDatabases(mainDBName, fmt.Sprintf("%s-hf", mainDBName))
+	return p.CreateClientDatabases(mainDBName, fmt.Sprintf("%s-hf", mainDBName), p.config.Template)
 }
 
-func (p *PostgresService) CreateClientDatabases(mainDBName, hangfireDBName string) error {
-	psqlInfo := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
-		p.config.Host, p.config.Port, p.config.User, p.config.Password, p.config.DBName)
+// CreateClientDatabases clones the client databases from the given template set.
+func (p *PostgresService) CreateClientDatabases(mainDBName, hangfireDBName, template string) error {
+	db, err := p.connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	templateMain, templateHangfire := TemplateDatabases(template)
+
+	if err := p.cloneDatabase(db, mainDBName, templateMain); err != nil {
+		return fmt.Errorf("failed to create main database: %w", err)
+	}
+
+	if err := p.cloneDatabase(db, hangfireDBName, templateHangfire); err != nil {
+		return fmt.Errorf("failed to create hangfire database: %w", err)
+	}
 
-	db, err := sql.Open("postgres", psqlInfo)
+	return nil
+}
+
+// EnsureClientDatabases only clones the client databases that do not exist yet.
+// It is used when resuming an install whose database step did not finish.
+func (p *PostgresService) EnsureClientDatabases(mainDBName, hangfireDBName, template string) error {
+	db, err := p.connect()
 	if err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
+		return err
 	}
 	defer db.Close()
 
-	if err := db.Ping(); err != nil {
-		return fmt.Errorf("failed to ping database: %w", err)
+	templateMain, templateHangfire := TemplateDatabases(template)
+
+	for _, target := range []struct{ name, template string }{
+		{mainDBName, templateMain},
+		{hangfireDBName, templateHangfire},
+	} {
+		exists, err := p.databaseExists(db, target.name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		if err := p.cloneDatabase(db, target.name, target.template); err != nil {
+			return fmt.Errorf("failed to create database %s: %w", target.name, err)
+		}
 	}
 
-	if err := p.killConnections(db, "demo"); err != nil {
-		return fmt.Errorf("failed to kill connections to template database: %w", err)
+	return nil
+}
+
+func (p *PostgresService) ClientDatabasesExist(mainDBName, hangfireDBName string) (bool, error) {
+	db, err := p.connect()
+	if err != nil {
+		return false, err
 	}
+	defer db.Close()
 
-	if err := p.createDatabase(db, mainDBName, "demo"); err != nil {
-		return fmt.Errorf("failed to create main database: %w", err)
+	for _, dbName := range []string{mainDBName, hangfireDBName} {
+		exists, err := p.databaseExists(db, dbName)
+		if err != nil {
+			return false, err
+		}
+		if !exists {
+			return false, nil
+		}
 	}
 
-	if err := p.killConnections(db, "demo-hf"); err != nil {
-		return fmt.Errorf("failed to kill connections to hangfire template database: %w", err)
+	return true, nil
+}
+
+func (p *PostgresService) DatabaseExists(dbName string) (bool, error) {
+	db, err := p.connect()
+	if err != nil {
+		return false, err
 	}
+	defer db.Close()
 
-	if err := p.createDatabase(db, hangfireDBName, "demo-hf"); err != nil {
-		return fmt.Errorf("failed to create hangfire database: %w", err)
+	return p.databaseExists(db, dbName)
+}
+
+// DatabaseSizes returns the size in bytes of each of the given databases that exists. Missing
+// databases are left out of the result.
+func (p *PostgresService) DatabaseSizes(dbNames ...string) (map[string]int64, error) {
+	db, err := p.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	sizes := make(map[string]int64, len(dbNames))
+	for _, dbName := range dbNames {
+		var size int64
+		err := db.QueryRow(`SELECT pg_database_size(datname) FROM pg_database WHERE datname = $1`, dbName).Scan(&size)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get size of database %s: %w", dbName, err)
+		}
+		sizes[dbName] = size
 	}
 
-	return nil
+	return sizes, nil
+}
+
+// cloneDatabase creates the database from a template. A template that other sessions are
+// connected to, such as a live demo database, is copied with pg_dump instead, since CREATE
+// DATABASE would otherwise require disconnecting them.
+func (p *PostgresService) cloneDatabase(db *sql.DB, dbName, templateDB string) error {
+	err := p.createDatabase(db, dbName, templateDB)
+	if err == nil || !isObjectInUse(err) {
+		return err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"database": dbName,
+		"template": templateDB,
+		"service":  "postgres",
+	}).Info("Template database is in use, copying it instead")
+
+	return p.copyDatabase(db, templateDB, dbName)
+}
+
+func (p *PostgresService) databaseExists(db *sql.DB, dbName string) (bool, error) {
+	var exists bool
+	if err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)`, dbName).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check database %s: %w", dbName, err)
+	}
+	return exists, nil
 }
 
 func (p *PostgresService) killConnections(db *sql.DB, templateDB string) error {
@@ -86,21 +187,13 @@ func (p *PostgresService) DeleteClientDatabases(mainDBName, hangfireDBName strin
 
 	log.Info("Starting database deletion")
 
-	psqlInfo := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
-		p.config.Host, p.config.Port, p.config.User, p.config.Password, p.config.DBName)
-
-	db, err := sql.Open("postgres", psqlInfo)
+	db, err := p.connect()
 	if err != nil {
 		log.WithError(err).Error("Failed to connect to database")
-		return fmt.Errorf("failed to connect to database: %w", err)
+		return err
 	}
 	defer db.Close()
 
-	if err := db.Ping(); err != nil {
-		log.WithError(err).Error("Failed to ping database")
-		return fmt.Errorf("failed to ping database: %w", err)
-	}
-
 	if err := p.deleteDatabase(db, mainDBName); err != nil {
 		if !p.isDatabaseNotFoundError(err) {
 			log.WithError(err).Error("Failed to delete main database")
@@ -148,6 +241,242 @@ func (p *PostgresService) deleteDatabase(db *sql.DB, dbName string) error {
 	return nil
 }
 
+// ConfigureClientRole creates the client's login role, or resets its password when it already
+// exists, and hands it the client's databases and everything in them. CONNECT is revoked from
+// PUBLIC so other clients' roles cannot reach these databases.
+func (p *PostgresService) ConfigureClientRole(roleName, password string, dbNames ...string) error {
+	log := logger.WithFields(logrus.Fields{
+		"role":      roleName,
+		"databases": dbNames,
+		"service":   "postgres",
+	})
+
+	db, err := p.connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	exists, err := p.roleExists(db, roleName)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		log.Info("Updating database role password")
+	} else {
+		log.Info("Creating database role")
+	}
+	for _, statement := range clientRoleStatements(roleName, password, exists) {
+		if _, err := db.Exec(statement); err != nil {
+			return fmt.Errorf("failed to configure role %s: %w", roleName, err)
+		}
+	}
+
+	for _, dbName := range dbNames {
+		log.WithField("database", dbName).Info("Transferring database to role")
+		if err := p.assignDatabase(db, dbName, roleName); err != nil {
+			return err
+		}
+	}
+
+	log.Info("Database role configured")
+	return nil
+}
+
+// clientRoleStatements create a login role without any cluster-wide privilege, or reset the
+// password of an existing one. Membership lets an admin without superuser rights transfer
+// ownership to the role.
+func clientRoleStatements(roleName, password string, exists bool) []string {
+	role := pq.QuoteIdentifier(roleName)
+
+	statement := fmt.Sprintf(`CREATE ROLE %s WITH LOGIN NOSUPERUSER NOCREATEDB NOCREATEROLE PASSWORD %s`, role, pq.QuoteLiteral(password))
+	if exists {
+		statement = fmt.Sprintf(`ALTER ROLE %s WITH LOGIN PASSWORD %s`, role, pq.QuoteLiteral(password))
+	}
+
+	return []string{statement, fmt.Sprintf(`GRANT %s TO CURRENT_USER`, role)}
+}
+
+// assignDatabase makes the role the owner of a database and of the objects in it, and closes
+// the database to every other role.
+func (p *PostgresService) assignDatabase(db *sql.DB, dbName, roleName string) error {
+	for _, statement := range assignDatabaseStatements(dbName, roleName) {
+		if _, err := db.Exec(statement); err != nil {
+			return fmt.Errorf("failed to restrict database %s to role %s: %w", dbName, roleName, err)
+		}
+	}
+
+	return p.transferObjects(dbName, roleName)
+}
+
+func assignDatabaseStatements(dbName, roleName string) []string {
+	database := pq.QuoteIdentifier(dbName)
+	return []string{
+		fmt.Sprintf(`ALTER DATABASE %s OWNER TO %s`, database, pq.QuoteIdentifier(roleName)),
+		fmt.Sprintf(`REVOKE CONNECT, TEMPORARY ON DATABASE %s FROM PUBLIC`, database),
+	}
+}
+
+// transferObjects makes the role the owner of the schemas, tables, views, sequences, routines
+// and types the database was cloned with. Objects that belong to extensions stay with their
+// owner, and sequences owned by a column follow their table.
+func (p *PostgresService) transferObjects(dbName, roleName string) error {
+	db, err := p.connectTo(dbName)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	query := fmt.Sprintf(`DO $$
+DECLARE
+	target text := %s;
+	item record;
+BEGIN
+	FOR item IN
+		SELECT n.nspname FROM pg_namespace n
+		WHERE n.nspname NOT LIKE 'pg\_%%' AND n.nspname <> 'information_schema'
+		AND NOT EXISTS (SELECT 1 FROM pg_depend d WHERE d.classid = 'pg_namespace'::regclass AND d.objid = n.oid AND d.deptype = 'e')
+	LOOP
+		EXECUTE format('ALTER SCHEMA %%I OWNER TO %%I', item.nspname, target);
+	END LOOP;
+
+	FOR item IN
+		SELECT n.nspname, c.relname, CASE c.relkind
+			WHEN 'v' THEN 'VIEW'
+			WHEN 'm' THEN 'MATERIALIZED VIEW'
+			WHEN 'S' THEN 'SEQUENCE'
+			WHEN 'f' THEN 'FOREIGN TABLE'
+			ELSE 'TABLE'
+		END AS kind
+		FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p', 'v', 'm', 'S', 'f')
+		AND n.nspname NOT LIKE 'pg\_%%' AND n.nspname <> 'information_schema'
+		AND NOT EXISTS (SELECT 1 FROM pg_depend d WHERE d.classid = 'pg_class'::regclass AND d.objid = c.oid
+			AND (d.deptype = 'e' OR (c.relkind = 'S' AND d.deptype IN ('a', 'i'))))
+	LOOP
+		EXECUTE format('ALTER %%s %%I.%%I OWNER TO %%I', item.kind, item.nspname, item.relname, target);
+	END LOOP;
+
+	FOR item IN
+		SELECT p.oid::regprocedure AS signature FROM pg_proc p JOIN pg_namespace n ON n.oid = p.pronamespace
+		WHERE p.prokind IN ('f', 'p')
+		AND n.nspname NOT LIKE 'pg\_%%' AND n.nspname <> 'information_schema'
+		AND NOT EXISTS (SELECT 1 FROM pg_depend d WHERE d.classid = 'pg_proc'::regclass AND d.objid = p.oid AND d.deptype = 'e')
+	LOOP
+		EXECUTE format('ALTER ROUTINE %%s OWNER TO %%I', item.signature, target);
+	END LOOP;
+
+	FOR item IN
+		SELECT n.nspname, t.typname, CASE t.typtype WHEN 'd' THEN 'DOMAIN' ELSE 'TYPE' END AS kind
+		FROM pg_type t JOIN pg_namespace n ON n.oid = t.typnamespace
+		WHERE (t.typtype IN ('e', 'd', 'r') OR (t.typtype = 'c' AND EXISTS (SELECT 1 FROM pg_class c WHERE c.oid = t.typrelid AND c.relkind = 'c')))
+		AND n.nspname NOT LIKE 'pg\_%%' AND n.nspname <> 'information_schema'
+		AND NOT EXISTS (SELECT 1 FROM pg_depend d WHERE d.classid = 'pg_type'::regclass AND d.objid = t.oid AND d.deptype = 'e')
+	LOOP
+		EXECUTE format('ALTER %%s %%I.%%I OWNER TO %%I', item.kind, item.nspname, item.typname, target);
+	END LOOP;
+END
+$$`, pq.QuoteLiteral(roleName))
+
+	if _, err := db.Exec(query); err != nil {
+		return fmt.Errorf("failed to transfer objects of database %s to role %s: %w", dbName, roleName, err)
+	}
+
+	return nil
+}
+
+// DropClientRole hands whatever the role still owns back to the admin user and drops it. It is
+// a no-op when the role does not exist.
+func (p *PostgresService) DropClientRole(roleName string, dbNames ...string) error {
+	log := logger.WithFields(logrus.Fields{
+		"role":    roleName,
+		"service": "postgres",
+		"action":  "delete",
+	})
+
+	db, err := p.connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	exists, err := p.roleExists(db, roleName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		log.Info("Database role does not exist, skipping deletion")
+		return nil
+	}
+
+	// Ownership inside a database can only be reassigned from a connection to it.
+	for _, dbName := range dbNames {
+		dbExists, err := p.databaseExists(db, dbName)
+		if err != nil {
+			return err
+		}
+		if !dbExists {
+			continue
+		}
+
+		if err := p.reassignOwned(dbName, roleName); err != nil {
+			return err
+		}
+	}
+
+	log.Info("Dropping database role")
+	if _, err := db.Exec(fmt.Sprintf(`DROP ROLE IF EXISTS %s`, pq.QuoteIdentifier(roleName))); err != nil {
+		return fmt.Errorf("failed to drop role %s: %w", roleName, err)
+	}
+
+	return nil
+}
+
+func (p *PostgresService) reassignOwned(dbName, roleName string) error {
+	db, err := p.connectTo(dbName)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	for _, statement := range releaseRoleStatements(roleName) {
+		if _, err := db.Exec(statement); err != nil {
+			return fmt.Errorf("failed to release objects of role %s in database %s: %w", roleName, dbName, err)
+		}
+	}
+
+	return nil
+}
+
+// releaseRoleStatements hand what the role owns in a database back to the admin user and drop
+// its remaining privileges there, so the role can be dropped.
+func releaseRoleStatements(roleName string) []string {
+	role := pq.QuoteIdentifier(roleName)
+	return []string{
+		fmt.Sprintf(`REASSIGN OWNED BY %s TO CURRENT_USER`, role),
+		fmt.Sprintf(`DROP OWNED BY %s`, role),
+	}
+}
+
+func (p *PostgresService) RoleExists(roleName string) (bool, error) {
+	db, err := p.connect()
+	if err != nil {
+		return false, err
+	}
+	defer db.Close()
+
+	return p.roleExists(db, roleName)
+}
+
+func (p *PostgresService) roleExists(db *sql.DB, roleName string) (bool, error) {
+	var exists bool
+	if err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = $1)`, roleName).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check role %s: %w", roleName, err)
+	}
+	return exists, nil
+}
+
 func (p *PostgresService) isDatabaseNotFoundError(err error) bool {
 	if err == nil {
 		return false
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClientRoleStatements(t *testing.T) {
+	tests := []struct {
+		name     string
+		roleName string
+		password string
+		exists   bool
+		want     []string
+	}{
+		{
+			name:     "new role",
+			roleName: "acme-app",
+			password: "secret",
+			want: []string{
+				`CREATE ROLE "acme-app" WITH LOGIN NOSUPERUSER NOCREATEDB NOCREATEROLE PASSWORD 'secret'`,
+				`GRANT "acme-app" TO CURRENT_USER`,
+			},
+		},
+		{
+			name:     "existing role",
+			roleName: "acme-app",
+			password: "secret",
+			exists:   true,
+			want: []string{
+				`ALTER ROLE "acme-app" WITH LOGIN PASSWORD 'secret'`,
+				`GRANT "acme-app" TO CURRENT_USER`,
+			},
+		},
+		{
+			name:     "names and passwords are quoted",
+			roleName: `o"brien`,
+			password: `it's`,
+			want: []string{
+				`CREATE ROLE "o""brien" WITH LOGIN NOSUPERUSER NOCREATEDB NOCREATEROLE PASSWORD 'it''s'`,
+				`GRANT "o""brien" TO CURRENT_USER`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := clientRoleStatements(tt.roleName, tt.password, tt.exists); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("clientRoleStatements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssignDatabaseStatements(t *testing.T) {
+	want := []string{
+		`ALTER DATABASE "acme-hf" OWNER TO "acme-app"`,
+		`REVOKE CONNECT, TEMPORARY ON DATABASE "acme-hf" FROM PUBLIC`,
+	}
+	if got := assignDatabaseStatements("acme-hf", "acme-app"); !reflect.DeepEqual(got, want) {
+		t.Errorf("assignDatabaseStatements() = %q, want %q", got, want)
+	}
+}
+
+func TestReleaseRoleStatements(t *testing.T) {
+	want := []string{
+		`REASSIGN OWNED BY "acme-app" TO CURRENT_USER`,
+		`DROP OWNED BY "acme-app"`,
+	}
+	if got := releaseRoleStatements("acme-app"); !reflect.DeepEqual(got, want) {
+		t.Errorf("releaseRoleStatements() = %q, want %q", got, want)
+	}
+}
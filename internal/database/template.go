@@ -0,0 +1,167 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/CaioDGallo/easy-cli/internal/logger"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// A template is rebuilt under its name with refreshSuffix and the template it replaces is kept
+// under previousSuffix until the swap is done. Both suffixes have the same length.
+const (
+	refreshSuffix  = "-next"
+	previousSuffix = "-prev"
+)
+
+var templateNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// TemplateDatabases returns the main and hangfire databases of a template set. A template set
+// is named after its main database, like the client databases it is cloned into.
+func TemplateDatabases(template string) (mainDBName, hangfireDBName string) {
+	return template, template + "-hf"
+}
+
+func ValidateTemplateName(template string) error {
+	if template == "" {
+		return fmt.Errorf("template name cannot be empty")
+	}
+
+	if !templateNameRegex.MatchString(template) {
+		return fmt.Errorf("template name %q may only contain lowercase letters, digits, hyphens and underscores", template)
+	}
+
+	_, hangfireDBName := TemplateDatabases(template)
+	if len(hangfireDBName+refreshSuffix) > 63 {
+		return fmt.Errorf("template name %q is too long", template)
+	}
+
+	return nil
+}
+
+// TemplateExists reports whether both databases of the template set exist.
+func (p *PostgresService) TemplateExists(template string) (bool, error) {
+	return p.ClientDatabasesExist(TemplateDatabases(template))
+}
+
+// RefreshTemplate rebuilds a template set from a source set, for example a live demo
+// environment. The source is copied with pg_dump so its users are not disconnected. Each
+// template is built under a temporary name and only swapped in once it is complete; templates
+// refuse connections, so cloning from them never has to terminate anyone's session.
+func (p *PostgresService) RefreshTemplate(source, template string) error {
+	if err := ValidateTemplateName(template); err != nil {
+		return err
+	}
+	if source == template {
+		return fmt.Errorf("the template cannot be refreshed from itself")
+	}
+
+	db, err := p.connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	sourceMain, sourceHangfire := TemplateDatabases(source)
+	templateMain, templateHangfire := TemplateDatabases(template)
+
+	for _, target := range []struct{ source, template string }{
+		{sourceMain, templateMain},
+		{sourceHangfire, templateHangfire},
+	} {
+		if err := p.refreshTemplateDatabase(db, target.source, target.template); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *PostgresService) refreshTemplateDatabase(db *sql.DB, source, template string) error {
+	log := logger.WithFields(logrus.Fields{
+		"source":   source,
+		"template": template,
+		"service":  "postgres",
+	})
+
+	exists, err := p.databaseExists(db, source)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("source database %s does not exist", source)
+	}
+
+	building := template + refreshSuffix
+	if err := p.dropTemplate(db, building); err != nil {
+		return fmt.Errorf("failed to remove leftover database %s: %w", building, err)
+	}
+
+	log.Info("Building template database")
+	if err := p.copyDatabase(db, source, building); err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(fmt.Sprintf(`ALTER DATABASE %s WITH IS_TEMPLATE true ALLOW_CONNECTIONS false`, pq.QuoteIdentifier(building))); err != nil {
+		return fmt.Errorf("failed to mark %s as a template: %w", building, err)
+	}
+
+	exists, err = p.databaseExists(db, template)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		previous := template + previousSuffix
+		if err := p.dropTemplate(db, previous); err != nil {
+			return fmt.Errorf("failed to remove leftover database %s: %w", previous, err)
+		}
+		if err := p.renameDatabase(db, template, previous); err != nil {
+			return err
+		}
+		if err := p.renameDatabase(db, building, template); err != nil {
+			return err
+		}
+		if err := p.dropTemplate(db, previous); err != nil {
+			log.WithError(err).Warn("Failed to drop the previous template database")
+		}
+	} else if err := p.renameDatabase(db, building, template); err != nil {
+		return err
+	}
+
+	log.Info("Template database refreshed")
+	return nil
+}
+
+func (p *PostgresService) renameDatabase(db *sql.DB, from, to string) error {
+	if _, err := db.Exec(fmt.Sprintf(`ALTER DATABASE %s RENAME TO %s`, pq.QuoteIdentifier(from), pq.QuoteIdentifier(to))); err != nil {
+		return fmt.Errorf("failed to rename database %s to %s: %w", from, to, err)
+	}
+	return nil
+}
+
+// dropTemplate drops a database, clearing its template flag first since template databases
+// cannot be dropped.
+func (p *PostgresService) dropTemplate(db *sql.DB, dbName string) error {
+	exists, err := p.databaseExists(db, dbName)
+	if err != nil || !exists {
+		return err
+	}
+
+	database := pq.QuoteIdentifier(dbName)
+	if _, err := db.Exec(fmt.Sprintf(`ALTER DATABASE %s WITH IS_TEMPLATE false`, database)); err != nil {
+		return err
+	}
+	_, err = db.Exec(fmt.Sprintf(`DROP DATABASE %s`, database))
+	return err
+}
+
+// isObjectInUse reports whether a statement failed because other sessions use the database.
+func isObjectInUse(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "55006"
+}
@@ -3,11 +3,17 @@ package digitalocean
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/CaioDGallo/easy-cli/internal/config"
+	"github.com/CaioDGallo/easy-cli/internal/gitprovider"
 	"github.com/CaioDGallo/easy-cli/internal/interfaces"
 	"github.com/CaioDGallo/easy-cli/internal/logger"
+	"github.com/CaioDGallo/easy-cli/internal/resources"
 	"github.com/CaioDGallo/easy-cli/internal/retry"
 	"github.com/CaioDGallo/easy-cli/internal/types"
 	"github.com/digitalocean/godo"
@@ -26,60 +32,209 @@ func NewAppService(token string) *AppService {
 	}
 }
 
-func (a *AppService) CreateApp(ctx context.Context, client types.Client, envVars types.DigitalOceanEnvVars, cfg *config.Config) (string, error) {
-	appLevelEnvs := make([]*godo.AppVariableDefinition, 0, len(envVars.AppEnvs))
-	for _, value := range envVars.AppEnvs {
-		valueCopy := value
-		appLevelEnvs = append(appLevelEnvs, &valueCopy)
+func (a *AppService) CreateApp(ctx context.Context, client types.Client, envVars types.DigitalOceanEnvVars, cfg *config.Config) (types.DigitalOceanApp, error) {
+	spec, err := BuildAppSpec(client, envVars, cfg)
+	if err != nil {
+		return types.DigitalOceanApp{}, err
 	}
 
+	// The app is created without its service first and the service is added in a follow-up update.
+	services := spec.Services
+	spec.Services = nil
+
 	createAppReq := godo.AppCreateRequest{
-		Spec: &godo.AppSpec{
-			Name:   fmt.Sprintf("%s-%s", cfg.Application.NamePrefix, client.SanitizedClientName),
-			Envs:   appLevelEnvs,
-			Region: "sfo",
-		},
+		Spec: spec,
 	}
 
 	app, _, err := a.client.Apps.Create(ctx, &createAppReq)
 	if err != nil {
-		return "", fmt.Errorf("failed to create DigitalOcean app: %w", err)
+		return types.DigitalOceanApp{}, fmt.Errorf("failed to create DigitalOcean app: %w", err)
 	}
 
-	componentLevelEnvs := make([]*godo.AppVariableDefinition, 0, len(envVars.ComponentEnvs))
-	for _, value := range envVars.ComponentEnvs {
-		valueCopy := value
-		componentLevelEnvs = append(componentLevelEnvs, &valueCopy)
-	}
-
-	component := &godo.AppServiceSpec{
-		Name:           client.SanitizedClientName,
-		SourceDir:      "/",
-		DockerfilePath: "Dockerfile",
-		Bitbucket: &godo.BitbucketSourceSpec{
-			Repo:         cfg.Repository.Backend,
-			Branch:       client.BackendBranch,
-			DeployOnPush: true,
-		},
-		HTTPPort:         80,
-		InstanceCount:    1,
-		InstanceSizeSlug: "basic-xxs",
-		Envs:             componentLevelEnvs,
-	}
-
-	app.Spec.Services = append(app.Spec.Services, component)
+	app.Spec.Services = append(app.Spec.Services, services...)
 	updateRequest := &godo.AppUpdateRequest{Spec: app.Spec}
 
 	if _, _, err := a.client.Apps.Update(ctx, app.ID, updateRequest); err != nil {
-		return "", fmt.Errorf("failed to update DigitalOcean app with service: %w", err)
+		return types.DigitalOceanApp{ID: app.ID}, fmt.Errorf("failed to update DigitalOcean app with service: %w", err)
 	}
 
 	appURL, err := a.WaitForAppDeploymentAndGetURL(ctx, app.ID)
 	if err != nil {
-		return "", fmt.Errorf("failed to wait for app deployment and get URL: %w", err)
+		return types.DigitalOceanApp{ID: app.ID}, fmt.Errorf("failed to wait for app deployment and get URL: %w", err)
 	}
 
-	return appURL, nil
+	return types.DigitalOceanApp{ID: app.ID, URL: appURL}, nil
+}
+
+// BuildAppSpec returns the full app spec, including the backend service, that CreateApp provisions.
+func BuildAppSpec(client types.Client, envVars types.DigitalOceanEnvVars, cfg *config.Config) (*godo.AppSpec, error) {
+	appSpec, err := resources.ResolveAppSpec(cfg, client.App)
+	if err != nil {
+		return nil, err
+	}
+
+	gitProvider, err := gitprovider.For(cfg.Repository.Backend)
+	if err != nil {
+		return nil, err
+	}
+
+	component := &godo.AppServiceSpec{
+		Name:             client.SanitizedClientName,
+		SourceDir:        appSpec.SourceDir,
+		DockerfilePath:   appSpec.DockerfilePath,
+		HTTPPort:         appSpec.HTTPPort,
+		InstanceCount:    appSpec.InstanceCount,
+		InstanceSizeSlug: appSpec.InstanceSizeSlug,
+		Envs:             envDefinitions(envVars.ComponentEnvs),
+	}
+	gitProvider.ApplyAppSource(component, cfg.Repository.Backend, client.BackendBranch)
+
+	spec := &godo.AppSpec{
+		Name:     fmt.Sprintf("%s-%s", cfg.Application.NamePrefix, client.SanitizedClientName),
+		Envs:     envDefinitions(envVars.AppEnvs),
+		Region:   appSpec.Region,
+		Services: []*godo.AppServiceSpec{component},
+	}
+
+	if client.Domain != "" {
+		// A primary domain becomes the app's live URL once App Platform has verified it.
+		spec.Domains = []*godo.AppDomainSpec{{
+			Domain: resources.CustomDomainsFor(client.Domain).Backend,
+			Type:   godo.AppDomainSpecType_Primary,
+		}}
+	}
+
+	return spec, nil
+}
+
+// AppDomainRecords returns the DNS records App Platform needs to serve the app's custom domains:
+// a CNAME to the app's default ingress and any pending validation challenges.
+func (a *AppService) AppDomainRecords(ctx context.Context, appID string) ([]types.DNSRecord, error) {
+	app, _, err := a.client.Apps.Get(ctx, appID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get app %s: %w", appID, err)
+	}
+
+	if app.Spec == nil || len(app.Spec.Domains) == 0 {
+		return nil, nil
+	}
+
+	ingress, err := url.Parse(app.DefaultIngress)
+	if err != nil || ingress.Host == "" {
+		return nil, fmt.Errorf("app %s has no default ingress yet", appID)
+	}
+
+	var records []types.DNSRecord
+	for _, domain := range app.Spec.Domains {
+		records = append(records, types.DNSRecord{
+			Provider: "digitalocean",
+			Name:     domain.Domain,
+			Type:     types.DNSRecordTypeCNAME,
+			Value:    ingress.Host,
+		})
+	}
+
+	for _, domain := range app.Domains {
+		for _, validation := range domain.Validations {
+			records = append(records, types.DNSRecord{
+				Provider: "digitalocean",
+				Name:     validation.TXTName,
+				Type:     types.DNSRecordTypeTXT,
+				Value:    validation.TXTValue,
+			})
+		}
+	}
+
+	return records, nil
+}
+
+// AppDomainPhase returns the phase of one of the app's custom domains. Domains App Platform has
+// not picked up yet are reported as pending.
+func (a *AppService) AppDomainPhase(ctx context.Context, appID, domain string) (godo.AppDomainPhase, error) {
+	app, _, err := a.client.Apps.Get(ctx, appID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get app %s: %w", appID, err)
+	}
+
+	for _, appDomain := range app.Domains {
+		if appDomain.Spec != nil && appDomain.Spec.Domain == domain {
+			return appDomain.Phase, nil
+		}
+	}
+
+	return godo.AppJobSpecKindPHASE_Pending, nil
+}
+
+// ValidateAppSpec checks the region and instance size against the ones App Platform reports.
+func (a *AppService) ValidateAppSpec(ctx context.Context, appSpec types.AppSpecOptions) error {
+	regions, _, err := a.client.Apps.ListRegions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list App Platform regions: %w", err)
+	}
+
+	var regionSlugs []string
+	var region *godo.AppRegion
+	for _, candidate := range regions {
+		if candidate.Disabled {
+			continue
+		}
+		regionSlugs = append(regionSlugs, candidate.Slug)
+		if candidate.Slug == appSpec.Region {
+			region = candidate
+		}
+	}
+	if region == nil {
+		return fmt.Errorf("unknown or disabled region %q (available: %s)", appSpec.Region, strings.Join(regionSlugs, ", "))
+	}
+
+	sizes, _, err := a.client.Apps.ListInstanceSizes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list App Platform instance sizes: %w", err)
+	}
+
+	var sizeSlugs []string
+	var size *godo.AppInstanceSize
+	for _, candidate := range sizes {
+		sizeSlugs = append(sizeSlugs, candidate.Slug)
+		if candidate.Slug == appSpec.InstanceSizeSlug {
+			size = candidate
+		}
+	}
+	if size == nil {
+		return fmt.Errorf("unknown instance size %q (available: %s)", appSpec.InstanceSizeSlug, strings.Join(sizeSlugs, ", "))
+	}
+	if appSpec.InstanceCount > 1 && size.SingleInstanceOnly {
+		return fmt.Errorf("instance size %s only supports a single instance, got %d", size.Slug, appSpec.InstanceCount)
+	}
+
+	return nil
+}
+
+func envDefinitions(envs map[string]godo.AppVariableDefinition) []*godo.AppVariableDefinition {
+	definitions := make([]*godo.AppVariableDefinition, 0, len(envs))
+	for _, value := range envs {
+		valueCopy := value
+		definitions = append(definitions, &valueCopy)
+	}
+
+	sort.Slice(definitions, func(i, j int) bool {
+		return definitions[i].Key < definitions[j].Key
+	})
+
+	return definitions
+}
+
+// GetApp returns the app with the given ID, or nil if it no longer exists.
+func (a *AppService) GetApp(ctx context.Context, appID string) (*godo.App, error) {
+	app, resp, err := a.client.Apps.Get(ctx, appID)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get app %s: %w", appID, err)
+	}
+
+	return app, nil
 }
 
 func (a *AppService) GetAppURL(ctx context.Context, appID string) (string, error) {
@@ -118,35 +273,68 @@ func (a *AppService) GetAppURL(ctx context.Context, appID string) (string, error
 }
 
 func (a *AppService) UpdateAppEnvironmentVariables(ctx context.Context, appName string, envVars types.DigitalOceanEnvVars, cfg *config.Config) error {
-	apps, _, err := a.client.Apps.List(ctx, &godo.ListOptions{})
+	targetApp, err := a.FindAppByName(ctx, fmt.Sprintf("%s-%s", cfg.Application.NamePrefix, appName))
 	if err != nil {
-		return fmt.Errorf("failed to list apps: %w", err)
+		return err
 	}
 
-	var targetApp *godo.App
-	for _, app := range apps {
-		if app.Spec.Name == fmt.Sprintf("%s-%s", cfg.Application.NamePrefix, appName) {
-			targetApp = app
-			break
+	if targetApp == nil {
+		return fmt.Errorf("app not found: %s-%s", cfg.Application.NamePrefix, appName)
+	}
+
+	return a.updateAppEnvs(ctx, targetApp, envVars)
+}
+
+func (a *AppService) UpdateAppEnvironmentVariablesByID(ctx context.Context, appID string, envVars types.DigitalOceanEnvVars) error {
+	targetApp, _, err := a.client.Apps.Get(ctx, appID)
+	if err != nil {
+		return fmt.Errorf("failed to get app %s: %w", appID, err)
+	}
+
+	return a.updateAppEnvs(ctx, targetApp, envVars)
+}
+
+// MergeAppEnvironmentVariablesByID upserts the given variables into the live app spec and leaves
+// every other variable untouched.
+func (a *AppService) MergeAppEnvironmentVariablesByID(ctx context.Context, appID string, envVars types.DigitalOceanEnvVars) error {
+	targetApp, _, err := a.client.Apps.Get(ctx, appID)
+	if err != nil {
+		return fmt.Errorf("failed to get app %s: %w", appID, err)
+	}
+
+	targetApp.Spec.Envs = mergeEnvDefinitions(targetApp.Spec.Envs, envVars.AppEnvs)
+
+	if len(envVars.ComponentEnvs) > 0 {
+		if len(targetApp.Spec.Services) == 0 {
+			return fmt.Errorf("app %s has no service to update", appID)
 		}
+		targetApp.Spec.Services[0].Envs = mergeEnvDefinitions(targetApp.Spec.Services[0].Envs, envVars.ComponentEnvs)
 	}
 
-	if targetApp == nil {
-		return fmt.Errorf("app not found: %s-%s", cfg.Application.NamePrefix, appName)
+	updateRequest := &godo.AppUpdateRequest{Spec: targetApp.Spec}
+	if _, _, err := a.client.Apps.Update(ctx, targetApp.ID, updateRequest); err != nil {
+		return fmt.Errorf("failed to update app environment variables: %w", err)
 	}
 
-	targetApp.Spec.Envs = make([]*godo.AppVariableDefinition, 0, len(envVars.AppEnvs))
-	for _, value := range envVars.AppEnvs {
-		valueCopy := value
-		targetApp.Spec.Envs = append(targetApp.Spec.Envs, &valueCopy)
+	return nil
+}
+
+func mergeEnvDefinitions(existing []*godo.AppVariableDefinition, updates map[string]godo.AppVariableDefinition) []*godo.AppVariableDefinition {
+	merged := make(map[string]godo.AppVariableDefinition, len(existing)+len(updates))
+	for _, envVar := range existing {
+		merged[envVar.Key] = *envVar
+	}
+	for key, envVar := range updates {
+		merged[key] = envVar
 	}
+	return envDefinitions(merged)
+}
+
+func (a *AppService) updateAppEnvs(ctx context.Context, targetApp *godo.App, envVars types.DigitalOceanEnvVars) error {
+	targetApp.Spec.Envs = envDefinitions(envVars.AppEnvs)
 
 	if len(targetApp.Spec.Services) > 0 {
-		targetApp.Spec.Services[0].Envs = make([]*godo.AppVariableDefinition, 0, len(envVars.ComponentEnvs))
-		for _, value := range envVars.ComponentEnvs {
-			valueCopy := value
-			targetApp.Spec.Services[0].Envs = append(targetApp.Spec.Services[0].Envs, &valueCopy)
-		}
+		targetApp.Spec.Services[0].Envs = envDefinitions(envVars.ComponentEnvs)
 
 		if targetApp.Spec.Services[0].DockerfilePath == "" {
 			targetApp.Spec.Services[0].DockerfilePath = "Dockerfile"
@@ -226,26 +414,85 @@ func (a *AppService) WaitForAppDeploymentAndGetURL(ctx context.Context, appID st
 }
 
 func (a *AppService) DeleteApp(ctx context.Context, appName string, cfg *config.Config) error {
-	apps, _, err := a.client.Apps.List(ctx, &godo.ListOptions{})
+	targetApp, err := a.FindAppByName(ctx, fmt.Sprintf("%s-%s", cfg.Application.NamePrefix, appName))
 	if err != nil {
-		return fmt.Errorf("failed to list apps: %w", err)
-	}
-
-	var targetApp *godo.App
-	for _, app := range apps {
-		if app.Spec.Name == fmt.Sprintf("%s-%s", cfg.Application.NamePrefix, appName) {
-			targetApp = app
-			break
-		}
+		return err
 	}
 
 	if targetApp == nil {
 		return nil
 	}
 
-	if _, err := a.client.Apps.Delete(ctx, targetApp.ID); err != nil {
+	return a.DeleteAppByID(ctx, targetApp.ID)
+}
+
+func (a *AppService) DeleteAppByID(ctx context.Context, appID string) error {
+	resp, err := a.client.Apps.Delete(ctx, appID)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil
+		}
 		return fmt.Errorf("failed to delete DigitalOcean app: %w", err)
 	}
 
 	return nil
 }
+
+// FindAppByName returns the app whose spec has the given name, or nil if there is none.
+func (a *AppService) FindAppByName(ctx context.Context, specName string) (*godo.App, error) {
+	apps, _, err := a.client.Apps.List(ctx, &godo.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list apps: %w", err)
+	}
+
+	for _, app := range apps {
+		if app.Spec.Name == specName {
+			return app, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// LabeledApp is an app with the labels easy-cli set in its environment.
+type LabeledApp struct {
+	Name   string
+	Labels types.ResourceLabels
+}
+
+// LabeledApps returns the apps whose spec name starts with prefix and whose app-level
+// environment carries labels. Apps created before they were labeled are left out.
+func (a *AppService) LabeledApps(ctx context.Context, prefix string) ([]LabeledApp, error) {
+	var labeled []LabeledApp
+
+	opts := &godo.ListOptions{PerPage: 100}
+	for {
+		apps, resp, err := a.client.Apps.List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list apps: %w", err)
+		}
+
+		for _, app := range apps {
+			if app.Spec == nil || !strings.HasPrefix(app.Spec.Name, prefix) {
+				continue
+			}
+
+			envs := make(map[string]string, len(app.Spec.Envs))
+			for _, env := range app.Spec.Envs {
+				envs[env.Key] = env.Value
+			}
+			if labels, ok := types.LabelsFromEnvVars(envs); ok {
+				labeled = append(labeled, LabeledApp{Name: app.Spec.Name, Labels: labels})
+			}
+		}
+
+		if resp == nil || resp.Links == nil || resp.Links.IsLastPage() {
+			return labeled, nil
+		}
+		page, err := resp.Links.CurrentPage()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read apps page: %w", err)
+		}
+		opts.Page = page + 1
+	}
+}
@@ -0,0 +1,33 @@
+package dns
+
+import (
+	"github.com/CaioDGallo/easy-cli/internal/aws"
+	"github.com/CaioDGallo/easy-cli/internal/config"
+	"github.com/CaioDGallo/easy-cli/internal/interfaces"
+	"github.com/CaioDGallo/easy-cli/internal/types"
+)
+
+// NewProvider returns the configured DNS provider, or nil when records are created by hand.
+func NewProvider(cfg *config.Config) (interfaces.DNSProvider, error) {
+	switch cfg.DNS.Provider {
+	case "route53":
+		route53Service, err := aws.NewRoute53Service(cfg.AWS.Region, cfg.AWS.AccessKeyID, cfg.AWS.SecretAccessKey, cfg.DNS.Route53HostedZoneID, cfg.DNS.Route53Endpoint, cfg.DNS.TTL)
+		if err != nil {
+			return nil, err
+		}
+		return route53Service, nil
+	default:
+		return nil, nil
+	}
+}
+
+// PublishedRecords returns the records a DNS provider created.
+func PublishedRecords(records []types.DNSRecord) []types.DNSRecord {
+	var published []types.DNSRecord
+	for _, record := range records {
+		if record.Zone != "" {
+			published = append(published, record)
+		}
+	}
+	return published
+}
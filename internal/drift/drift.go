@@ -0,0 +1,319 @@
+package drift
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/CaioDGallo/easy-cli/internal/digitalocean"
+	"github.com/CaioDGallo/easy-cli/internal/envvars"
+	"github.com/CaioDGallo/easy-cli/internal/types"
+	"github.com/CaioDGallo/easy-cli/internal/vercel"
+	"github.com/digitalocean/godo"
+)
+
+type Kind string
+
+const (
+	KindMissing Kind = "missing"
+	KindExtra   Kind = "extra"
+	KindChanged Kind = "changed"
+)
+
+const (
+	ScopeAppLevel       = "app"
+	ScopeComponentLevel = "component"
+	ScopeProduction     = "production"
+)
+
+// Entry is a single variable whose live value differs from the generated one. Secret values are
+// always redacted.
+type Entry struct {
+	Provider string `json:"provider"`
+	Scope    string `json:"scope"`
+	Key      string `json:"key"`
+	Kind     Kind   `json:"kind"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// Expected is the environment a client should have, as generated by the envvars package.
+type Expected struct {
+	Backend  types.DigitalOceanEnvVars
+	Frontend []types.VercelEnvVariable
+}
+
+type Report struct {
+	ClientName string  `json:"clientName"`
+	Entries    []Entry `json:"entries"`
+
+	appID      string
+	projectRef string
+	app        *godo.App
+	vercelEnvs []types.VercelProjectEnv
+	expected   Expected
+}
+
+// Detector compares the live environment of a client's DigitalOcean app and Vercel project with
+// the expected one.
+type Detector struct {
+	doService     *digitalocean.AppService
+	vercelService *vercel.ProjectService
+}
+
+func NewDetector(doService *digitalocean.AppService, vercelService *vercel.ProjectService) *Detector {
+	return &Detector{
+		doService:     doService,
+		vercelService: vercelService,
+	}
+}
+
+func (d *Detector) Detect(ctx context.Context, deploymentState *types.DeploymentState, expected Expected) (*Report, error) {
+	if deploymentState.DOAppID == "" {
+		return nil, fmt.Errorf("deployment state has no DigitalOcean app ID")
+	}
+
+	app, err := d.doService.GetApp(ctx, deploymentState.DOAppID)
+	if err != nil {
+		return nil, err
+	}
+	if app == nil {
+		return nil, fmt.Errorf("DigitalOcean app %s no longer exists", deploymentState.DOAppID)
+	}
+	if app.Spec == nil {
+		return nil, fmt.Errorf("DigitalOcean app %s has no spec", deploymentState.DOAppID)
+	}
+
+	projectRef := deploymentState.ResourceNames.VercelProject
+	if deploymentState.VercelProjectID != "" {
+		projectRef = deploymentState.VercelProjectID
+	}
+
+	vercelEnvs, err := d.vercelService.ListProjectEnvironmentVariables(ctx, projectRef)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		ClientName: deploymentState.ClientName,
+		appID:      app.ID,
+		projectRef: projectRef,
+		app:        app,
+		vercelEnvs: vercelEnvs,
+		expected:   expected,
+	}
+
+	var serviceEnvs []*godo.AppVariableDefinition
+	if len(app.Spec.Services) > 0 {
+		serviceEnvs = app.Spec.Services[0].Envs
+	}
+
+	report.Entries = append(report.Entries, diffAppVariables(ScopeAppLevel, expected.Backend.AppEnvs, app.Spec.Envs)...)
+	report.Entries = append(report.Entries, diffAppVariables(ScopeComponentLevel, expected.Backend.ComponentEnvs, serviceEnvs)...)
+	report.Entries = append(report.Entries, diffVercelVariables(expected.Frontend, productionEnvs(vercelEnvs))...)
+
+	return report, nil
+}
+
+func (r *Report) HasDrift() bool {
+	return len(r.Entries) > 0
+}
+
+func diffAppVariables(scope string, expected map[string]godo.AppVariableDefinition, live []*godo.AppVariableDefinition) []Entry {
+	liveByKey := make(map[string]godo.AppVariableDefinition, len(live))
+	for _, envVar := range live {
+		liveByKey[envVar.Key] = *envVar
+	}
+
+	var entries []Entry
+	for _, key := range sortedKeys(expected) {
+		want := expected[key]
+		entry := Entry{Provider: "digitalocean", Scope: scope, Key: key}
+
+		got, exists := liveByKey[key]
+		if !exists {
+			entry.Kind = KindMissing
+			entry.Expected = displayAppValue(want)
+			entries = append(entries, entry)
+			continue
+		}
+
+		if detail := appVariableDifference(want, got); detail != "" {
+			entry.Kind = KindChanged
+			entry.Expected = displayAppValue(want)
+			entry.Actual = displayAppValue(got)
+			entry.Detail = detail
+			entries = append(entries, entry)
+		}
+	}
+
+	for _, key := range sortedKeys(liveByKey) {
+		if _, exists := expected[key]; exists {
+			continue
+		}
+		entries = append(entries, Entry{
+			Provider: "digitalocean",
+			Scope:    scope,
+			Key:      key,
+			Kind:     KindExtra,
+			Actual:   displayAppValue(liveByKey[key]),
+		})
+	}
+
+	return entries
+}
+
+// appVariableDifference describes how a live variable differs from the expected one. DigitalOcean
+// returns secret values encrypted, so their values cannot be compared and only their type and
+// scope are checked.
+func appVariableDifference(want, got godo.AppVariableDefinition) string {
+	if appVariableType(got) != appVariableType(want) {
+		return fmt.Sprintf("type is %s, expected %s", appVariableType(got), appVariableType(want))
+	}
+	if appVariableScope(got) != appVariableScope(want) {
+		return fmt.Sprintf("scope is %s, expected %s", appVariableScope(got), appVariableScope(want))
+	}
+	if appVariableType(got) != godo.AppVariableType_Secret && got.Value != want.Value {
+		return "value differs"
+	}
+	return ""
+}
+
+func appVariableType(envVar godo.AppVariableDefinition) godo.AppVariableType {
+	if envVar.Type == "" {
+		return godo.AppVariableType_General
+	}
+	return envVar.Type
+}
+
+func appVariableScope(envVar godo.AppVariableDefinition) godo.AppVariableScope {
+	if envVar.Scope == "" || envVar.Scope == godo.AppVariableScope_Unset {
+		return godo.AppVariableScope_RunAndBuildTime
+	}
+	return envVar.Scope
+}
+
+func displayAppValue(envVar godo.AppVariableDefinition) string {
+	if envvars.IsSecretAppVariable(envVar) {
+		return envvars.Redact(envVar.Value)
+	}
+	return envVar.Value
+}
+
+func productionEnvs(envs []types.VercelProjectEnv) []types.VercelProjectEnv {
+	var production []types.VercelProjectEnv
+	for _, envVar := range envs {
+		for _, target := range envVar.Target {
+			if target == "production" {
+				production = append(production, envVar)
+				break
+			}
+		}
+	}
+	return production
+}
+
+func diffVercelVariables(expected []types.VercelEnvVariable, live []types.VercelProjectEnv) []Entry {
+	liveByKey := make(map[string]types.VercelProjectEnv, len(live))
+	for _, envVar := range live {
+		liveByKey[envVar.Key] = envVar
+	}
+
+	expectedKeys := make(map[string]bool, len(expected))
+	var entries []Entry
+	for _, want := range expected {
+		expectedKeys[want.Key] = true
+		entry := Entry{Provider: "vercel", Scope: ScopeProduction, Key: want.Key}
+
+		got, exists := liveByKey[want.Key]
+		if !exists {
+			entry.Kind = KindMissing
+			entry.Expected = displayVercelValue(want.Key, want.Type, want.Value)
+			entries = append(entries, entry)
+			continue
+		}
+
+		if detail := vercelVariableDifference(want, got); detail != "" {
+			entry.Kind = KindChanged
+			entry.Expected = displayVercelValue(want.Key, want.Type, want.Value)
+			entry.Actual = displayVercelValue(got.Key, got.Type, got.Value)
+			entry.Detail = detail
+			entries = append(entries, entry)
+		}
+	}
+
+	for _, envVar := range live {
+		if expectedKeys[envVar.Key] {
+			continue
+		}
+		entries = append(entries, Entry{
+			Provider: "vercel",
+			Scope:    ScopeProduction,
+			Key:      envVar.Key,
+			Kind:     KindExtra,
+			Actual:   displayVercelValue(envVar.Key, envVar.Type, envVar.Value),
+		})
+	}
+
+	return entries
+}
+
+// vercelVariableDifference describes how a live variable differs from the expected one. Vercel
+// never returns the value of sensitive variables, so only their type is checked.
+func vercelVariableDifference(want types.VercelEnvVariable, got types.VercelProjectEnv) string {
+	if got.Type != want.Type {
+		return fmt.Sprintf("type is %s, expected %s", got.Type, want.Type)
+	}
+	if got.Type != "sensitive" && got.Value != want.Value {
+		return "value differs"
+	}
+	return ""
+}
+
+func displayVercelValue(key, variableType, value string) string {
+	if envvars.IsSecretVercelVariable(types.VercelEnvVariable{Key: key, Type: variableType}) {
+		return envvars.Redact(value)
+	}
+	return value
+}
+
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+func (r *Report) WriteTable(w io.Writer) error {
+	if !r.HasDrift() {
+		_, err := fmt.Fprintf(w, "No drift detected for %s\n", r.ClientName)
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROVIDER\tSCOPE\tKEY\tDRIFT\tEXPECTED\tACTUAL\tDETAIL")
+	for _, entry := range r.Entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			entry.Provider,
+			entry.Scope,
+			entry.Key,
+			entry.Kind,
+			entry.Expected,
+			entry.Actual,
+			entry.Detail,
+		)
+	}
+	return tw.Flush()
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,116 @@
+package drift
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CaioDGallo/easy-cli/internal/types"
+	"github.com/digitalocean/godo"
+)
+
+type FixResult struct {
+	BackendUpdated  bool
+	FrontendUpdated bool
+}
+
+// Fix reconciles the live environment with the expected one. Missing and changed variables are
+// written; extra variables are only removed when prune is set, since they may have been added on
+// purpose.
+func (d *Detector) Fix(ctx context.Context, report *Report, prune bool) (FixResult, error) {
+	var result FixResult
+
+	backendEntries, frontendEntries := report.fixableEntries(prune)
+
+	if len(backendEntries) > 0 {
+		envVars := report.reconciledBackend(backendEntries)
+		if err := d.doService.UpdateAppEnvironmentVariablesByID(ctx, report.appID, envVars); err != nil {
+			return result, fmt.Errorf("failed to reconcile DigitalOcean environment variables: %w", err)
+		}
+		result.BackendUpdated = true
+	}
+
+	if len(frontendEntries) > 0 {
+		var upserts []types.VercelEnvVariable
+		var removals []string
+		for _, entry := range frontendEntries {
+			if entry.Kind == KindExtra {
+				removals = append(removals, entry.Key)
+				continue
+			}
+			for _, envVar := range report.expected.Frontend {
+				if envVar.Key == entry.Key {
+					upserts = append(upserts, envVar)
+				}
+			}
+		}
+
+		if len(upserts) > 0 {
+			if err := d.vercelService.UpdateProjectEnvironmentVariables(ctx, report.projectRef, upserts); err != nil {
+				return result, fmt.Errorf("failed to reconcile Vercel environment variables: %w", err)
+			}
+		}
+
+		for _, key := range removals {
+			for _, envVar := range productionEnvs(report.vercelEnvs) {
+				if envVar.Key != key {
+					continue
+				}
+				if err := d.vercelService.DeleteProjectEnvironmentVariable(ctx, report.projectRef, envVar.ID); err != nil {
+					return result, fmt.Errorf("failed to remove Vercel environment variable %s: %w", key, err)
+				}
+			}
+		}
+		result.FrontendUpdated = true
+	}
+
+	return result, nil
+}
+
+func (r *Report) fixableEntries(prune bool) (backend, frontend []Entry) {
+	for _, entry := range r.Entries {
+		if entry.Kind == KindExtra && !prune {
+			continue
+		}
+		if entry.Provider == "digitalocean" {
+			backend = append(backend, entry)
+		} else {
+			frontend = append(frontend, entry)
+		}
+	}
+	return backend, frontend
+}
+
+// reconciledBackend starts from the live app spec so untouched variables, including encrypted
+// secrets, are sent back unchanged.
+func (r *Report) reconciledBackend(entries []Entry) types.DigitalOceanEnvVars {
+	envVars := types.DigitalOceanEnvVars{
+		AppEnvs:       definitionsByKey(r.app.Spec.Envs),
+		ComponentEnvs: map[string]godo.AppVariableDefinition{},
+	}
+	if len(r.app.Spec.Services) > 0 {
+		envVars.ComponentEnvs = definitionsByKey(r.app.Spec.Services[0].Envs)
+	}
+
+	for _, entry := range entries {
+		live, expected := envVars.AppEnvs, r.expected.Backend.AppEnvs
+		if entry.Scope == ScopeComponentLevel {
+			live, expected = envVars.ComponentEnvs, r.expected.Backend.ComponentEnvs
+		}
+
+		if entry.Kind == KindExtra {
+			delete(live, entry.Key)
+		} else {
+			live[entry.Key] = expected[entry.Key]
+		}
+	}
+
+	return envVars
+}
+
+func definitionsByKey(definitions []*godo.AppVariableDefinition) map[string]godo.AppVariableDefinition {
+	byKey := make(map[string]godo.AppVariableDefinition, len(definitions))
+	for _, definition := range definitions {
+		byKey[definition.Key] = *definition
+	}
+	return byKey
+}
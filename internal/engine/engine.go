@@ -0,0 +1,323 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CaioDGallo/easy-cli/internal/rollback"
+	"github.com/CaioDGallo/easy-cli/internal/types"
+)
+
+// Step is a unit of provisioning work. Steps exchange data through Values: every key listed in
+// Inputs must be an output of one of the step's (transitive) dependencies.
+type Step struct {
+	Name      types.StepName
+	DependsOn []types.StepName
+	Inputs    []string
+	Outputs   []string
+	Run       func(ctx context.Context, values *Values) error
+	// Compensate undoes the step. It is registered with the rollback manager once the step
+	// completes, or as soon as it fails when CompensateOnFailure is set, for steps that can leave
+	// a partially created resource behind.
+	Compensate          func(ctx context.Context) error
+	CompensateOnFailure bool
+	// Resumable steps only reconfigure existing resources. When one fails, nothing is rolled back
+	// so the run can be continued later.
+	Resumable bool
+}
+
+type EventType string
+
+const (
+	EventStepStarted    EventType = "started"
+	EventStepSkipped    EventType = "skipped"
+	EventStepCompleted  EventType = "completed"
+	EventStepFailed     EventType = "failed"
+	EventStepRolledBack EventType = "rolled_back"
+)
+
+// Event reports the progress of a step. Events are delivered sequentially from the goroutine
+// that called Run, so handlers do not need to synchronize.
+type Event struct {
+	Step     types.StepName
+	Type     EventType
+	Time     time.Time
+	Duration time.Duration
+	Err      error
+}
+
+type StepError struct {
+	Step      types.StepName
+	Resumable bool
+	Err       error
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("step %s failed: %v", e.Step, e.Err)
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+type Options struct {
+	// Skip reports whether a step already completed in an earlier run.
+	Skip    func(step types.StepName) bool
+	OnEvent func(event Event)
+}
+
+// Engine runs steps as soon as their dependencies have completed, so independent steps run
+// concurrently.
+type Engine struct {
+	steps       []Step
+	byName      map[types.StepName]Step
+	rollbackMgr *rollback.Manager
+	opts        Options
+}
+
+type stepResult struct {
+	step     Step
+	err      error
+	duration time.Duration
+}
+
+func New(steps []Step, rollbackMgr *rollback.Manager, opts Options) (*Engine, error) {
+	e := &Engine{
+		steps:       steps,
+		byName:      make(map[types.StepName]Step, len(steps)),
+		rollbackMgr: rollbackMgr,
+		opts:        opts,
+	}
+
+	for _, step := range steps {
+		if _, exists := e.byName[step.Name]; exists {
+			return nil, fmt.Errorf("step %s is declared more than once", step.Name)
+		}
+		if step.Run == nil {
+			return nil, fmt.Errorf("step %s has no run function", step.Name)
+		}
+		e.byName[step.Name] = step
+	}
+
+	if err := e.validate(); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func (e *Engine) validate() error {
+	for _, step := range e.steps {
+		for _, dependency := range step.DependsOn {
+			if _, exists := e.byName[dependency]; !exists {
+				return fmt.Errorf("step %s depends on unknown step %s", step.Name, dependency)
+			}
+		}
+	}
+
+	visiting := make(map[types.StepName]bool)
+	visited := make(map[types.StepName]bool)
+	var visit func(name types.StepName) error
+	visit = func(name types.StepName) error {
+		if visited[name] {
+			return nil
+		}
+		if visiting[name] {
+			return fmt.Errorf("dependency cycle detected at step %s", name)
+		}
+		visiting[name] = true
+		for _, dependency := range e.byName[name].DependsOn {
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+		visiting[name] = false
+		visited[name] = true
+		return nil
+	}
+
+	for _, step := range e.steps {
+		if err := visit(step.Name); err != nil {
+			return err
+		}
+	}
+
+	for _, step := range e.steps {
+		provided := make(map[string]bool)
+		for _, ancestor := range e.ancestors(step.Name) {
+			for _, output := range e.byName[ancestor].Outputs {
+				provided[output] = true
+			}
+		}
+		for _, input := range step.Inputs {
+			if !provided[input] {
+				return fmt.Errorf("step %s reads %s, which none of its dependencies produce", step.Name, input)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (e *Engine) ancestors(name types.StepName) []types.StepName {
+	seen := make(map[types.StepName]bool)
+	var result []types.StepName
+	var walk func(name types.StepName)
+	walk = func(name types.StepName) {
+		for _, dependency := range e.byName[name].DependsOn {
+			if !seen[dependency] {
+				seen[dependency] = true
+				result = append(result, dependency)
+				walk(dependency)
+			}
+		}
+	}
+	walk(name)
+	return result
+}
+
+// Dependents returns every step that directly or transitively depends on the given step, in
+// declaration order.
+func (e *Engine) Dependents(name types.StepName) []types.StepName {
+	var result []types.StepName
+	for _, step := range e.steps {
+		for _, ancestor := range e.ancestors(step.Name) {
+			if ancestor == name {
+				result = append(result, step.Name)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// Run executes the steps. Once a step fails no new step is started; steps already running are
+// waited for. Unless every failed step is resumable, the compensating actions of the steps that
+// completed in this run are executed in reverse order of completion.
+func (e *Engine) Run(ctx context.Context, values *Values) error {
+	completed := make(map[types.StepName]bool, len(e.steps))
+	started := make(map[types.StepName]bool, len(e.steps))
+	results := make(chan stepResult)
+	running := 0
+
+	var failures []error
+	rollbackNeeded := false
+
+	fail := func(step Step, err error) {
+		failures = append(failures, &StepError{Step: step.Name, Resumable: step.Resumable, Err: err})
+		if !step.Resumable {
+			rollbackNeeded = true
+		}
+	}
+
+	for {
+		for progress := len(failures) == 0; progress; {
+			progress = false
+			for _, step := range e.steps {
+				if started[step.Name] || !e.ready(step, completed) {
+					continue
+				}
+				started[step.Name] = true
+				progress = true
+
+				if e.opts.Skip != nil && e.opts.Skip(step.Name) {
+					completed[step.Name] = true
+					e.emit(Event{Step: step.Name, Type: EventStepSkipped})
+					continue
+				}
+
+				if missing := missingInputs(step, values); len(missing) > 0 {
+					err := fmt.Errorf("missing inputs %v", missing)
+					e.emit(Event{Step: step.Name, Type: EventStepFailed, Err: err})
+					fail(step, err)
+					progress = false
+					break
+				}
+
+				e.emit(Event{Step: step.Name, Type: EventStepStarted})
+				running++
+				go func(step Step) {
+					start := time.Now()
+					err := step.Run(ctx, values)
+					results <- stepResult{step: step, err: err, duration: time.Since(start)}
+				}(step)
+			}
+		}
+
+		if running == 0 {
+			break
+		}
+
+		result := <-results
+		running--
+
+		if result.err != nil {
+			if result.step.CompensateOnFailure {
+				e.registerCompensation(result.step)
+			}
+			e.emit(Event{Step: result.step.Name, Type: EventStepFailed, Duration: result.duration, Err: result.err})
+			fail(result.step, result.err)
+			continue
+		}
+
+		e.registerCompensation(result.step)
+		completed[result.step.Name] = true
+		e.emit(Event{Step: result.step.Name, Type: EventStepCompleted, Duration: result.duration})
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+
+	if rollbackNeeded {
+		if err := e.rollbackMgr.ExecuteRollback(ctx); err != nil {
+			failures = append(failures, fmt.Errorf("rollback failed: %w", err))
+		}
+	}
+
+	return errors.Join(failures...)
+}
+
+func (e *Engine) ready(step Step, completed map[types.StepName]bool) bool {
+	for _, dependency := range step.DependsOn {
+		if !completed[dependency] {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Engine) registerCompensation(step Step) {
+	if step.Compensate == nil {
+		return
+	}
+	e.rollbackMgr.AddAction(string(step.Name), func(ctx context.Context) error {
+		if err := step.Compensate(ctx); err != nil {
+			return err
+		}
+		e.emit(Event{Step: step.Name, Type: EventStepRolledBack})
+		return nil
+	})
+}
+
+func (e *Engine) emit(event Event) {
+	if e.opts.OnEvent == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	e.opts.OnEvent(event)
+}
+
+func missingInputs(step Step, values *Values) []string {
+	var missing []string
+	for _, input := range step.Inputs {
+		if values.Get(input) == "" {
+			missing = append(missing, input)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,374 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/CaioDGallo/easy-cli/internal/rollback"
+	"github.com/CaioDGallo/easy-cli/internal/types"
+)
+
+var errStep = errors.New("step failed")
+
+// recorder collects what the steps of a test run did, in order.
+type recorder struct {
+	mu          sync.Mutex
+	runs        []types.StepName
+	compensated []types.StepName
+}
+
+func (r *recorder) run(name types.StepName) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runs = append(r.runs, name)
+}
+
+func (r *recorder) compensate(name types.StepName) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.compensated = append(r.compensated, name)
+}
+
+// testStep declares a step that records its run and compensation and fails when err is set.
+func (r *recorder) testStep(name types.StepName, err error, dependsOn ...types.StepName) Step {
+	return Step{
+		Name:      name,
+		DependsOn: dependsOn,
+		Run: func(ctx context.Context, values *Values) error {
+			r.run(name)
+			return err
+		},
+		Compensate: func(ctx context.Context) error {
+			r.compensate(name)
+			return nil
+		},
+	}
+}
+
+func noop(ctx context.Context, values *Values) error { return nil }
+
+func TestNewValidatesSteps(t *testing.T) {
+	tests := []struct {
+		name    string
+		steps   []Step
+		wantErr string
+	}{
+		{
+			name:    "duplicate step",
+			steps:   []Step{{Name: "a", Run: noop}, {Name: "a", Run: noop}},
+			wantErr: "declared more than once",
+		},
+		{
+			name:    "missing run function",
+			steps:   []Step{{Name: "a"}},
+			wantErr: "has no run function",
+		},
+		{
+			name:    "unknown dependency",
+			steps:   []Step{{Name: "a", Run: noop, DependsOn: []types.StepName{"b"}}},
+			wantErr: "depends on unknown step b",
+		},
+		{
+			name: "dependency cycle",
+			steps: []Step{
+				{Name: "a", Run: noop, DependsOn: []types.StepName{"b"}},
+				{Name: "b", Run: noop, DependsOn: []types.StepName{"a"}},
+			},
+			wantErr: "dependency cycle",
+		},
+		{
+			name: "input produced by a step that is not a dependency",
+			steps: []Step{
+				{Name: "a", Run: noop, Outputs: []string{"id"}},
+				{Name: "b", Run: noop, Inputs: []string{"id"}},
+			},
+			wantErr: "reads id",
+		},
+		{
+			name: "input produced by a transitive dependency",
+			steps: []Step{
+				{Name: "a", Run: noop, Outputs: []string{"id"}},
+				{Name: "b", Run: noop, DependsOn: []types.StepName{"a"}},
+				{Name: "c", Run: noop, DependsOn: []types.StepName{"b"}, Inputs: []string{"id"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.steps, rollback.NewManager(), Options{})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("New() error = %v, want none", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("New() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunOrdersStepsByDependencies(t *testing.T) {
+	r := &recorder{}
+	steps := []Step{
+		r.testStep("env", nil, "app", "project"),
+		r.testStep("project", nil, "app"),
+		r.testStep("app", nil, "bucket", "databases"),
+		r.testStep("bucket", nil),
+		r.testStep("databases", nil),
+	}
+
+	e, err := New(steps, rollback.NewManager(), Options{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := e.Run(context.Background(), NewValues(nil)); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	position := make(map[types.StepName]int, len(r.runs))
+	for i, name := range r.runs {
+		position[name] = i
+	}
+	if len(position) != len(steps) {
+		t.Fatalf("ran %v, want every step once", r.runs)
+	}
+	for _, step := range steps {
+		for _, dependency := range step.DependsOn {
+			if position[dependency] > position[step.Name] {
+				t.Errorf("%s ran before its dependency %s (order %v)", step.Name, dependency, r.runs)
+			}
+		}
+	}
+}
+
+func TestRunSkipsCompletedSteps(t *testing.T) {
+	r := &recorder{}
+	steps := []Step{
+		r.testStep("bucket", nil),
+		r.testStep("app", nil, "bucket"),
+	}
+
+	var events []Event
+	e, err := New(steps, rollback.NewManager(), Options{
+		Skip:    func(step types.StepName) bool { return step == "bucket" },
+		OnEvent: func(event Event) { events = append(events, event) },
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := e.Run(context.Background(), NewValues(nil)); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if want := []types.StepName{"app"}; !reflect.DeepEqual(r.runs, want) {
+		t.Errorf("ran %v, want %v", r.runs, want)
+	}
+	if len(events) == 0 || events[0].Step != "bucket" || events[0].Type != EventStepSkipped {
+		t.Errorf("first event = %+v, want bucket skipped", events)
+	}
+}
+
+func TestRunFailures(t *testing.T) {
+	tests := []struct {
+		name                string
+		resumable           bool
+		compensateOnFailure bool
+		wantRuns            []types.StepName
+		wantCompensated     []types.StepName
+	}{
+		{
+			name:            "completed steps are compensated in reverse order",
+			wantRuns:        []types.StepName{"bucket", "app", "env"},
+			wantCompensated: []types.StepName{"app", "bucket"},
+		},
+		{
+			name:                "failed step that can leave a resource behind is compensated first",
+			compensateOnFailure: true,
+			wantRuns:            []types.StepName{"bucket", "app", "env"},
+			wantCompensated:     []types.StepName{"env", "app", "bucket"},
+		},
+		{
+			name:      "resumable failure keeps everything",
+			resumable: true,
+			wantRuns:  []types.StepName{"bucket", "app", "env"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{}
+			failing := r.testStep("env", errStep, "app")
+			failing.Resumable = tt.resumable
+			failing.CompensateOnFailure = tt.compensateOnFailure
+			steps := []Step{
+				r.testStep("bucket", nil),
+				r.testStep("app", nil, "bucket"),
+				failing,
+				r.testStep("deployment", nil, "env"),
+			}
+
+			var rolledBack []types.StepName
+			e, err := New(steps, rollback.NewManager(), Options{
+				OnEvent: func(event Event) {
+					if event.Type == EventStepRolledBack {
+						rolledBack = append(rolledBack, event.Step)
+					}
+				},
+			})
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			err = e.Run(context.Background(), NewValues(nil))
+			var stepErr *StepError
+			if !errors.As(err, &stepErr) || stepErr.Step != "env" || !errors.Is(err, errStep) {
+				t.Fatalf("Run() error = %v, want a StepError for env", err)
+			}
+			if stepErr.Resumable != tt.resumable {
+				t.Errorf("StepError.Resumable = %t, want %t", stepErr.Resumable, tt.resumable)
+			}
+
+			if !reflect.DeepEqual(r.runs, tt.wantRuns) {
+				t.Errorf("ran %v, want %v", r.runs, tt.wantRuns)
+			}
+			if !reflect.DeepEqual(r.compensated, tt.wantCompensated) {
+				t.Errorf("compensated %v, want %v", r.compensated, tt.wantCompensated)
+			}
+			if !reflect.DeepEqual(rolledBack, tt.wantCompensated) {
+				t.Errorf("rolled back events %v, want %v", rolledBack, tt.wantCompensated)
+			}
+		})
+	}
+}
+
+func TestRunWaitsForRunningStepsBeforeRollback(t *testing.T) {
+	r := &recorder{}
+	release := make(chan struct{})
+	steps := []Step{
+		{
+			Name: "bucket",
+			Run: func(ctx context.Context, values *Values) error {
+				<-release
+				r.run("bucket")
+				return nil
+			},
+			Compensate: func(ctx context.Context) error {
+				r.compensate("bucket")
+				return nil
+			},
+		},
+		{
+			Name: "databases",
+			Run: func(ctx context.Context, values *Values) error {
+				defer close(release)
+				r.run("databases")
+				return errStep
+			},
+		},
+		r.testStep("app", nil, "bucket", "databases"),
+	}
+
+	e, err := New(steps, rollback.NewManager(), Options{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := e.Run(context.Background(), NewValues(nil)); !errors.Is(err, errStep) {
+		t.Fatalf("Run() error = %v, want %v", err, errStep)
+	}
+
+	if want := []types.StepName{"databases", "bucket"}; !reflect.DeepEqual(r.runs, want) {
+		t.Errorf("ran %v, want %v", r.runs, want)
+	}
+	// The bucket finished after the failure but was still created by this run.
+	if want := []types.StepName{"bucket"}; !reflect.DeepEqual(r.compensated, want) {
+		t.Errorf("compensated %v, want %v", r.compensated, want)
+	}
+}
+
+func TestRunPassesValuesBetweenSteps(t *testing.T) {
+	var got string
+	steps := []Step{
+		{
+			Name:    "app",
+			Outputs: []string{"app_id"},
+			Run: func(ctx context.Context, values *Values) error {
+				values.Set("app_id", "123")
+				return nil
+			},
+		},
+		{
+			Name:      "env",
+			DependsOn: []types.StepName{"app"},
+			Inputs:    []string{"app_id"},
+			Run: func(ctx context.Context, values *Values) error {
+				got = values.Get("app_id")
+				return nil
+			},
+		},
+	}
+
+	e, err := New(steps, rollback.NewManager(), Options{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := e.Run(context.Background(), NewValues(nil)); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got != "123" {
+		t.Errorf("env read app_id = %q, want 123", got)
+	}
+}
+
+func TestRunFailsStepWithMissingInputs(t *testing.T) {
+	r := &recorder{}
+	env := r.testStep("env", nil, "app")
+	env.Inputs = []string{"app_id"}
+	steps := []Step{
+		{Name: "app", Run: noop, Outputs: []string{"app_id"}},
+		env,
+	}
+
+	e, err := New(steps, rollback.NewManager(), Options{
+		// A resumed run whose earlier app step did not record its output.
+		Skip: func(step types.StepName) bool { return step == "app" },
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	err = e.Run(context.Background(), NewValues(nil))
+	if err == nil || !strings.Contains(err.Error(), "missing inputs [app_id]") {
+		t.Fatalf("Run() error = %v, want missing inputs", err)
+	}
+	if len(r.runs) != 0 {
+		t.Errorf("ran %v, want nothing", r.runs)
+	}
+}
+
+func TestDependents(t *testing.T) {
+	steps := []Step{
+		{Name: "bucket", Run: noop},
+		{Name: "app", Run: noop, DependsOn: []types.StepName{"bucket"}},
+		{Name: "databases", Run: noop},
+		{Name: "env", Run: noop, DependsOn: []types.StepName{"app"}},
+	}
+
+	e, err := New(steps, rollback.NewManager(), Options{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if got, want := e.Dependents("bucket"), []types.StepName{"app", "env"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Dependents(bucket) = %v, want %v", got, want)
+	}
+	if got := e.Dependents("databases"); len(got) != 0 {
+		t.Errorf("Dependents(databases) = %v, want none", got)
+	}
+}
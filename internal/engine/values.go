@@ -0,0 +1,31 @@
+package engine
+
+import "sync"
+
+// Values holds the outputs steps hand to each other. It is safe for concurrent use.
+type Values struct {
+	mu     sync.RWMutex
+	values map[string]string
+}
+
+func NewValues(initial map[string]string) *Values {
+	values := make(map[string]string, len(initial))
+	for key, value := range initial {
+		if value != "" {
+			values[key] = value
+		}
+	}
+	return &Values{values: values}
+}
+
+func (v *Values) Get(key string) string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.values[key]
+}
+
+func (v *Values) Set(key, value string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.values[key] = value
+}
@@ -2,6 +2,8 @@ package envvars
 
 import (
 	"fmt"
+	"maps"
+	"sort"
 
 	"github.com/CaioDGallo/easy-cli/internal/config"
 	"github.com/CaioDGallo/easy-cli/internal/resources"
@@ -10,16 +12,32 @@ import (
 )
 
 func GenerateDeploymentEnvironment(client types.Client, cfg *config.Config) (types.DeploymentEnvironment, error) {
-	resourceNames := resources.GenerateResourceNames(client.SanitizedClientName, cfg)
+	resourceNames := resources.GenerateResourceNames(client.SanitizedClientName, client.S3Region, cfg)
 	defaults := resources.GetDeploymentDefaults(cfg)
 
 	if err := resources.ValidateResourceNames(resourceNames); err != nil {
 		return types.DeploymentEnvironment{}, fmt.Errorf("invalid resource names: %w", err)
 	}
 
+	if err := validateSecrets(client.Secrets); err != nil {
+		return types.DeploymentEnvironment{}, err
+	}
+	if client.Secrets.DatabasePassword == "" {
+		return types.DeploymentEnvironment{}, fmt.Errorf("client database password has not been generated (run fresh-install --resume to create the client's database role)")
+	}
+
 	frontend := generateFrontendEnvironment(resourceNames, defaults, client)
 	backend := generateBackendEnvironment(resourceNames, defaults, client)
 
+	for _, key := range sortedKeys(client.ExtraEnv.Backend) {
+		_, isAppLevel := backend.AppLevelVars[key]
+		_, isComponentLevel := backend.ComponentLevelVars[key]
+		if isAppLevel || isComponentLevel {
+			return types.DeploymentEnvironment{}, fmt.Errorf("extra backend variable %s conflicts with a generated variable", key)
+		}
+		backend.ComponentLevelVars[key] = appVariable(key, client.ExtraEnv.Backend[key])
+	}
+
 	return types.DeploymentEnvironment{
 		ResourceNames: resourceNames,
 		Frontend:      frontend,
@@ -27,25 +45,38 @@ func GenerateDeploymentEnvironment(client types.Client, cfg *config.Config) (typ
 	}, nil
 }
 
-func generateFrontendEnvironment(resourceNames types.ResourceNames, defaults types.DeploymentDefaults, client types.Client) types.FrontendEnvironment {
-	backendURL := resourceNames.BackendURL
-	if client.BackendInfo.URL != "" {
-		backendURL = client.BackendInfo.URL
+// clientURLs returns the frontend and backend URLs the environment points at. A custom domain
+// takes precedence over the URLs the platforms assigned.
+func clientURLs(resourceNames types.ResourceNames, client types.Client) (frontendURL, backendURL string) {
+	if client.Domain != "" {
+		domains := resources.CustomDomainsFor(client.Domain)
+		return "https://" + domains.Frontend, "https://" + domains.Backend
 	}
 
-	frontendURL := resourceNames.FrontendURL
+	frontendURL = resourceNames.FrontendURL
 	if client.FrontendInfo.URL != "" {
 		frontendURL = client.FrontendInfo.URL
 	}
 
+	backendURL = resourceNames.BackendURL
+	if client.BackendInfo.URL != "" {
+		backendURL = client.BackendInfo.URL
+	}
+
+	return frontendURL, backendURL
+}
+
+func generateFrontendEnvironment(resourceNames types.ResourceNames, defaults types.DeploymentDefaults, client types.Client) types.FrontendEnvironment {
+	frontendURL, backendURL := clientURLs(resourceNames, client)
+
 	return types.FrontendEnvironment{
-		S3URL:               fmt.Sprintf("https://%s.s3.amazonaws.com", resourceNames.S3Bucket),
+		S3URL:               resourceNames.S3URL,
 		StrapiURL:           backendURL,
 		DefaultLanguage:     defaults.Frontend.DefaultLanguage,
 		FrontURL:            frontendURL,
 		ValidationTime:      defaults.Frontend.ValidationTime,
 		AmazonEnv:           defaults.Frontend.AmazonEnv,
-		RevalidationToken:   defaults.Frontend.RevalidationToken,
+		RevalidationToken:   client.Secrets.RevalidationToken,
 		ForcedLoginTimeInMs: defaults.Frontend.ForcedLoginTimeInMs,
 	}
 }
@@ -57,34 +88,25 @@ func generateBackendEnvironment(resourceNames types.ResourceNames, defaults type
 	appLevelVars := map[string]string{
 		"Security_JWT_Issuer":            defaults.JWT.Issuer,
 		"Security_JWT_Audience":          defaults.JWT.Audience,
-		"Security_JWT_Key":               defaults.JWT.Key,
+		"Security_JWT_Key":               client.Secrets.JWTKey,
 		"Security_JWT_ExpirationMinutes": defaults.JWT.ExpirationMinutes,
 	}
 
-	for key, value := range appLevelVars {
-		appLevelEnvVars[key] = godo.AppVariableDefinition{
-			Key:   key,
-			Value: value,
-			Scope: godo.AppVariableScope_RunAndBuildTime,
-			Type:  godo.AppVariableType_General,
-		}
+	if !client.Labels.IsZero() {
+		maps.Copy(appLevelVars, client.Labels.EnvVars())
 	}
 
-	frontendURL := resourceNames.FrontendURL
-	if client.FrontendInfo.URL != "" {
-		frontendURL = client.FrontendInfo.URL
+	for key, value := range appLevelVars {
+		appLevelEnvVars[key] = appVariable(key, value)
 	}
 
-	backendURL := resourceNames.BackendURL
-	if client.BackendInfo.URL != "" {
-		backendURL = client.BackendInfo.URL
-	}
+	frontendURL, backendURL := clientURLs(resourceNames, client)
 
 	componentLevelVars := map[string]string{
-		"ConnectionStrings__NextGenDBContext": fmt.Sprintf("Server=%s;Database=%s;Username=%s;Password=%s;IncludeErrorDetail=true",
-			client.DatabaseHost, resourceNames.DatabaseMain, client.DatabaseUser, client.BackendInfo.DatabasePassword),
-		"ConnectionStrings__Hangfire": fmt.Sprintf("Server=%s;Database=%s;Username=%s;Password=%s",
-			client.DatabaseHost, resourceNames.DatabaseHangfire, client.DatabaseUser, client.BackendInfo.DatabasePassword),
+		"ConnectionStrings__NextGenDBContext": fmt.Sprintf("Server=%s;Port=%d;Database=%s;Username=%s;Password=%s;%s;IncludeErrorDetail=true",
+			client.DatabaseHost, client.DatabasePort, resourceNames.DatabaseMain, resourceNames.DatabaseRole, client.Secrets.DatabasePassword, npgsqlSSLOptions(client.DatabaseSSLMode)),
+		"ConnectionStrings__Hangfire": fmt.Sprintf("Server=%s;Port=%d;Database=%s;Username=%s;Password=%s;%s",
+			client.DatabaseHost, client.DatabasePort, resourceNames.DatabaseHangfire, resourceNames.DatabaseRole, client.Secrets.DatabasePassword, npgsqlSSLOptions(client.DatabaseSSLMode)),
 		"SMTP_Server":          client.SMTPInfo.Server,
 		"SMTP_Port":            client.SMTPInfo.Port,
 		"SMTP_Username":        client.SMTPInfo.Username,
@@ -92,7 +114,7 @@ func generateBackendEnvironment(resourceNames types.ResourceNames, defaults type
 		"SMTP_DoNotReplyName":  client.SMTPInfo.DoNotReplyName,
 		"SMTP_DoNotReplyEmail": client.SMTPInfo.DoNotReplyEmail,
 		"SMTP_DevEmail":        client.SMTPInfo.DevEmail,
-		"Paths_MediaPath":      fmt.Sprintf("https://%s.s3.amazonaws.com", resourceNames.S3Bucket),
+		"Paths_MediaPath":      resourceNames.S3URL,
 		"Paths_FrontEndPath":   frontendURL,
 		"Paths_BackendPath":    backendURL,
 		"AWS_S3_BUCKET":        resourceNames.S3Bucket,
@@ -100,13 +122,15 @@ func generateBackendEnvironment(resourceNames types.ResourceNames, defaults type
 		"AWS_S3_PATH":          defaults.S3Path,
 	}
 
+	// Without client credentials, the backend keeps using the ones it is deployed with.
+	if client.Secrets.S3AccessKeyID != "" {
+		componentLevelVars["AWS_ACCESS_KEY_ID"] = client.Secrets.S3AccessKeyID
+		componentLevelVars["AWS_SECRET_ACCESS_KEY"] = client.Secrets.S3SecretAccessKey
+		componentLevelVars["AWS_REGION"] = client.S3Region
+	}
+
 	for key, value := range componentLevelVars {
-		componentLevelEnvVars[key] = godo.AppVariableDefinition{
-			Key:   key,
-			Value: value,
-			Scope: godo.AppVariableScope_RunAndBuildTime,
-			Type:  godo.AppVariableType_General,
-		}
+		componentLevelEnvVars[key] = appVariable(key, value)
 	}
 
 	return types.BackendEnvironment{
@@ -115,17 +139,83 @@ func generateBackendEnvironment(resourceNames types.ResourceNames, defaults type
 	}
 }
 
+// npgsqlSSLOptions translates a libpq sslmode into the options of the backend's .NET driver.
+// With require, libpq encrypts without checking the certificate, which older Npgsql versions
+// only do when told to trust it.
+func npgsqlSSLOptions(sslMode string) string {
+	switch sslMode {
+	case "require":
+		return "SSL Mode=Require;Trust Server Certificate=true"
+	case "verify-ca":
+		return "SSL Mode=VerifyCA"
+	case "verify-full":
+		return "SSL Mode=VerifyFull"
+	default:
+		return "SSL Mode=Disable"
+	}
+}
+
+func appVariable(key, value string) godo.AppVariableDefinition {
+	variableType := godo.AppVariableType_General
+	if IsSecret(key) {
+		variableType = godo.AppVariableType_Secret
+	}
+
+	return godo.AppVariableDefinition{
+		Key:   key,
+		Value: value,
+		Scope: godo.AppVariableScope_RunAndBuildTime,
+		Type:  variableType,
+	}
+}
+
+func validateSecrets(clientSecrets types.ClientSecrets) error {
+	if clientSecrets.JWTKey == "" || clientSecrets.RevalidationToken == "" {
+		return fmt.Errorf("client secrets have not been generated")
+	}
+	return nil
+}
+
+// GenerateSecretEnvironment returns only the variables that carry the client's generated
+// secrets, so they can be pushed on their own when the secrets are rotated.
+func GenerateSecretEnvironment(clientSecrets types.ClientSecrets) (types.DigitalOceanEnvVars, []types.VercelEnvVariable, error) {
+	if err := validateSecrets(clientSecrets); err != nil {
+		return types.DigitalOceanEnvVars{}, nil, err
+	}
+
+	backendEnvVars := types.DigitalOceanEnvVars{
+		AppEnvs: map[string]godo.AppVariableDefinition{
+			"Security_JWT_Key": appVariable("Security_JWT_Key", clientSecrets.JWTKey),
+		},
+	}
+
+	frontendEnvVars := []types.VercelEnvVariable{
+		{
+			Key:    "NEXT_PUBLIC_REVALIDATION_TOKEN",
+			Target: "production",
+			Value:  clientSecrets.RevalidationToken,
+			Type:   "encrypted",
+		},
+	}
+
+	return backendEnvVars, frontendEnvVars, nil
+}
+
 func GenerateVercelEnvironmentVariables(client types.Client, cfg *config.Config) ([]types.VercelEnvVariable, error) {
-	resourceNames := resources.GenerateResourceNames(client.SanitizedClientName, cfg)
+	resourceNames := resources.GenerateResourceNames(client.SanitizedClientName, client.S3Region, cfg)
 	defaults := resources.GetDeploymentDefaults(cfg)
 
 	if err := resources.ValidateResourceNames(resourceNames); err != nil {
 		return nil, fmt.Errorf("invalid resource names: %w", err)
 	}
 
+	if err := validateSecrets(client.Secrets); err != nil {
+		return nil, err
+	}
+
 	frontend := generateFrontendEnvironment(resourceNames, defaults, client)
 
-	return []types.VercelEnvVariable{
+	vercelEnvVars := []types.VercelEnvVariable{
 		{
 			Key:    "NEXT_PUBLIC_S3_URL",
 			Target: "production",
@@ -174,5 +264,36 @@ func GenerateVercelEnvironmentVariables(client types.Client, cfg *config.Config)
 			Value:  frontend.ForcedLoginTimeInMs,
 			Type:   "plain",
 		},
-	}, nil
+	}
+
+	for _, key := range sortedKeys(client.ExtraEnv.Frontend) {
+		for _, envVar := range vercelEnvVars {
+			if envVar.Key == key {
+				return nil, fmt.Errorf("extra frontend variable %s conflicts with a generated variable", key)
+			}
+		}
+
+		variableType := "plain"
+		if IsSecret(key) {
+			variableType = "encrypted"
+		}
+
+		vercelEnvVars = append(vercelEnvVars, types.VercelEnvVariable{
+			Key:    key,
+			Target: "production",
+			Value:  client.ExtraEnv.Frontend[key],
+			Type:   variableType,
+		})
+	}
+
+	return vercelEnvVars, nil
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
 }
@@ -0,0 +1,154 @@
+package envvars
+
+import (
+	"testing"
+
+	"github.com/CaioDGallo/easy-cli/internal/config"
+	"github.com/CaioDGallo/easy-cli/internal/types"
+)
+
+// The frontend and the backend must point at the bucket in the client's own region.
+func TestGeneratorsUseClientBucketRegion(t *testing.T) {
+	cfg := &config.Config{
+		AWS:         config.AWSConfig{Region: "us-east-1"},
+		Application: config.ApplicationConfig{NamePrefix: "easy"},
+	}
+	client := types.Client{
+		Name:                "Acme",
+		SanitizedClientName: "acme",
+		S3Region:            "eu-west-1",
+		Secrets: types.ClientSecrets{
+			JWTKey:            "jwt",
+			RevalidationToken: "token",
+			DatabasePassword:  "password",
+		},
+	}
+	want := "https://easy-acme.s3.eu-west-1.amazonaws.com"
+
+	deploymentEnv, err := GenerateDeploymentEnvironment(client, cfg)
+	if err != nil {
+		t.Fatalf("GenerateDeploymentEnvironment() error = %v", err)
+	}
+	if got := deploymentEnv.ResourceNames.S3URL; got != want {
+		t.Errorf("resource names S3 URL = %q, want %q", got, want)
+	}
+	if got := deploymentEnv.Backend.ComponentLevelVars["Paths_MediaPath"].Value; got != want {
+		t.Errorf("Paths_MediaPath = %q, want %q", got, want)
+	}
+
+	vercelEnvVars, err := GenerateVercelEnvironmentVariables(client, cfg)
+	if err != nil {
+		t.Fatalf("GenerateVercelEnvironmentVariables() error = %v", err)
+	}
+	for _, envVar := range vercelEnvVars {
+		if envVar.Key == "NEXT_PUBLIC_S3_URL" && envVar.Value != want {
+			t.Errorf("NEXT_PUBLIC_S3_URL = %q, want %q", envVar.Value, want)
+		}
+	}
+}
+
+// DigitalOcean apps carry the client's labels in their app-level environment.
+func TestBackendEnvironmentLabelsApp(t *testing.T) {
+	cfg := &config.Config{
+		AWS:         config.AWSConfig{Region: "us-east-1"},
+		Application: config.ApplicationConfig{NamePrefix: "easy"},
+	}
+	client := types.Client{
+		Name:                "Acme",
+		SanitizedClientName: "acme",
+		Secrets: types.ClientSecrets{
+			JWTKey:            "jwt",
+			RevalidationToken: "token",
+			DatabasePassword:  "password",
+		},
+		Labels: types.ResourceLabels{
+			Client:        "Acme",
+			SanitizedName: "acme",
+			Environment:   "production",
+			CreatedBy:     "ops",
+			Version:       "1.2.0",
+		},
+	}
+
+	deploymentEnv, err := GenerateDeploymentEnvironment(client, cfg)
+	if err != nil {
+		t.Fatalf("GenerateDeploymentEnvironment() error = %v", err)
+	}
+
+	envs := make(map[string]string, len(deploymentEnv.Backend.AppLevelVars))
+	for key, envVar := range deploymentEnv.Backend.AppLevelVars {
+		envs[key] = envVar.Value
+	}
+	labels, ok := types.LabelsFromEnvVars(envs)
+	if !ok || labels != client.Labels {
+		t.Errorf("labels read back from app variables = %+v, want %+v", labels, client.Labels)
+	}
+
+	client.Labels = types.ResourceLabels{}
+	deploymentEnv, err = GenerateDeploymentEnvironment(client, cfg)
+	if err != nil {
+		t.Fatalf("GenerateDeploymentEnvironment() error = %v", err)
+	}
+	if _, ok := deploymentEnv.Backend.AppLevelVars[types.LabelEnvSanitizedName]; ok {
+		t.Errorf("unlabeled client got %s", types.LabelEnvSanitizedName)
+	}
+}
+
+// The backend connects as the client's own role, never as the admin user.
+func TestBackendConnectionStrings(t *testing.T) {
+	tests := []struct {
+		sslMode      string
+		wantMain     string
+		wantHangfire string
+	}{
+		{
+			sslMode:      "disable",
+			wantMain:     "Server=db.example.com;Port=25060;Database=acme;Username=acme-app;Password=password;SSL Mode=Disable;IncludeErrorDetail=true",
+			wantHangfire: "Server=db.example.com;Port=25060;Database=acme-hf;Username=acme-app;Password=password;SSL Mode=Disable",
+		},
+		{
+			sslMode:      "require",
+			wantMain:     "Server=db.example.com;Port=25060;Database=acme;Username=acme-app;Password=password;SSL Mode=Require;Trust Server Certificate=true;IncludeErrorDetail=true",
+			wantHangfire: "Server=db.example.com;Port=25060;Database=acme-hf;Username=acme-app;Password=password;SSL Mode=Require;Trust Server Certificate=true",
+		},
+		{
+			sslMode:      "verify-full",
+			wantMain:     "Server=db.example.com;Port=25060;Database=acme;Username=acme-app;Password=password;SSL Mode=VerifyFull;IncludeErrorDetail=true",
+			wantHangfire: "Server=db.example.com;Port=25060;Database=acme-hf;Username=acme-app;Password=password;SSL Mode=VerifyFull",
+		},
+	}
+
+	cfg := &config.Config{
+		AWS:         config.AWSConfig{Region: "us-east-1"},
+		Application: config.ApplicationConfig{NamePrefix: "easy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sslMode, func(t *testing.T) {
+			client := types.Client{
+				Name:                "Acme",
+				SanitizedClientName: "acme",
+				DatabaseHost:        "db.example.com",
+				DatabasePort:        25060,
+				DatabaseSSLMode:     tt.sslMode,
+				Secrets: types.ClientSecrets{
+					JWTKey:            "jwt",
+					RevalidationToken: "token",
+					DatabasePassword:  "password",
+				},
+			}
+
+			deploymentEnv, err := GenerateDeploymentEnvironment(client, cfg)
+			if err != nil {
+				t.Fatalf("GenerateDeploymentEnvironment() error = %v", err)
+			}
+
+			envs := deploymentEnv.Backend.ComponentLevelVars
+			if got := envs["ConnectionStrings__NextGenDBContext"].Value; got != tt.wantMain {
+				t.Errorf("main connection string = %q, want %q", got, tt.wantMain)
+			}
+			if got := envs["ConnectionStrings__Hangfire"].Value; got != tt.wantHangfire {
+				t.Errorf("hangfire connection string = %q, want %q", got, tt.wantHangfire)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package envvars
+
+import (
+	"github.com/CaioDGallo/easy-cli/internal/types"
+	"github.com/digitalocean/godo"
+)
+
+const redactedValue = "********"
+
+var secretKeys = map[string]bool{
+	"Security_JWT_Key":                    true,
+	"SMTP_Password":                       true,
+	"ConnectionStrings__NextGenDBContext": true,
+	"ConnectionStrings__Hangfire":         true,
+	"NEXT_PUBLIC_REVALIDATION_TOKEN":      true,
+	"AWS_ACCESS_KEY_ID":                   true,
+	"AWS_SECRET_ACCESS_KEY":               true,
+}
+
+// IsSecret reports whether the variable must never be printed in clear text.
+func IsSecret(key string) bool {
+	return secretKeys[key]
+}
+
+func IsSecretAppVariable(envVar godo.AppVariableDefinition) bool {
+	return envVar.Type == godo.AppVariableType_Secret || IsSecret(envVar.Key)
+}
+
+func IsSecretVercelVariable(envVar types.VercelEnvVariable) bool {
+	return envVar.Type == "encrypted" || envVar.Type == "sensitive" || IsSecret(envVar.Key)
+}
+
+func Redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	return redactedValue
+}
+
+func RedactAppVariables(envs map[string]godo.AppVariableDefinition) map[string]godo.AppVariableDefinition {
+	redacted := make(map[string]godo.AppVariableDefinition, len(envs))
+	for key, envVar := range envs {
+		if IsSecretAppVariable(envVar) {
+			envVar.Value = Redact(envVar.Value)
+		}
+		redacted[key] = envVar
+	}
+	return redacted
+}
+
+func RedactVercelVariables(envs []types.VercelEnvVariable) []types.VercelEnvVariable {
+	redacted := make([]types.VercelEnvVariable, len(envs))
+	for i, envVar := range envs {
+		if IsSecretVercelVariable(envVar) {
+			envVar.Value = Redact(envVar.Value)
+		}
+		redacted[i] = envVar
+	}
+	return redacted
+}
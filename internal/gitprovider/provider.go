@@ -0,0 +1,205 @@
+package gitprovider
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/CaioDGallo/easy-cli/internal/types"
+	"github.com/digitalocean/godo"
+)
+
+// Provider translates a repository into the source definitions DigitalOcean and Vercel expect.
+type Provider interface {
+	Name() types.GitProvider
+	// ValidateRepository checks the repository name and, when set, its ID.
+	ValidateRepository(repo types.Repository) error
+	// RequiresDeploymentID reports whether Vercel needs the repository ID to create a deployment.
+	RequiresDeploymentID() bool
+	ApplyAppSource(service *godo.AppServiceSpec, repo types.Repository, branch string)
+	VercelGitRepo(repo types.Repository) types.VercelGitRepo
+	VercelGitSource(repo types.Repository, ref string) types.VercelGitSource
+}
+
+var providers = map[types.GitProvider]Provider{
+	types.GitProviderBitbucket: bitbucketProvider{},
+	types.GitProviderGitHub:    gitHubProvider{},
+	types.GitProviderGitLab:    gitLabProvider{},
+}
+
+var numericIDRegex = regexp.MustCompile(`^\d+$`)
+
+// For returns the provider of the repository. An empty provider means Bitbucket.
+func For(repo types.Repository) (Provider, error) {
+	name := repo.Provider
+	if name == "" {
+		name = types.GitProviderBitbucket
+	}
+
+	provider, ok := providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported git provider %q (expected bitbucket, github or gitlab)", repo.Provider)
+	}
+
+	return provider, nil
+}
+
+// Validate checks the repository against the rules of its provider. requireID is set for
+// repositories Vercel deploys from.
+func Validate(repo types.Repository, requireID bool) error {
+	provider, err := For(repo)
+	if err != nil {
+		return err
+	}
+
+	if err := provider.ValidateRepository(repo); err != nil {
+		return err
+	}
+
+	if requireID && repo.ID == "" && provider.RequiresDeploymentID() {
+		return fmt.Errorf("%s repository %s needs an ID to be deployed on Vercel", provider.Name(), repo.Name)
+	}
+
+	return nil
+}
+
+type bitbucketProvider struct{}
+
+var (
+	bitbucketNameRegex = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+	bitbucketUUIDRegex = regexp.MustCompile(`^\{[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\}$`)
+)
+
+func (bitbucketProvider) Name() types.GitProvider {
+	return types.GitProviderBitbucket
+}
+
+func (bitbucketProvider) ValidateRepository(repo types.Repository) error {
+	if !bitbucketNameRegex.MatchString(repo.Name) {
+		return fmt.Errorf("invalid Bitbucket repository %q (expected workspace/repository)", repo.Name)
+	}
+	if repo.ID != "" && !bitbucketUUIDRegex.MatchString(repo.ID) {
+		return fmt.Errorf("invalid Bitbucket repository UUID %q (expected {xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx})", repo.ID)
+	}
+	return nil
+}
+
+func (bitbucketProvider) RequiresDeploymentID() bool {
+	return true
+}
+
+func (bitbucketProvider) ApplyAppSource(service *godo.AppServiceSpec, repo types.Repository, branch string) {
+	service.Bitbucket = &godo.BitbucketSourceSpec{
+		Repo:         repo.Name,
+		Branch:       branch,
+		DeployOnPush: true,
+	}
+}
+
+func (bitbucketProvider) VercelGitRepo(repo types.Repository) types.VercelGitRepo {
+	return types.VercelGitRepo{Repo: repo.Name, Type: string(types.GitProviderBitbucket)}
+}
+
+func (bitbucketProvider) VercelGitSource(repo types.Repository, ref string) types.VercelGitSource {
+	return types.VercelGitSource{
+		Type:     string(types.GitProviderBitbucket),
+		Repo:     repo.Name,
+		RepoUuid: repo.ID,
+		Ref:      ref,
+	}
+}
+
+type gitHubProvider struct{}
+
+var gitHubNameRegex = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9-]{0,38})/[A-Za-z0-9._-]{1,100}$`)
+
+func (gitHubProvider) Name() types.GitProvider {
+	return types.GitProviderGitHub
+}
+
+func (gitHubProvider) ValidateRepository(repo types.Repository) error {
+	if !gitHubNameRegex.MatchString(repo.Name) {
+		return fmt.Errorf("invalid GitHub repository %q (expected owner/repository)", repo.Name)
+	}
+	if repo.ID != "" && !numericIDRegex.MatchString(repo.ID) {
+		return fmt.Errorf("invalid GitHub repository ID %q (expected a number)", repo.ID)
+	}
+	return nil
+}
+
+// RequiresDeploymentID is false because Vercel can resolve GitHub repositories by org and name.
+func (gitHubProvider) RequiresDeploymentID() bool {
+	return false
+}
+
+func (gitHubProvider) ApplyAppSource(service *godo.AppServiceSpec, repo types.Repository, branch string) {
+	service.GitHub = &godo.GitHubSourceSpec{
+		Repo:         repo.Name,
+		Branch:       branch,
+		DeployOnPush: true,
+	}
+}
+
+func (gitHubProvider) VercelGitRepo(repo types.Repository) types.VercelGitRepo {
+	return types.VercelGitRepo{Repo: repo.Name, Type: string(types.GitProviderGitHub)}
+}
+
+func (gitHubProvider) VercelGitSource(repo types.Repository, ref string) types.VercelGitSource {
+	if repo.ID != "" {
+		return types.VercelGitSource{
+			Type:   string(types.GitProviderGitHub),
+			RepoID: repo.ID,
+			Ref:    ref,
+		}
+	}
+
+	org, name, _ := strings.Cut(repo.Name, "/")
+	return types.VercelGitSource{
+		Type: string(types.GitProviderGitHub),
+		Org:  org,
+		Repo: name,
+		Ref:  ref,
+	}
+}
+
+type gitLabProvider struct{}
+
+var gitLabNameRegex = regexp.MustCompile(`^[A-Za-z0-9_.-]+(?:/[A-Za-z0-9_.-]+)+$`)
+
+func (gitLabProvider) Name() types.GitProvider {
+	return types.GitProviderGitLab
+}
+
+func (gitLabProvider) ValidateRepository(repo types.Repository) error {
+	if !gitLabNameRegex.MatchString(repo.Name) {
+		return fmt.Errorf("invalid GitLab project %q (expected group/project, subgroups allowed)", repo.Name)
+	}
+	if repo.ID != "" && !numericIDRegex.MatchString(repo.ID) {
+		return fmt.Errorf("invalid GitLab project ID %q (expected a number)", repo.ID)
+	}
+	return nil
+}
+
+func (gitLabProvider) RequiresDeploymentID() bool {
+	return true
+}
+
+func (gitLabProvider) ApplyAppSource(service *godo.AppServiceSpec, repo types.Repository, branch string) {
+	service.GitLab = &godo.GitLabSourceSpec{
+		Repo:         repo.Name,
+		Branch:       branch,
+		DeployOnPush: true,
+	}
+}
+
+func (gitLabProvider) VercelGitRepo(repo types.Repository) types.VercelGitRepo {
+	return types.VercelGitRepo{Repo: repo.Name, Type: string(types.GitProviderGitLab)}
+}
+
+func (gitLabProvider) VercelGitSource(repo types.Repository, ref string) types.VercelGitSource {
+	return types.VercelGitSource{
+		Type:      string(types.GitProviderGitLab),
+		ProjectID: repo.ID,
+		Ref:       ref,
+	}
+}
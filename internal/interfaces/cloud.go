@@ -8,24 +8,38 @@ import (
 )
 
 type CloudStorageProvider interface {
-	CreateBucket(ctx context.Context, bucketName string) error
+	CreateBucket(ctx context.Context, bucketName string, options types.BucketOptions) error
 	DeleteBucket(ctx context.Context, bucketName string) error
 }
 
 type DatabaseProvider interface {
 	CreateClientDatabase(sanitizedClientName string) error
 	DeleteClientDatabases(mainDBName, hangfireDBName string) error
+	ConfigureClientRole(roleName, password string, dbNames ...string) error
+	DropClientRole(roleName string, dbNames ...string) error
 }
 
 type AppHostingProvider interface {
-	CreateApp(ctx context.Context, client types.Client, envVars types.DigitalOceanEnvVars, cfg *config.Config) (string, error)
+	CreateApp(ctx context.Context, client types.Client, envVars types.DigitalOceanEnvVars, cfg *config.Config) (types.DigitalOceanApp, error)
 	UpdateAppEnvironmentVariables(ctx context.Context, appName string, envVars types.DigitalOceanEnvVars, cfg *config.Config) error
+	UpdateAppEnvironmentVariablesByID(ctx context.Context, appID string, envVars types.DigitalOceanEnvVars) error
 	DeleteApp(ctx context.Context, appName string, cfg *config.Config) error
+	DeleteAppByID(ctx context.Context, appID string) error
 }
 
 type StaticHostingProvider interface {
-	CreateProject(ctx context.Context, sanitizedClientName string, envVars []types.VercelEnvVariable, cfg *config.Config) (string, error)
+	CreateProject(ctx context.Context, sanitizedClientName string, envVars []types.VercelEnvVariable, cfg *config.Config) (types.VercelProject, error)
 	DeleteProject(ctx context.Context, projectName string) error
 	CreateDeployment(ctx context.Context, client types.Client, cfg *config.Config) error
 	UpdateProjectEnvironmentVariables(ctx context.Context, projectName string, envVars []types.VercelEnvVariable) error
 }
+
+// DNSProvider publishes the records custom domains need. UpsertRecords returns the records with
+// the zone they were written to, which DeleteRecords uses to remove them again.
+type DNSProvider interface {
+	Name() string
+	// ZoneFor returns the zone that serves the name.
+	ZoneFor(ctx context.Context, name string) (string, error)
+	UpsertRecords(ctx context.Context, records []types.DNSRecord) ([]types.DNSRecord, error)
+	DeleteRecords(ctx context.Context, records []types.DNSRecord) error
+}
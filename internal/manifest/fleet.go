@@ -0,0 +1,203 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/CaioDGallo/easy-cli/internal/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// Fleet is a list of client manifests provisioned together by `batch-install`.
+type Fleet struct {
+	Version int        `yaml:"version"`
+	Clients []Manifest `yaml:"clients"`
+}
+
+var csvColumns = map[string]func(m *Manifest, value string){
+	"name":                 func(m *Manifest, value string) { m.Client.Name = value },
+	"domain":               func(m *Manifest, value string) { m.Client.Domain = value },
+	"smtp_server":          func(m *Manifest, value string) { m.SMTP.Server = value },
+	"smtp_port":            func(m *Manifest, value string) { m.SMTP.Port = value },
+	"smtp_username":        func(m *Manifest, value string) { m.SMTP.Username = value },
+	"smtp_password":        func(m *Manifest, value string) { m.SMTP.Password = value },
+	"smtp_password_env":    func(m *Manifest, value string) { m.SMTP.PasswordEnv = value },
+	"smtp_donotreplyname":  func(m *Manifest, value string) { m.SMTP.DoNotReplyName = value },
+	"smtp_donotreplyemail": func(m *Manifest, value string) { m.SMTP.DoNotReplyEmail = value },
+	"smtp_devemail":        func(m *Manifest, value string) { m.SMTP.DevEmail = value },
+	"backend_branch":       func(m *Manifest, value string) { m.Branches.Backend = value },
+	"frontend_branch":      func(m *Manifest, value string) { m.Branches.Frontend = value },
+	"tier":                 func(m *Manifest, value string) { m.DigitalOcean.Tier = value },
+	"region":               func(m *Manifest, value string) { m.DigitalOcean.Region = value },
+	"instance_size":        func(m *Manifest, value string) { m.DigitalOcean.InstanceSize = value },
+	"db_template":          func(m *Manifest, value string) { m.Database.Template = value },
+	"s3_region":            func(m *Manifest, value string) { m.S3.Region = value },
+	"s3_seed":              func(m *Manifest, value string) { m.S3.Seed = value },
+}
+
+// LoadFleet reads a fleet file. Files ending in .csv are read as CSV with a header row, anything
+// else as YAML.
+func LoadFleet(path string) ([]*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fleet file %s: %w", path, err)
+	}
+
+	var manifests []*Manifest
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		manifests, err = parseFleetCSV(data)
+	} else {
+		manifests, err = parseFleetYAML(data)
+	}
+	if err != nil {
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			validationErr.Path = path
+			return nil, validationErr
+		}
+		return nil, fmt.Errorf("failed to parse fleet file %s: %w", path, err)
+	}
+
+	if len(manifests) == 0 {
+		return nil, fmt.Errorf("fleet file %s has no clients", path)
+	}
+
+	// Clients are keyed by sanitized name, which names their resources and deployment state.
+	seen := make(map[string]string, len(manifests))
+	for _, m := range manifests {
+		name := utils.SanitizeClientName(m.Client.Name)
+		if previous, ok := seen[name]; ok {
+			return nil, fmt.Errorf("fleet file %s lists clients %q and %q, which share the sanitized name %s", path, previous, m.Client.Name, name)
+		}
+		seen[name] = m.Client.Name
+	}
+
+	return manifests, nil
+}
+
+func parseFleetYAML(data []byte) ([]*Manifest, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+
+	var fleet Fleet
+	if err := decodeStrict(data, &fleet); err != nil {
+		return nil, err
+	}
+
+	clientNodes := fleetClientNodes(&root)
+
+	var fieldErrors []FieldError
+	if fleet.Version != CurrentVersion {
+		fieldErrors = append(fieldErrors, FieldError{
+			Line:    (&Manifest{root: &root}).line("version"),
+			Field:   "version",
+			Message: fmt.Sprintf("must be %d", CurrentVersion),
+		})
+	}
+
+	manifests := make([]*Manifest, len(fleet.Clients))
+	for i := range fleet.Clients {
+		m := &fleet.Clients[i]
+		m.Version = CurrentVersion
+		if i < len(clientNodes) {
+			m.root = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{clientNodes[i]}}
+		}
+
+		if err := m.Validate(); err != nil {
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				return nil, err
+			}
+			for _, fieldErr := range validationErr.Errors {
+				fieldErr.Field = fmt.Sprintf("clients[%d].%s", i, fieldErr.Field)
+				fieldErrors = append(fieldErrors, fieldErr)
+			}
+		}
+		manifests[i] = m
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, &ValidationError{Errors: fieldErrors}
+	}
+
+	return manifests, nil
+}
+
+func fleetClientNodes(root *yaml.Node) []*yaml.Node {
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+
+	document := root.Content[0]
+	for i := 0; i+1 < len(document.Content); i += 2 {
+		if document.Content[i].Value == "clients" && document.Content[i+1].Kind == yaml.SequenceNode {
+			return document.Content[i+1].Content
+		}
+	}
+
+	return nil
+}
+
+func parseFleetCSV(data []byte) ([]*Manifest, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("fleet file is empty")
+		}
+		return nil, err
+	}
+
+	for i, column := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(column))
+		if _, ok := csvColumns[header[i]]; !ok {
+			return nil, &ValidationError{Errors: []FieldError{{Line: 1, Field: header[i], Message: "unknown column"}}}
+		}
+	}
+
+	var fieldErrors []FieldError
+	var manifests []*Manifest
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		m := &Manifest{Version: CurrentVersion}
+		for i, value := range record {
+			csvColumns[header[i]](m, strings.TrimSpace(value))
+		}
+
+		if err := m.Validate(); err != nil {
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				return nil, err
+			}
+			for _, fieldErr := range validationErr.Errors {
+				fieldErr.Line = line
+				fieldErrors = append(fieldErrors, fieldErr)
+			}
+		}
+		manifests = append(manifests, m)
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, &ValidationError{Errors: fieldErrors}
+	}
+
+	return manifests, nil
+}
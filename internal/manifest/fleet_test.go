@@ -0,0 +1,93 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFleet(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write fleet file: %v", err)
+	}
+	return path
+}
+
+func TestLoadFleet(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      string
+		content   string
+		wantNames []string
+		wantErr   string
+	}{
+		{
+			name: "yaml",
+			file: "fleet.yaml",
+			content: `version: 1
+clients:
+  - client:
+      name: Acme Co
+  - client:
+      name: Globex
+`,
+			wantNames: []string{"Acme Co", "Globex"},
+		},
+		{
+			name:      "csv",
+			file:      "fleet.csv",
+			content:   "name,domain\nAcme Co,acme.example.com\nGlobex,\n",
+			wantNames: []string{"Acme Co", "Globex"},
+		},
+		{
+			name: "same name in another case",
+			file: "fleet.yaml",
+			content: `version: 1
+clients:
+  - client:
+      name: Acme
+  - client:
+      name: ACME
+`,
+			wantErr: `lists clients "Acme" and "ACME", which share the sanitized name acme`,
+		},
+		{
+			name:    "names that sanitize to the same client",
+			file:    "fleet.csv",
+			content: "name\nAcme Co\nGlobex\nacme-co\n",
+			wantErr: `lists clients "Acme Co" and "acme-co", which share the sanitized name acme-co`,
+		},
+		{
+			name:    "no clients",
+			file:    "fleet.yaml",
+			content: "version: 1\nclients: []\n",
+			wantErr: "has no clients",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifests, err := LoadFleet(writeFleet(t, tt.file, tt.content))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadFleet() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadFleet() error = %v", err)
+			}
+
+			names := make([]string, len(manifests))
+			for i, m := range manifests {
+				names[i] = m.Client.Name
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantNames, ",") {
+				t.Errorf("clients = %q, want %q", names, tt.wantNames)
+			}
+		})
+	}
+}
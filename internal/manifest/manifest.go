@@ -0,0 +1,389 @@
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/CaioDGallo/easy-cli/internal/config"
+	"github.com/CaioDGallo/easy-cli/internal/database"
+	"github.com/CaioDGallo/easy-cli/internal/gitprovider"
+	"github.com/CaioDGallo/easy-cli/internal/resources"
+	"github.com/CaioDGallo/easy-cli/internal/types"
+	"gopkg.in/yaml.v3"
+)
+
+const CurrentVersion = 1
+
+var (
+	envKeyRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	portRegex   = regexp.MustCompile(`^\d+$`)
+)
+
+// Manifest is the declarative description of a client consumed by `fresh-install -f`.
+type Manifest struct {
+	Version      int              `yaml:"version"`
+	Client       ClientSpec       `yaml:"client"`
+	SMTP         SMTPSpec         `yaml:"smtp"`
+	Branches     BranchesSpec     `yaml:"branches"`
+	Env          EnvSpec          `yaml:"env"`
+	Providers    ProvidersSpec    `yaml:"providers"`
+	DigitalOcean DigitalOceanSpec `yaml:"digitalocean"`
+	Database     DatabaseSpec     `yaml:"database"`
+	S3           S3Spec           `yaml:"s3"`
+
+	root *yaml.Node
+}
+
+type ClientSpec struct {
+	Name   string `yaml:"name"`
+	Domain string `yaml:"domain"`
+}
+
+type SMTPSpec struct {
+	Server   string `yaml:"server"`
+	Port     string `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	// PasswordEnv names an environment variable holding the password, so it never has to be
+	// written to the manifest or typed on the command line.
+	PasswordEnv     string `yaml:"passwordEnv"`
+	DoNotReplyName  string `yaml:"doNotReplyName"`
+	DoNotReplyEmail string `yaml:"doNotReplyEmail"`
+	DevEmail        string `yaml:"devEmail"`
+}
+
+type BranchesSpec struct {
+	Backend  string `yaml:"backend"`
+	Frontend string `yaml:"frontend"`
+}
+
+type EnvSpec struct {
+	Backend  map[string]string `yaml:"backend"`
+	Frontend map[string]string `yaml:"frontend"`
+}
+
+type ProvidersSpec struct {
+	Repositories RepositoriesSpec `yaml:"repositories"`
+}
+
+type RepositoriesSpec struct {
+	Backend  RepositorySpec `yaml:"backend"`
+	Frontend RepositorySpec `yaml:"frontend"`
+}
+
+// RepositorySpec overrides a configured repository. It can also be written as a plain
+// repository name, which keeps the configured provider.
+type RepositorySpec struct {
+	Provider string `yaml:"provider"`
+	Name     string `yaml:"name"`
+	ID       string `yaml:"id"`
+}
+
+func (r *RepositorySpec) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		r.Name = node.Value
+		return nil
+	}
+
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			switch key := node.Content[i]; key.Value {
+			case "provider", "name", "id":
+			default:
+				return &yaml.TypeError{Errors: []string{fmt.Sprintf("line %d: field %s not found in type manifest.RepositorySpec", key.Line, key.Value)}}
+			}
+		}
+	}
+
+	type plain RepositorySpec
+	return node.Decode((*plain)(r))
+}
+
+func (r RepositorySpec) IsZero() bool {
+	return r.Provider == "" && r.Name == "" && r.ID == ""
+}
+
+// DigitalOceanSpec overrides the App Platform settings of the backend service.
+type DigitalOceanSpec struct {
+	Tier           string `yaml:"tier"`
+	Region         string `yaml:"region"`
+	InstanceSize   string `yaml:"instanceSize"`
+	InstanceCount  int64  `yaml:"instanceCount"`
+	HTTPPort       int64  `yaml:"httpPort"`
+	SourceDir      string `yaml:"sourceDir"`
+	DockerfilePath string `yaml:"dockerfilePath"`
+}
+
+// DatabaseSpec selects the template set the client databases are cloned from, for example the
+// one of the client's product or plan, or a snapshot such as template-v42.
+type DatabaseSpec struct {
+	Template string `yaml:"template"`
+}
+
+// S3Spec places the client bucket in another region than AWS_REGION, for example close to the
+// client's users, and picks the assets it is seeded with, for example those of the client's plan.
+type S3Spec struct {
+	Region string `yaml:"region"`
+	Seed   string `yaml:"seed"`
+}
+
+type FieldError struct {
+	Line    int
+	Field   string
+	Message string
+}
+
+func (e FieldError) String() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: %s: %s", e.Line, e.Field, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+type ValidationError struct {
+	Path   string
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, fieldErr := range e.Errors {
+		messages[i] = fieldErr.String()
+	}
+	return fmt.Sprintf("invalid manifest %s:\n  %s", e.Path, strings.Join(messages, "\n  "))
+}
+
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest %s: %w", path, err)
+	}
+
+	manifest, err := Parse(data)
+	if err != nil {
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			validationErr.Path = path
+			return nil, validationErr
+		}
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+
+	return manifest, nil
+}
+
+func Parse(data []byte) (*Manifest, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+
+	var manifest Manifest
+	if err := decodeStrict(data, &manifest); err != nil {
+		return nil, err
+	}
+	manifest.root = &root
+
+	if err := manifest.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &manifest, nil
+}
+
+// Validate runs the schema checks that YAML decoding alone cannot express. Client fields such as
+// email addresses are validated later by validation.ValidateClient.
+func (m *Manifest) Validate() error {
+	var fieldErrors []FieldError
+	addError := func(message string, path ...string) {
+		fieldErrors = append(fieldErrors, FieldError{
+			Line:    m.line(path...),
+			Field:   strings.Join(path, "."),
+			Message: message,
+		})
+	}
+
+	if m.Version == 0 {
+		addError("is required", "version")
+	} else if m.Version != CurrentVersion {
+		addError(fmt.Sprintf("unsupported version %d (expected %d)", m.Version, CurrentVersion), "version")
+	}
+
+	if strings.TrimSpace(m.Client.Name) == "" {
+		addError("is required", "client", "name")
+	}
+
+	if m.SMTP.Port != "" && !portRegex.MatchString(m.SMTP.Port) {
+		addError("must be a number", "smtp", "port")
+	}
+
+	if m.SMTP.Password != "" && m.SMTP.PasswordEnv != "" {
+		addError("cannot be combined with smtp.password", "smtp", "passwordEnv")
+	} else if m.SMTP.PasswordEnv != "" && os.Getenv(m.SMTP.PasswordEnv) == "" {
+		addError(fmt.Sprintf("environment variable %s is not set", m.SMTP.PasswordEnv), "smtp", "passwordEnv")
+	}
+
+	if tier := m.DigitalOcean.Tier; tier != "" {
+		if _, ok := resources.SizeTiers[tier]; !ok {
+			addError(fmt.Sprintf("unknown tier %q (expected one of %s)", tier, strings.Join(resources.TierNames(), ", ")), "digitalocean", "tier")
+		}
+	}
+
+	if m.DigitalOcean.InstanceCount < 0 {
+		addError("must be at least 1", "digitalocean", "instanceCount")
+	}
+
+	if m.DigitalOcean.HTTPPort < 0 || m.DigitalOcean.HTTPPort > 65535 {
+		addError("must be between 1 and 65535", "digitalocean", "httpPort")
+	}
+
+	if m.DigitalOcean.SourceDir != "" && !strings.HasPrefix(m.DigitalOcean.SourceDir, "/") {
+		addError("must start with /", "digitalocean", "sourceDir")
+	}
+
+	if m.Database.Template != "" {
+		if err := database.ValidateTemplateName(m.Database.Template); err != nil {
+			addError(err.Error(), "database", "template")
+		}
+	}
+
+	if m.S3.Region != "" {
+		if err := config.ValidateRegion(m.S3.Region); err != nil {
+			addError(err.Error(), "s3", "region")
+		}
+	}
+
+	if _, err := resources.ParseSeedSource(m.S3.Seed); err != nil {
+		addError(err.Error(), "s3", "seed")
+	}
+
+	for _, repository := range []struct {
+		name string
+		spec RepositorySpec
+	}{
+		{"backend", m.Providers.Repositories.Backend},
+		{"frontend", m.Providers.Repositories.Frontend},
+	} {
+		if repository.spec.IsZero() {
+			continue
+		}
+		if err := validateRepositorySpec(repository.spec); err != nil {
+			addError(err.Error(), "providers", "repositories", repository.name)
+		}
+	}
+
+	for _, section := range []struct {
+		name string
+		vars map[string]string
+	}{
+		{"backend", m.Env.Backend},
+		{"frontend", m.Env.Frontend},
+	} {
+		for _, key := range sortedKeys(section.vars) {
+			if !envKeyRegex.MatchString(key) {
+				addError("is not a valid environment variable name", "env", section.name, key)
+			}
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return &ValidationError{Errors: fieldErrors}
+	}
+
+	return nil
+}
+
+// validateRepositorySpec checks what the spec sets on its own. The merged repository is
+// validated again once it is applied to the configuration.
+func validateRepositorySpec(spec RepositorySpec) error {
+	provider, err := gitprovider.For(types.Repository{Provider: types.GitProvider(spec.Provider)})
+	if err != nil {
+		return err
+	}
+	if spec.Name == "" {
+		return nil
+	}
+	return provider.ValidateRepository(types.Repository{
+		Provider: provider.Name(),
+		Name:     spec.Name,
+		ID:       spec.ID,
+	})
+}
+
+// SMTPPassword returns the password from the manifest or from the environment variable it names.
+func (m *Manifest) SMTPPassword() string {
+	if m.SMTP.PasswordEnv != "" {
+		return os.Getenv(m.SMTP.PasswordEnv)
+	}
+	return m.SMTP.Password
+}
+
+// line returns the line of the node at the given mapping path, falling back to the closest
+// ancestor that exists. It returns 0 when the manifest was not parsed from YAML.
+func (m *Manifest) line(path ...string) int {
+	if m.root == nil || len(m.root.Content) == 0 {
+		return 0
+	}
+
+	node := m.root.Content[0]
+	line := node.Line
+	for _, key := range path {
+		if node.Kind != yaml.MappingNode {
+			break
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				next = node.Content[i+1]
+				line = node.Content[i].Line
+				break
+			}
+		}
+		if next == nil {
+			break
+		}
+		node = next
+	}
+
+	return line
+}
+
+// decodeStrict decodes YAML while rejecting unknown fields, turning type errors into a
+// ValidationError so they are reported with their line numbers.
+func decodeStrict(data []byte, out interface{}) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(out); err != nil {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("document is empty")
+		}
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			fieldErrors := make([]FieldError, len(typeErr.Errors))
+			for i, message := range typeErr.Errors {
+				fieldErrors[i] = FieldError{Message: message}
+			}
+			return &ValidationError{Errors: fieldErrors}
+		}
+		return err
+	}
+	return nil
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
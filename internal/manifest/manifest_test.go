@@ -0,0 +1,141 @@
+package manifest
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		yaml       string
+		wantErrors []string
+	}{
+		{
+			name: "valid manifest",
+			yaml: `version: 1
+client:
+  name: Acme
+  domain: acme.example.com
+providers:
+  repositories:
+    backend: acme/acme-api
+    frontend:
+      provider: gitlab
+      id: "42"
+env:
+  backend:
+    FEATURE_FLAG: "on"
+`,
+		},
+		{
+			name: "unknown top-level field",
+			yaml: `version: 1
+client:
+  name: Acme
+region: eu-west-1
+`,
+			wantErrors: []string{"line 4: field region not found in type manifest.Manifest"},
+		},
+		{
+			name: "unknown nested field",
+			yaml: `version: 1
+client:
+  name: Acme
+  email: ops@acme.example.com
+`,
+			wantErrors: []string{"line 4: field email not found in type manifest.ClientSpec"},
+		},
+		{
+			name: "unknown repository field",
+			yaml: `version: 1
+client:
+  name: Acme
+providers:
+  repositories:
+    backend:
+      branch: main
+`,
+			wantErrors: []string{"line 7: field branch not found in type manifest.RepositorySpec"},
+		},
+		{
+			name: "schema errors carry the line of their field",
+			yaml: `version: 2
+client:
+  domain: acme.example.com
+smtp:
+  port: smtp
+env:
+  backend:
+    1INVALID: "x"
+`,
+			wantErrors: []string{
+				"line 1: version: unsupported version 2 (expected 1)",
+				"line 2: client.name: is required",
+				"line 5: smtp.port: must be a number",
+				"line 8: env.backend.1INVALID: is not a valid environment variable name",
+			},
+		},
+		{
+			name: "missing field falls back to its closest ancestor",
+			yaml: `client:
+  name: Acme
+`,
+			wantErrors: []string{"line 1: version: is required"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest, err := Parse([]byte(tt.yaml))
+			if len(tt.wantErrors) == 0 {
+				if err != nil {
+					t.Fatalf("Parse() error = %v", err)
+				}
+				if manifest.Client.Name != "Acme" {
+					t.Errorf("client name = %q, want Acme", manifest.Client.Name)
+				}
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Parse() error = %v, want a ValidationError", err)
+			}
+			got := make([]string, len(validationErr.Errors))
+			for i, fieldErr := range validationErr.Errors {
+				got[i] = fieldErr.String()
+			}
+			if !reflect.DeepEqual(got, tt.wantErrors) {
+				t.Errorf("errors = %q, want %q", got, tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestParseRepositoryShorthand(t *testing.T) {
+	manifest, err := Parse([]byte(`version: 1
+client:
+  name: Acme
+providers:
+  repositories:
+    backend: acme/acme-api
+`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := RepositorySpec{Name: "acme/acme-api"}
+	if got := manifest.Providers.Repositories.Backend; got != want {
+		t.Errorf("backend repository = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseEmptyDocument(t *testing.T) {
+	_, err := Parse([]byte(""))
+	if err == nil || !strings.Contains(err.Error(), "empty") {
+		t.Fatalf("Parse() error = %v, want an empty document error", err)
+	}
+}
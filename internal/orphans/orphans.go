@@ -0,0 +1,214 @@
+package orphans
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/CaioDGallo/easy-cli/internal/aws"
+	"github.com/CaioDGallo/easy-cli/internal/config"
+	"github.com/CaioDGallo/easy-cli/internal/database"
+	"github.com/CaioDGallo/easy-cli/internal/digitalocean"
+	"github.com/CaioDGallo/easy-cli/internal/types"
+)
+
+// Report lists the resources easy-cli created that no known client accounts for. A client is
+// known while it has a deployment state.
+type Report struct {
+	Environment string          `json:"environment"`
+	Orphans     []Orphan        `json:"orphans"`
+	Errors      []ProviderError `json:"errors,omitempty"`
+}
+
+type Orphan struct {
+	Provider string `json:"provider"`
+	Resource string `json:"resource"`
+	Name     string `json:"name"`
+	// Client is the sanitized name of the client the resource was created for.
+	Client string `json:"client"`
+	Detail string `json:"detail"`
+}
+
+// ProviderError is a provider that could not be searched.
+type ProviderError struct {
+	Provider string `json:"provider"`
+	Resource string `json:"resource"`
+	Error    string `json:"error"`
+}
+
+// Find searches every provider for resources labeled with the configured environment that are
+// not recorded in the deployment state of their client. Vercel projects are not labeled, so they
+// are not searched. Provider errors are reported instead of aborting the search.
+func Find(ctx context.Context, cfg *config.Config, states []*types.DeploymentState) *Report {
+	known := make(map[string]types.ResourceNames, len(states))
+	for _, deploymentState := range states {
+		known[deploymentState.SanitizedName] = deploymentState.ResourceNames
+	}
+
+	finder := &finder{
+		cfg:    cfg,
+		known:  known,
+		report: &Report{Environment: cfg.Application.Environment},
+	}
+
+	finder.findBuckets(ctx)
+	if cfg.AWS.ClientCredentials {
+		finder.findBucketUsers(ctx)
+	}
+	finder.findDatabaseObjects()
+	finder.findApps(ctx)
+
+	return finder.report
+}
+
+type finder struct {
+	cfg    *config.Config
+	known  map[string]types.ResourceNames
+	report *Report
+}
+
+// checkLabeled records a labeled resource of this environment as an orphan unless the deployment
+// state of its client records it. Resources of other environments are left alone.
+func (f *finder) checkLabeled(provider, resource, name string, labels types.ResourceLabels, recorded func(types.ResourceNames) []string, detail string) {
+	if labels.Environment != f.cfg.Application.Environment {
+		return
+	}
+
+	names, ok := f.known[labels.SanitizedName]
+	reason := "client has no deployment state"
+	if ok {
+		for _, recordedName := range recorded(names) {
+			if recordedName == name {
+				return
+			}
+		}
+		reason = "not recorded in the client's deployment state"
+	}
+
+	details := []string{reason, fmt.Sprintf("created by %s with easy-cli %s", labels.CreatedBy, labels.Version)}
+	if detail != "" {
+		details = append(details, detail)
+	}
+
+	f.report.Orphans = append(f.report.Orphans, Orphan{
+		Provider: provider,
+		Resource: resource,
+		Name:     name,
+		Client:   labels.SanitizedName,
+		Detail:   strings.Join(details, "; "),
+	})
+}
+
+func (f *finder) fail(provider, resource string, err error) {
+	f.report.Errors = append(f.report.Errors, ProviderError{
+		Provider: provider,
+		Resource: resource,
+		Error:    err.Error(),
+	})
+}
+
+func (f *finder) findBuckets(ctx context.Context) {
+	s3Service, err := aws.NewS3Service(f.cfg.AWS.Region, f.cfg.AWS.AccessKeyID, f.cfg.AWS.SecretAccessKey, f.cfg.AWS.S3Endpoint, f.cfg.AWS.S3UsePathStyle)
+	if err != nil {
+		f.fail("aws", "S3 bucket", err)
+		return
+	}
+
+	buckets, err := s3Service.LabeledBuckets(ctx, f.cfg.Application.NamePrefix+"-")
+	if err != nil {
+		f.fail("aws", "S3 bucket", err)
+		return
+	}
+
+	for _, bucket := range buckets {
+		detail := ""
+		if bucket.Region != "" {
+			detail = "region " + bucket.Region
+		}
+		f.checkLabeled("aws", "S3 bucket", bucket.Name, bucket.Labels, func(names types.ResourceNames) []string {
+			return []string{names.S3Bucket}
+		}, detail)
+	}
+}
+
+func (f *finder) findBucketUsers(ctx context.Context) {
+	iamService, err := aws.NewIAMService(f.cfg.AWS.AccessKeyID, f.cfg.AWS.SecretAccessKey, f.cfg.AWS.IAMEndpoint)
+	if err != nil {
+		f.fail("aws", "IAM user", err)
+		return
+	}
+
+	users, err := iamService.LabeledUsers(ctx)
+	if err != nil {
+		f.fail("aws", "IAM user", err)
+		return
+	}
+
+	for _, user := range users {
+		f.checkLabeled("aws", "IAM user", user.Name, user.Labels, func(names types.ResourceNames) []string {
+			return []string{names.IAMUser}
+		}, "")
+	}
+}
+
+func (f *finder) findDatabaseObjects() {
+	objects, err := database.NewPostgresService(f.cfg.Database).LabeledObjects()
+	if err != nil {
+		f.fail("postgres", "Database", err)
+		return
+	}
+
+	for _, object := range objects {
+		resource, recorded := "Database", func(names types.ResourceNames) []string {
+			return []string{names.DatabaseMain, names.DatabaseHangfire}
+		}
+		if object.Kind == "role" {
+			resource, recorded = "Role", func(names types.ResourceNames) []string {
+				return []string{names.DatabaseRole}
+			}
+		}
+		f.checkLabeled("postgres", resource, object.Name, object.Labels, recorded, "")
+	}
+}
+
+func (f *finder) findApps(ctx context.Context) {
+	apps, err := digitalocean.NewAppService(f.cfg.DO.Token).LabeledApps(ctx, f.cfg.Application.NamePrefix+"-")
+	if err != nil {
+		f.fail("digitalocean", "App", err)
+		return
+	}
+
+	for _, app := range apps {
+		f.checkLabeled("digitalocean", "App", app.Name, app.Labels, func(names types.ResourceNames) []string {
+			return []string{names.DOApp}
+		}, "")
+	}
+}
+
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+func (r *Report) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROVIDER\tRESOURCE\tNAME\tCLIENT\tDETAIL")
+	for _, orphan := range r.Orphans {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", orphan.Provider, orphan.Resource, orphan.Name, orphan.Client, orphan.Detail)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(r.Errors) > 0 {
+		fmt.Fprintln(w)
+	}
+	for _, providerErr := range r.Errors {
+		fmt.Fprintf(w, "Failed to search %s %ss: %s\n", providerErr.Provider, providerErr.Resource, providerErr.Error)
+	}
+	return nil
+}
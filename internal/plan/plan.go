@@ -0,0 +1,388 @@
+package plan
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/CaioDGallo/easy-cli/internal/aws"
+	"github.com/CaioDGallo/easy-cli/internal/config"
+	"github.com/CaioDGallo/easy-cli/internal/database"
+	"github.com/CaioDGallo/easy-cli/internal/digitalocean"
+	"github.com/CaioDGallo/easy-cli/internal/dns"
+	"github.com/CaioDGallo/easy-cli/internal/envvars"
+	"github.com/CaioDGallo/easy-cli/internal/resources"
+	"github.com/CaioDGallo/easy-cli/internal/state"
+	"github.com/CaioDGallo/easy-cli/internal/types"
+	"github.com/CaioDGallo/easy-cli/internal/vercel"
+	"github.com/digitalocean/godo"
+)
+
+type CheckStatus string
+
+const (
+	CheckStatusOK       CheckStatus = "ok"
+	CheckStatusConflict CheckStatus = "conflict"
+	CheckStatusError    CheckStatus = "error"
+)
+
+// Plan describes everything fresh-install would create for a client. Secret values are masked.
+type Plan struct {
+	ClientName    string              `json:"clientName"`
+	SanitizedName string              `json:"sanitizedName"`
+	ResourceNames types.ResourceNames `json:"resourceNames"`
+	// DatabaseTemplate is the template set the client databases are cloned from.
+	DatabaseTemplate string `json:"databaseTemplate"`
+	// S3Region is the region the client bucket is created in.
+	S3Region string              `json:"s3Region"`
+	Bucket   types.BucketOptions `json:"bucket"`
+	// Seed is what the bucket is filled with once created, if anything.
+	Seed          *types.SeedSource              `json:"seed,omitempty"`
+	BackendEnv    BackendEnv                     `json:"backendEnv"`
+	VercelEnv     []types.VercelEnvVariable      `json:"vercelEnv"`
+	DOAppSpec     *godo.AppSpec                  `json:"doAppSpec"`
+	VercelProject *types.CreateVercelProjectBody `json:"vercelProject"`
+	Domains       *types.CustomDomains           `json:"domains,omitempty"`
+	DNSProvider   string                         `json:"dnsProvider,omitempty"`
+	Labels        types.ResourceLabels           `json:"labels"`
+	Preflight     []Check                        `json:"preflight"`
+}
+
+type BackendEnv struct {
+	AppLevel       []godo.AppVariableDefinition `json:"appLevel"`
+	ComponentLevel []godo.AppVariableDefinition `json:"componentLevel"`
+}
+
+type Check struct {
+	Provider string      `json:"provider"`
+	Resource string      `json:"resource"`
+	Name     string      `json:"name"`
+	Status   CheckStatus `json:"status"`
+	Detail   string      `json:"detail,omitempty"`
+}
+
+func Build(client types.Client, cfg *config.Config) (*Plan, error) {
+	labels := resources.LabelsFor(client, cfg)
+	client.Labels = labels
+
+	deploymentEnv, err := envvars.GenerateDeploymentEnvironment(client, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate deployment environment: %w", err)
+	}
+
+	vercelEnvVars, err := envvars.GenerateVercelEnvironmentVariables(client, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate Vercel environment variables: %w", err)
+	}
+
+	appEnvs := envvars.RedactAppVariables(deploymentEnv.Backend.AppLevelVars)
+	componentEnvs := envvars.RedactAppVariables(deploymentEnv.Backend.ComponentLevelVars)
+	redactedVercelEnvVars := envvars.RedactVercelVariables(vercelEnvVars)
+
+	vercelProject, err := vercel.BuildProjectBody(client.SanitizedClientName, redactedVercelEnvVars, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build Vercel project: %w", err)
+	}
+
+	doAppSpec, err := digitalocean.BuildAppSpec(client, types.DigitalOceanEnvVars{
+		AppEnvs:       appEnvs,
+		ComponentEnvs: componentEnvs,
+	}, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build DigitalOcean app spec: %w", err)
+	}
+
+	seedSource, err := resources.ParseSeedSource(client.S3Seed)
+	if err != nil {
+		return nil, err
+	}
+	var seed *types.SeedSource
+	if !seedSource.IsZero() {
+		seed = &seedSource
+	}
+
+	var domains *types.CustomDomains
+	if client.Domain != "" {
+		customDomains := resources.CustomDomainsFor(client.Domain)
+		domains = &customDomains
+	}
+
+	return &Plan{
+		ClientName:       client.Name,
+		SanitizedName:    client.SanitizedClientName,
+		ResourceNames:    deploymentEnv.ResourceNames,
+		DatabaseTemplate: client.DatabaseTemplate,
+		S3Region:         client.S3Region,
+		Bucket:           resources.BucketOptionsFor(cfg, deploymentEnv.ResourceNames, client.Domain, labels),
+		Seed:             seed,
+		BackendEnv: BackendEnv{
+			AppLevel:       sortedVariables(appEnvs),
+			ComponentLevel: sortedVariables(componentEnvs),
+		},
+		VercelEnv:     redactedVercelEnvVars,
+		DOAppSpec:     doAppSpec,
+		VercelProject: vercelProject,
+		Domains:       domains,
+		DNSProvider:   cfg.DNS.Provider,
+		Labels:        labels,
+	}, nil
+}
+
+// RunPreflight performs read-only checks for name conflicts on every provider and stores the
+// results in the plan.
+func (p *Plan) RunPreflight(ctx context.Context, cfg *config.Config, store *state.Store) {
+	names := p.ResourceNames
+	p.Preflight = nil
+
+	_, err := store.Load(p.SanitizedName)
+	switch {
+	case err == nil:
+		p.addCheck("easy-cli", "Deployment state", p.SanitizedName, true, nil)
+	case errors.Is(err, state.ErrNotFound):
+		p.addCheck("easy-cli", "Deployment state", p.SanitizedName, false, nil)
+	default:
+		p.addCheck("easy-cli", "Deployment state", p.SanitizedName, false, err)
+	}
+
+	s3Service, err := aws.NewS3Service(p.S3Region, cfg.AWS.AccessKeyID, cfg.AWS.SecretAccessKey, cfg.AWS.S3Endpoint, cfg.AWS.S3UsePathStyle)
+	if err != nil {
+		p.addCheck("aws", "S3 bucket", names.S3Bucket, false, err)
+	} else {
+		availability, region, err := s3Service.BucketAvailability(ctx, names.S3Bucket)
+		check := p.addCheck("aws", "S3 bucket", names.S3Bucket, err == nil && availability != aws.BucketAvailable, err)
+		switch availability {
+		case aws.BucketTaken:
+			check.Detail = "name is taken by another AWS account"
+		case aws.BucketOtherRegion:
+			check.Detail = fmt.Sprintf("already exists in region %s", region)
+		}
+
+		if p.Seed != nil {
+			exists, err := s3Service.SeedSourceExists(ctx, *p.Seed)
+			if err == nil && !exists {
+				err = fmt.Errorf("seed source does not exist")
+			}
+			p.addCheck("aws", "S3 seed", p.Seed.String(), false, err)
+		}
+	}
+
+	if names.IAMUser != "" {
+		iamService, err := aws.NewIAMService(cfg.AWS.AccessKeyID, cfg.AWS.SecretAccessKey, cfg.AWS.IAMEndpoint)
+		exists := false
+		if err == nil {
+			exists, err = iamService.UserExists(ctx, names.IAMUser)
+		}
+		p.addCheck("aws", "IAM user", names.IAMUser, exists, err)
+	}
+
+	dbService := database.NewPostgresService(cfg.Database)
+	for _, dbName := range []string{names.DatabaseMain, names.DatabaseHangfire} {
+		exists, err := dbService.DatabaseExists(dbName)
+		p.addCheck("postgres", "Database", dbName, exists, err)
+	}
+	roleExists, err := dbService.RoleExists(names.DatabaseRole)
+	p.addCheck("postgres", "Role", names.DatabaseRole, roleExists, err)
+	templateExists, err := dbService.TemplateExists(p.DatabaseTemplate)
+	if err == nil && !templateExists {
+		templateMain, templateHangfire := database.TemplateDatabases(p.DatabaseTemplate)
+		err = fmt.Errorf("template databases %s and %s must both exist", templateMain, templateHangfire)
+	}
+	p.addCheck("postgres", "Template", p.DatabaseTemplate, false, err)
+
+	doService := digitalocean.NewAppService(cfg.DO.Token)
+	app, err := doService.FindAppByName(ctx, names.DOApp)
+	p.addCheck("digitalocean", "App", names.DOApp, app != nil, err)
+
+	service := p.DOAppSpec.Services[0]
+	appSpec := types.AppSpecOptions{
+		Region:           p.DOAppSpec.Region,
+		InstanceSizeSlug: service.InstanceSizeSlug,
+		InstanceCount:    service.InstanceCount,
+	}
+	appSpecName := fmt.Sprintf("%s %s x%d", appSpec.Region, appSpec.InstanceSizeSlug, appSpec.InstanceCount)
+	p.addCheck("digitalocean", "App spec", appSpecName, false, doService.ValidateAppSpec(ctx, appSpec))
+
+	vercelService := vercel.NewProjectService(cfg.Vercel)
+	exists, err := vercelService.ProjectExists(ctx, names.VercelProject)
+	p.addCheck("vercel", "Project", names.VercelProject, exists, err)
+
+	if p.Domains != nil && p.DNSProvider != "" {
+		dnsProvider, err := dns.NewProvider(cfg)
+		if err == nil {
+			_, err = dnsProvider.ZoneFor(ctx, p.Domains.Frontend)
+		}
+		p.addCheck(p.DNSProvider, "DNS zone", p.Domains.Frontend, false, err)
+	}
+}
+
+func (p *Plan) HasConflicts() bool {
+	for _, check := range p.Preflight {
+		if check.Status != CheckStatusOK {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Plan) addCheck(provider, resource, name string, exists bool, err error) *Check {
+	check := Check{
+		Provider: provider,
+		Resource: resource,
+		Name:     name,
+		Status:   CheckStatusOK,
+	}
+
+	switch {
+	case err != nil:
+		check.Status = CheckStatusError
+		check.Detail = err.Error()
+	case exists:
+		check.Status = CheckStatusConflict
+		check.Detail = "already exists"
+	}
+
+	p.Preflight = append(p.Preflight, check)
+	return &p.Preflight[len(p.Preflight)-1]
+}
+
+func lifecycleSummary(options types.BucketOptions) string {
+	var rules []string
+	if options.TempExpirationDays > 0 {
+		rules = append(rules, fmt.Sprintf("%s expires after %dd", options.TempPrefix, options.TempExpirationDays))
+	}
+	if options.Versioning && options.NoncurrentExpirationDays > 0 {
+		rules = append(rules, fmt.Sprintf("noncurrent versions expire after %dd", options.NoncurrentExpirationDays))
+	}
+	if options.AbortIncompleteUploadDays > 0 {
+		rules = append(rules, fmt.Sprintf("incomplete uploads aborted after %dd", options.AbortIncompleteUploadDays))
+	}
+	if len(rules) == 0 {
+		return "none"
+	}
+	return strings.Join(rules, ", ")
+}
+
+func (p *Plan) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(p)
+}
+
+func (p *Plan) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Plan for client %q (%s)\n\n", p.ClientName, p.SanitizedName)
+
+	fmt.Fprintln(tw, "Resources:")
+	fmt.Fprintf(tw, "  S3 bucket\t%s\n", p.ResourceNames.S3Bucket)
+	fmt.Fprintf(tw, "  S3 region\t%s\n", p.S3Region)
+	fmt.Fprintf(tw, "  S3 URL\t%s\n", p.ResourceNames.S3URL)
+	if p.ResourceNames.IAMUser != "" {
+		fmt.Fprintf(tw, "  IAM user\t%s\n", p.ResourceNames.IAMUser)
+	}
+	fmt.Fprintf(tw, "  Main database\t%s\n", p.ResourceNames.DatabaseMain)
+	fmt.Fprintf(tw, "  Hangfire database\t%s\n", p.ResourceNames.DatabaseHangfire)
+	fmt.Fprintf(tw, "  Database role\t%s\n", p.ResourceNames.DatabaseRole)
+	fmt.Fprintf(tw, "  Database template\t%s\n", p.DatabaseTemplate)
+	fmt.Fprintf(tw, "  DigitalOcean app\t%s\n", p.ResourceNames.DOApp)
+	fmt.Fprintf(tw, "  Vercel project\t%s\n", p.ResourceNames.VercelProject)
+	fmt.Fprintf(tw, "  Frontend URL\t%s\n", p.ResourceNames.FrontendURL)
+
+	fmt.Fprintln(tw, "\nLabels:")
+	fmt.Fprintf(tw, "  Client\t%s\n", p.Labels.Client)
+	fmt.Fprintf(tw, "  Sanitized name\t%s\n", p.Labels.SanitizedName)
+	fmt.Fprintf(tw, "  Environment\t%s\n", p.Labels.Environment)
+	fmt.Fprintf(tw, "  Created by\t%s\n", p.Labels.CreatedBy)
+	fmt.Fprintf(tw, "  Version\t%s\n", p.Labels.Version)
+
+	fmt.Fprintln(tw, "\nS3 bucket settings:")
+	fmt.Fprintf(tw, "  CORS origins\t%s\n", strings.Join(p.Bucket.CORS.AllowedOrigins, ", "))
+	fmt.Fprintf(tw, "  CORS methods\t%s\n", strings.Join(p.Bucket.CORS.AllowedMethods, ", "))
+	fmt.Fprintf(tw, "  Versioning\t%t\n", p.Bucket.Versioning)
+	fmt.Fprintf(tw, "  Lifecycle\t%s\n", lifecycleSummary(p.Bucket))
+	if p.Seed != nil {
+		fmt.Fprintf(tw, "  Seeded from\t%s\n", p.Seed)
+	}
+
+	fmt.Fprintln(tw, "\nDigitalOcean app spec:")
+	fmt.Fprintf(tw, "  Region\t%s\n", p.DOAppSpec.Region)
+	for _, service := range p.DOAppSpec.Services {
+		fmt.Fprintf(tw, "  Service\t%s\n", service.Name)
+		fmt.Fprintf(tw, "    Instance size\t%s\n", service.InstanceSizeSlug)
+		fmt.Fprintf(tw, "    Instance count\t%d\n", service.InstanceCount)
+		fmt.Fprintf(tw, "    HTTP port\t%d\n", service.HTTPPort)
+		fmt.Fprintf(tw, "    Source dir\t%s\n", service.SourceDir)
+		fmt.Fprintf(tw, "    Dockerfile\t%s\n", service.DockerfilePath)
+		switch {
+		case service.Bitbucket != nil:
+			fmt.Fprintf(tw, "    Repository\t%s (bitbucket)\n", service.Bitbucket.Repo)
+			fmt.Fprintf(tw, "    Branch\t%s\n", service.Bitbucket.Branch)
+		case service.GitHub != nil:
+			fmt.Fprintf(tw, "    Repository\t%s (github)\n", service.GitHub.Repo)
+			fmt.Fprintf(tw, "    Branch\t%s\n", service.GitHub.Branch)
+		case service.GitLab != nil:
+			fmt.Fprintf(tw, "    Repository\t%s (gitlab)\n", service.GitLab.Repo)
+			fmt.Fprintf(tw, "    Branch\t%s\n", service.GitLab.Branch)
+		}
+	}
+
+	fmt.Fprintln(tw, "\nBackend app-level environment:")
+	for _, envVar := range p.BackendEnv.AppLevel {
+		fmt.Fprintf(tw, "  %s\t%s\n", envVar.Key, envVar.Value)
+	}
+
+	fmt.Fprintln(tw, "\nBackend component-level environment:")
+	for _, envVar := range p.BackendEnv.ComponentLevel {
+		fmt.Fprintf(tw, "  %s\t%s\n", envVar.Key, envVar.Value)
+	}
+
+	fmt.Fprintln(tw, "\nVercel project:")
+	fmt.Fprintf(tw, "  Name\t%s\n", p.VercelProject.Name)
+	fmt.Fprintf(tw, "  Framework\t%s\n", p.VercelProject.FrameworkPreset)
+	fmt.Fprintf(tw, "  Build command\t%s\n", p.VercelProject.BuildCommand)
+	fmt.Fprintf(tw, "  Install command\t%s\n", p.VercelProject.InstallCommand)
+	fmt.Fprintf(tw, "  Repository\t%s (%s)\n", p.VercelProject.GitRepository.Repo, p.VercelProject.GitRepository.Type)
+
+	fmt.Fprintln(tw, "\nVercel environment:")
+	for _, envVar := range p.VercelEnv {
+		fmt.Fprintf(tw, "  %s\t%s\t(%s, %s)\n", envVar.Key, envVar.Value, envVar.Type, envVar.Target)
+	}
+
+	if p.Domains != nil {
+		if p.DNSProvider != "" {
+			fmt.Fprintf(tw, "\nCustom domains (DNS records published with %s):\n", p.DNSProvider)
+		} else {
+			fmt.Fprintln(tw, "\nCustom domains (DNS records to create):")
+		}
+		fmt.Fprintf(tw, "  %s\t%s %s\t(Vercel)\n", p.Domains.Frontend, types.DNSRecordTypeA, vercel.ApexARecord)
+		fmt.Fprintf(tw, "  %s\t%s %s\t(Vercel, redirects to %s)\n", p.Domains.FrontendWWW, types.DNSRecordTypeCNAME, vercel.CNAMERecord, p.Domains.Frontend)
+		fmt.Fprintf(tw, "  %s\t%s <app default ingress>\t(DigitalOcean, known once the app is deployed)\n", p.Domains.Backend, types.DNSRecordTypeCNAME)
+	}
+
+	if len(p.Preflight) > 0 {
+		fmt.Fprintln(tw, "\nPreflight checks:")
+		for _, check := range p.Preflight {
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\n", check.Provider, check.Resource, check.Name, check.Status, check.Detail)
+		}
+	}
+
+	return tw.Flush()
+}
+
+func sortedVariables(envs map[string]godo.AppVariableDefinition) []godo.AppVariableDefinition {
+	variables := make([]godo.AppVariableDefinition, 0, len(envs))
+	for _, envVar := range envs {
+		variables = append(variables, envVar)
+	}
+
+	sort.Slice(variables, func(i, j int) bool {
+		return variables[i].Key < variables[j].Key
+	})
+
+	return variables
+}
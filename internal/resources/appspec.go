@@ -0,0 +1,97 @@
+package resources
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/CaioDGallo/easy-cli/internal/config"
+	"github.com/CaioDGallo/easy-cli/internal/types"
+)
+
+var SizeTiers = map[string]types.AppSizeTier{
+	"small":  {InstanceSizeSlug: "basic-xxs", InstanceCount: 1},
+	"medium": {InstanceSizeSlug: "basic-s", InstanceCount: 1},
+	"large":  {InstanceSizeSlug: "professional-xs", InstanceCount: 2},
+}
+
+var defaultAppSpec = types.AppSpecOptions{
+	Region:           "sfo",
+	InstanceSizeSlug: "basic-xxs",
+	InstanceCount:    1,
+	HTTPPort:         80,
+	SourceDir:        "/",
+	DockerfilePath:   "Dockerfile",
+}
+
+// ResolveAppSpec layers the client's settings over the global configuration and the built-in
+// defaults. Within a layer, an explicit instance size or count takes precedence over its tier.
+func ResolveAppSpec(cfg *config.Config, clientOptions types.AppSpecOptions) (types.AppSpecOptions, error) {
+	resolved := defaultAppSpec
+
+	for _, layer := range []struct {
+		name    string
+		options types.AppSpecOptions
+	}{
+		{"configuration", cfg.DO.App},
+		{"client", clientOptions},
+	} {
+		if err := applyAppSpecLayer(&resolved, layer.options); err != nil {
+			return types.AppSpecOptions{}, fmt.Errorf("invalid %s app settings: %w", layer.name, err)
+		}
+	}
+
+	if resolved.InstanceCount < 1 {
+		return types.AppSpecOptions{}, fmt.Errorf("instance count must be at least 1")
+	}
+	if resolved.HTTPPort < 1 || resolved.HTTPPort > 65535 {
+		return types.AppSpecOptions{}, fmt.Errorf("HTTP port must be between 1 and 65535")
+	}
+	if !strings.HasPrefix(resolved.SourceDir, "/") {
+		return types.AppSpecOptions{}, fmt.Errorf("source directory must start with /")
+	}
+
+	return resolved, nil
+}
+
+func applyAppSpecLayer(resolved *types.AppSpecOptions, layer types.AppSpecOptions) error {
+	if layer.Tier != "" {
+		tier, ok := SizeTiers[layer.Tier]
+		if !ok {
+			return fmt.Errorf("unknown tier %q (expected one of %s)", layer.Tier, strings.Join(TierNames(), ", "))
+		}
+		resolved.Tier = layer.Tier
+		resolved.InstanceSizeSlug = tier.InstanceSizeSlug
+		resolved.InstanceCount = tier.InstanceCount
+	}
+
+	if layer.Region != "" {
+		resolved.Region = layer.Region
+	}
+	if layer.InstanceSizeSlug != "" {
+		resolved.InstanceSizeSlug = layer.InstanceSizeSlug
+	}
+	if layer.InstanceCount != 0 {
+		resolved.InstanceCount = layer.InstanceCount
+	}
+	if layer.HTTPPort != 0 {
+		resolved.HTTPPort = layer.HTTPPort
+	}
+	if layer.SourceDir != "" {
+		resolved.SourceDir = layer.SourceDir
+	}
+	if layer.DockerfilePath != "" {
+		resolved.DockerfilePath = layer.DockerfilePath
+	}
+
+	return nil
+}
+
+func TierNames() []string {
+	names := make([]string, 0, len(SizeTiers))
+	for name := range SizeTiers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,11 @@
+package resources
+
+import "github.com/CaioDGallo/easy-cli/internal/types"
+
+func CustomDomainsFor(domain string) types.CustomDomains {
+	return types.CustomDomains{
+		Frontend:    domain,
+		FrontendWWW: "www." + domain,
+		Backend:     "api." + domain,
+	}
+}
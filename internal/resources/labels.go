@@ -0,0 +1,32 @@
+package resources
+
+import (
+	"os"
+	"os/user"
+
+	"github.com/CaioDGallo/easy-cli/internal/config"
+	"github.com/CaioDGallo/easy-cli/internal/types"
+	"github.com/CaioDGallo/easy-cli/internal/version"
+)
+
+// LabelsFor returns the labels of a new client's resources, created by the current user with
+// this version of easy-cli.
+func LabelsFor(client types.Client, cfg *config.Config) types.ResourceLabels {
+	return types.ResourceLabels{
+		Client:        client.Name,
+		SanitizedName: client.SanitizedClientName,
+		Environment:   cfg.Application.Environment,
+		CreatedBy:     currentUser(),
+		Version:       version.String(),
+	}
+}
+
+func currentUser() string {
+	if current, err := user.Current(); err == nil && current.Username != "" {
+		return current.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
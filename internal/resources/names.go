@@ -8,15 +8,28 @@ import (
 	"github.com/CaioDGallo/easy-cli/internal/types"
 )
 
-func GenerateResourceNames(sanitizedClientName string, cfg *config.Config) types.ResourceNames {
+// GenerateResourceNames derives the names of a client's resources. The S3 URL points at the
+// bucket in s3Region, which defaults to AWS_REGION when empty.
+func GenerateResourceNames(sanitizedClientName, s3Region string, cfg *config.Config) types.ResourceNames {
+	s3Bucket := generateS3BucketName(sanitizedClientName, cfg)
+
+	// The user is named after the bucket it is limited to.
+	iamUser := ""
+	if cfg.AWS.ClientCredentials {
+		iamUser = s3Bucket
+	}
+
 	return types.ResourceNames{
-		S3Bucket:         generateS3BucketName(sanitizedClientName, cfg),
+		S3Bucket:         s3Bucket,
+		S3URL:            cfg.AWS.BucketURL(s3Bucket, s3Region),
 		DatabaseMain:     sanitizedClientName,
 		DatabaseHangfire: fmt.Sprintf("%s-hf", sanitizedClientName),
+		DatabaseRole:     fmt.Sprintf("%s-app", sanitizedClientName),
 		DOApp:            generateDOAppName(sanitizedClientName, cfg),
 		VercelProject:    sanitizedClientName,
 		FrontendURL:      generateFrontendURL(sanitizedClientName, cfg),
 		BackendURL:       "",
+		IAMUser:          iamUser,
 	}
 }
 
@@ -45,6 +58,10 @@ func ValidateResourceNames(names types.ResourceNames) error {
 		return fmt.Errorf("invalid hangfire database name: %w", err)
 	}
 
+	if err := validateRoleName(names.DatabaseRole); err != nil {
+		return fmt.Errorf("invalid database role name: %w", err)
+	}
+
 	return nil
 }
 
@@ -79,3 +96,11 @@ func validateDatabaseName(dbName string) error {
 
 	return nil
 }
+
+func validateRoleName(roleName string) error {
+	if len(roleName) > 63 {
+		return fmt.Errorf("role name cannot exceed 63 characters")
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package resources
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/CaioDGallo/easy-cli/internal/config"
+	"github.com/CaioDGallo/easy-cli/internal/types"
+)
+
+// BucketOptionsFor returns the configured bucket settings with the client's frontend origins
+// allowed by CORS, ahead of the origins allowed for every client, and the client's labels as
+// tags.
+func BucketOptionsFor(cfg *config.Config, names types.ResourceNames, domain string, labels types.ResourceLabels) types.BucketOptions {
+	options := cfg.AWS.Bucket
+	if !labels.IsZero() {
+		options.Tags = labels.Map()
+	}
+
+	origins := []string{strings.TrimSuffix(names.FrontendURL, "/")}
+	if domain != "" {
+		domains := CustomDomainsFor(domain)
+		origins = append(origins, "https://"+domains.Frontend, "https://"+domains.FrontendWWW)
+	}
+	for _, origin := range cfg.AWS.Bucket.CORS.AllowedOrigins {
+		if !slices.Contains(origins, origin) {
+			origins = append(origins, origin)
+		}
+	}
+	options.CORS.AllowedOrigins = origins
+
+	return options
+}
+
+// SeedNone turns seeding off for a client when S3_SEED_SOURCE sets a default.
+const SeedNone = "none"
+
+// ParseSeedSource reads a seed source given as a local directory or as s3://bucket/prefix. An
+// empty value or SeedNone disables seeding.
+func ParseSeedSource(value string) (types.SeedSource, error) {
+	if value == "" || value == SeedNone {
+		return types.SeedSource{}, nil
+	}
+
+	location, isS3 := strings.CutPrefix(value, "s3://")
+	if !isS3 {
+		return types.SeedSource{Dir: value}, nil
+	}
+
+	bucket, prefix, _ := strings.Cut(location, "/")
+	if err := validateS3BucketName(bucket); err != nil {
+		return types.SeedSource{}, fmt.Errorf("invalid seed bucket %q: %w", bucket, err)
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	return types.SeedSource{Bucket: bucket, Prefix: prefix}, nil
+}
@@ -0,0 +1,43 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/CaioDGallo/easy-cli/internal/types"
+)
+
+func TestParseSeedSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    types.SeedSource
+		wantErr bool
+	}{
+		{name: "empty", value: ""},
+		{name: "none", value: SeedNone},
+		{name: "directory", value: "./assets/pro", want: types.SeedSource{Dir: "./assets/pro"}},
+		{name: "bucket", value: "s3://easy-assets", want: types.SeedSource{Bucket: "easy-assets"}},
+		{name: "bucket with prefix", value: "s3://easy-assets/plans/pro", want: types.SeedSource{Bucket: "easy-assets", Prefix: "plans/pro/"}},
+		{name: "bucket with trailing slash", value: "s3://easy-assets/plans/pro/", want: types.SeedSource{Bucket: "easy-assets", Prefix: "plans/pro/"}},
+		{name: "uppercase bucket", value: "s3://Easy-Assets/pro", wantErr: true},
+		{name: "missing bucket", value: "s3://", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSeedSource(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseSeedSource(%q) = %+v, want an error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSeedSource(%q) error = %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSeedSource(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
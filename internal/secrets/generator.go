@@ -0,0 +1,79 @@
+package secrets
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/CaioDGallo/easy-cli/internal/types"
+)
+
+const (
+	alphabet                = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+	jwtKeyLength            = 64
+	revalidationTokenLength = 32
+	databasePasswordLength  = 32
+)
+
+func Generate() (types.ClientSecrets, error) {
+	jwtKey, err := GenerateJWTKey()
+	if err != nil {
+		return types.ClientSecrets{}, err
+	}
+
+	revalidationToken, err := GenerateRevalidationToken()
+	if err != nil {
+		return types.ClientSecrets{}, err
+	}
+
+	databasePassword, err := GenerateDatabasePassword()
+	if err != nil {
+		return types.ClientSecrets{}, err
+	}
+
+	return types.ClientSecrets{
+		JWTKey:            jwtKey,
+		RevalidationToken: revalidationToken,
+		DatabasePassword:  databasePassword,
+	}, nil
+}
+
+func GenerateJWTKey() (string, error) {
+	key, err := RandomString(jwtKeyLength)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate JWT key: %w", err)
+	}
+	return key, nil
+}
+
+func GenerateRevalidationToken() (string, error) {
+	token, err := RandomString(revalidationTokenLength)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate revalidation token: %w", err)
+	}
+	return token, nil
+}
+
+func GenerateDatabasePassword() (string, error) {
+	password, err := RandomString(databasePasswordLength)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate database password: %w", err)
+	}
+	return password, nil
+}
+
+// RandomString returns a cryptographically random alphanumeric string of the given length.
+func RandomString(length int) (string, error) {
+	max := big.NewInt(int64(len(alphabet)))
+	result := make([]byte, length)
+
+	for i := range result {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		result[i] = alphabet[n.Int64()]
+	}
+
+	return string(result), nil
+}
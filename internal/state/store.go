@@ -0,0 +1,194 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CaioDGallo/easy-cli/internal/logger"
+	"github.com/CaioDGallo/easy-cli/internal/types"
+	"github.com/sirupsen/logrus"
+)
+
+var ErrNotFound = errors.New("deployment state not found")
+
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func NewStore(dir string) *Store {
+	return &Store{
+		dir: dir,
+	}
+}
+
+func New(client types.Client, resourceNames types.ResourceNames) *types.DeploymentState {
+	now := time.Now().UTC()
+	return &types.DeploymentState{
+		Version:          types.DeploymentStateVersion,
+		ClientName:       client.Name,
+		SanitizedName:    client.SanitizedClientName,
+		ResourceNames:    resourceNames,
+		BackendBranch:    client.BackendBranch,
+		FrontendBranch:   client.FrontendBranch,
+		Domain:           client.Domain,
+		DatabaseTemplate: client.DatabaseTemplate,
+		S3Region:         client.S3Region,
+		S3Seed:           client.S3Seed,
+		Steps:            make(map[types.StepName]types.StepState),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+}
+
+func (s *Store) Load(sanitizedClientName string) (*types.DeploymentState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.path(sanitizedClientName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, sanitizedClientName)
+		}
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var deploymentState types.DeploymentState
+	if err := json.Unmarshal(data, &deploymentState); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal state file: %w", err)
+	}
+
+	if deploymentState.Steps == nil {
+		deploymentState.Steps = make(map[types.StepName]types.StepState)
+	}
+
+	return &deploymentState, nil
+}
+
+func (s *Store) Save(deploymentState *types.DeploymentState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if deploymentState.SanitizedName == "" {
+		return fmt.Errorf("deployment state has no sanitized client name")
+	}
+
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create state directory %s: %w", s.dir, err)
+	}
+
+	deploymentState.UpdatedAt = time.Now().UTC()
+
+	data, err := json.MarshalIndent(deploymentState, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal deployment state: %w", err)
+	}
+
+	// Write to a temporary file first so an interrupted run never leaves a truncated state file behind.
+	tmpFile, err := os.CreateTemp(s.dir, deploymentState.SanitizedName+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary state file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to close state file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, s.path(deploymentState.SanitizedName)); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to move state file into place: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) Delete(sanitizedClientName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(s.path(sanitizedClientName)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete state file: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) List() ([]*types.DeploymentState, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read state directory: %w", err)
+	}
+
+	var states []*types.DeploymentState
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		deploymentState, err := s.Load(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			logger.WithFields(logrus.Fields{
+				"component": "state",
+				"file":      entry.Name(),
+			}).WithError(err).Warn("Skipping unreadable state file")
+			continue
+		}
+		states = append(states, deploymentState)
+	}
+
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].SanitizedName < states[j].SanitizedName
+	})
+
+	return states, nil
+}
+
+// UpdateStep records the new status of a step and persists the state immediately.
+func (s *Store) UpdateStep(deploymentState *types.DeploymentState, step types.StepName, status types.StepStatus, stepErr error) error {
+	now := time.Now().UTC()
+	stepState := deploymentState.Steps[step]
+	stepState.Status = status
+	stepState.Error = ""
+
+	switch status {
+	case types.StepStatusInProgress:
+		stepState.StartedAt = now
+		stepState.CompletedAt = time.Time{}
+	case types.StepStatusCompleted:
+		stepState.CompletedAt = now
+	}
+
+	if stepErr != nil {
+		stepState.Error = stepErr.Error()
+	}
+
+	deploymentState.Steps[step] = stepState
+
+	return s.Save(deploymentState)
+}
+
+func IsStepCompleted(deploymentState *types.DeploymentState, step types.StepName) bool {
+	return deploymentState.Steps[step].Status == types.StepStatusCompleted
+}
+
+func (s *Store) path(sanitizedClientName string) string {
+	return filepath.Join(s.dir, sanitizedClientName+".json")
+}
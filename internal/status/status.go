@@ -0,0 +1,349 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/CaioDGallo/easy-cli/internal/aws"
+	"github.com/CaioDGallo/easy-cli/internal/config"
+	"github.com/CaioDGallo/easy-cli/internal/database"
+	"github.com/CaioDGallo/easy-cli/internal/digitalocean"
+	"github.com/CaioDGallo/easy-cli/internal/resources"
+	"github.com/CaioDGallo/easy-cli/internal/types"
+	"github.com/CaioDGallo/easy-cli/internal/vercel"
+	"github.com/digitalocean/godo"
+)
+
+type Health string
+
+const (
+	HealthHealthy  Health = "healthy"
+	HealthDegraded Health = "degraded"
+	HealthMissing  Health = "missing"
+	HealthError    Health = "error"
+)
+
+// Report is the live health of every resource that belongs to a client.
+type Report struct {
+	ClientName    string           `json:"clientName"`
+	SanitizedName string           `json:"sanitizedName"`
+	Resources     []ResourceHealth `json:"resources"`
+}
+
+type ResourceHealth struct {
+	Provider string            `json:"provider"`
+	Resource string            `json:"resource"`
+	Name     string            `json:"name"`
+	Status   Health            `json:"status"`
+	Details  map[string]string `json:"details,omitempty"`
+	Problems []string          `json:"problems,omitempty"`
+}
+
+func (r *ResourceHealth) problem(format string, args ...interface{}) {
+	r.Problems = append(r.Problems, fmt.Sprintf(format, args...))
+	if r.Status == HealthHealthy {
+		r.Status = HealthDegraded
+	}
+}
+
+func (r *ResourceHealth) fail(err error) {
+	r.Status = HealthError
+	r.Problems = append(r.Problems, err.Error())
+}
+
+// Check queries every provider for the resources recorded in the deployment state. Provider
+// errors are reported per resource instead of aborting the whole report.
+func Check(ctx context.Context, deploymentState *types.DeploymentState, cfg *config.Config) *Report {
+	report := &Report{
+		ClientName:    deploymentState.ClientName,
+		SanitizedName: deploymentState.SanitizedName,
+	}
+
+	report.Resources = append(report.Resources, checkBucket(ctx, deploymentState, cfg))
+	if deploymentState.Secrets.S3AccessKeyID != "" {
+		report.Resources = append(report.Resources, checkBucketUser(ctx, deploymentState, cfg))
+	}
+	report.Resources = append(report.Resources, checkDatabases(deploymentState, cfg)...)
+	report.Resources = append(report.Resources, checkApp(ctx, deploymentState, cfg))
+	report.Resources = append(report.Resources, checkProject(ctx, deploymentState, cfg))
+
+	return report
+}
+
+// Healthy reports whether every resource exists and matches its expected configuration.
+func (r *Report) Healthy() bool {
+	for _, resource := range r.Resources {
+		if resource.Status != HealthHealthy {
+			return false
+		}
+	}
+	return true
+}
+
+func checkBucket(ctx context.Context, deploymentState *types.DeploymentState, cfg *config.Config) ResourceHealth {
+	bucketName := deploymentState.ResourceNames.S3Bucket
+	health := ResourceHealth{Provider: "aws", Resource: "S3 bucket", Name: bucketName, Status: HealthHealthy}
+
+	s3Service, err := aws.NewS3Service(cfg.AWS.BucketRegion(deploymentState.S3Region), cfg.AWS.AccessKeyID, cfg.AWS.SecretAccessKey, cfg.AWS.S3Endpoint, cfg.AWS.S3UsePathStyle)
+	if err != nil {
+		health.fail(err)
+		return health
+	}
+
+	exists, err := s3Service.BucketExists(ctx, bucketName)
+	if err != nil {
+		health.fail(err)
+		return health
+	}
+	if !exists {
+		health.Status = HealthMissing
+		return health
+	}
+
+	// S3-compatible services may not implement these settings; that is reported, not flagged.
+	health.Details = map[string]string{}
+
+	encrypted, err := s3Service.BucketEncryptionEnabled(ctx, bucketName)
+	switch {
+	case errors.Is(err, aws.ErrNotSupported):
+		health.Details["encryption"] = "unsupported"
+	case err != nil:
+		health.fail(err)
+		return health
+	default:
+		health.Details["encryption"] = fmt.Sprintf("%t", encrypted)
+		if !encrypted {
+			health.problem("default encryption is not enabled")
+		}
+	}
+
+	public, err := s3Service.BucketHasPublicPolicy(ctx, bucketName)
+	switch {
+	case errors.Is(err, aws.ErrNotSupported):
+		health.Details["publicPolicy"] = "unsupported"
+	case err != nil:
+		health.fail(err)
+		return health
+	default:
+		health.Details["publicPolicy"] = fmt.Sprintf("%t", public)
+		if !public {
+			health.problem("public read policy for public/* is missing")
+		}
+	}
+
+	return health
+}
+
+// checkBucketUser checks that the client's IAM user still has the access key the backend uses.
+func checkBucketUser(ctx context.Context, deploymentState *types.DeploymentState, cfg *config.Config) ResourceHealth {
+	userName := deploymentState.ResourceNames.IAMUser
+	health := ResourceHealth{Provider: "aws", Resource: "IAM user", Name: userName, Status: HealthHealthy}
+
+	iamService, err := aws.NewIAMService(cfg.AWS.AccessKeyID, cfg.AWS.SecretAccessKey, cfg.AWS.IAMEndpoint)
+	if err != nil {
+		health.fail(err)
+		return health
+	}
+
+	exists, err := iamService.AccessKeyExists(ctx, userName, deploymentState.Secrets.S3AccessKeyID)
+	if err != nil {
+		health.fail(err)
+		return health
+	}
+	if !exists {
+		health.Status = HealthMissing
+	}
+
+	return health
+}
+
+func checkDatabases(deploymentState *types.DeploymentState, cfg *config.Config) []ResourceHealth {
+	dbNames := []string{deploymentState.ResourceNames.DatabaseMain, deploymentState.ResourceNames.DatabaseHangfire}
+	results := make([]ResourceHealth, len(dbNames))
+
+	dbService := database.NewPostgresService(cfg.Database)
+	sizes, err := dbService.DatabaseSizes(dbNames...)
+
+	for i, dbName := range dbNames {
+		results[i] = ResourceHealth{Provider: "postgres", Resource: "Database", Name: dbName, Status: HealthHealthy}
+		if err != nil {
+			results[i].fail(err)
+			continue
+		}
+
+		size, exists := sizes[dbName]
+		if !exists {
+			results[i].Status = HealthMissing
+			continue
+		}
+		results[i].Details = map[string]string{"size": formatBytes(size)}
+	}
+
+	return results
+}
+
+func checkApp(ctx context.Context, deploymentState *types.DeploymentState, cfg *config.Config) ResourceHealth {
+	health := ResourceHealth{Provider: "digitalocean", Resource: "App", Name: deploymentState.ResourceNames.DOApp, Status: HealthHealthy}
+
+	doService := digitalocean.NewAppService(cfg.DO.Token)
+
+	var app *godo.App
+	var err error
+	if deploymentState.DOAppID != "" {
+		app, err = doService.GetApp(ctx, deploymentState.DOAppID)
+	} else {
+		app, err = doService.FindAppByName(ctx, deploymentState.ResourceNames.DOApp)
+	}
+	if err != nil {
+		health.fail(err)
+		return health
+	}
+	if app == nil {
+		health.Status = HealthMissing
+		return health
+	}
+
+	health.Details = map[string]string{"id": app.ID}
+
+	if app.ActiveDeployment == nil {
+		health.problem("app has no active deployment")
+	} else {
+		phase := string(app.ActiveDeployment.Phase)
+		health.Details["deploymentPhase"] = phase
+		if app.ActiveDeployment.Phase != godo.DeploymentPhase_Active {
+			health.problem("active deployment is in phase %s", phase)
+		}
+	}
+
+	if app.InProgressDeployment != nil {
+		health.Details["inProgressPhase"] = string(app.InProgressDeployment.Phase)
+	}
+
+	if app.LiveURL == "" {
+		health.problem("app has no live URL")
+	} else {
+		health.Details["liveURL"] = app.LiveURL
+		// Once a custom domain is verified it replaces the URL App Platform assigned.
+		customURL := ""
+		if deploymentState.Domain != "" {
+			customURL = "https://" + resources.CustomDomainsFor(deploymentState.Domain).Backend
+		}
+		if deploymentState.BackendURL != "" && !sameURL(app.LiveURL, deploymentState.BackendURL) && !sameURL(app.LiveURL, customURL) {
+			health.problem("live URL %s does not match recorded URL %s", app.LiveURL, deploymentState.BackendURL)
+		}
+	}
+
+	return health
+}
+
+func checkProject(ctx context.Context, deploymentState *types.DeploymentState, cfg *config.Config) ResourceHealth {
+	health := ResourceHealth{Provider: "vercel", Resource: "Project", Name: deploymentState.ResourceNames.VercelProject, Status: HealthHealthy}
+
+	projectRef := deploymentState.ResourceNames.VercelProject
+	if deploymentState.VercelProjectID != "" {
+		projectRef = deploymentState.VercelProjectID
+	}
+
+	vercelService := vercel.NewProjectService(cfg.Vercel)
+	project, err := vercelService.GetProject(ctx, projectRef)
+	if err != nil {
+		health.fail(err)
+		return health
+	}
+	if project == nil {
+		health.Status = HealthMissing
+		return health
+	}
+
+	health.Details = map[string]string{"id": project.ID}
+
+	deployment, err := vercelService.GetLatestDeployment(ctx, project.ID)
+	if err != nil {
+		health.fail(err)
+		return health
+	}
+	if deployment == nil {
+		health.problem("project has no deployments")
+	} else {
+		health.Details["deploymentState"] = deployment.State
+		if deployment.State != "READY" {
+			health.problem("latest deployment is in state %s", deployment.State)
+		}
+	}
+
+	domains, err := vercelService.ListProjectDomains(ctx, project.ID)
+	if err != nil {
+		health.fail(err)
+		return health
+	}
+	domainNames := make([]string, len(domains))
+	for i, domain := range domains {
+		domainNames[i] = domain.Name
+	}
+	if len(domainNames) == 0 {
+		health.problem("project has no domains")
+	} else {
+		health.Details["domains"] = strings.Join(domainNames, ",")
+	}
+
+	return health
+}
+
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+func (r *Report) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROVIDER\tRESOURCE\tNAME\tSTATUS\tDETAILS\tPROBLEMS")
+	for _, resource := range r.Resources {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			resource.Provider,
+			resource.Resource,
+			resource.Name,
+			resource.Status,
+			formatDetails(resource.Details),
+			strings.Join(resource.Problems, "; "),
+		)
+	}
+	return tw.Flush()
+}
+
+func formatDetails(details map[string]string) string {
+	keys := make([]string, 0, len(details))
+	for key := range details {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = fmt.Sprintf("%s=%s", key, details[key])
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+func sameURL(a, b string) bool {
+	return strings.TrimSuffix(a, "/") == strings.TrimSuffix(b, "/")
+}
@@ -0,0 +1,25 @@
+package types
+
+import "time"
+
+const BackupManifestVersion = 1
+
+// BackupManifest describes a database archive created by `db backup`. It is stored in the
+// archive next to the dump it describes.
+type BackupManifest struct {
+	Version       int        `json:"version"`
+	Client        string     `json:"client,omitempty"`
+	Database      string     `json:"database"`
+	Role          string     `json:"role,omitempty"`
+	Host          string     `json:"host"`
+	ServerVersion string     `json:"serverVersion"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	Dump          BackupFile `json:"dump"`
+}
+
+type BackupFile struct {
+	Name   string `json:"name"`
+	Format string `json:"format"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
@@ -5,17 +5,39 @@ type Client struct {
 	SanitizedClientName string
 	Domain              string
 	DatabaseHost        string
-	DatabaseUser        string
+	DatabasePort        int
+	DatabaseSSLMode     string
+	DatabaseTemplate    string
+	S3Region            string
+	S3Seed              string
 	BackendBranch       string
 	FrontendBranch      string
 	SMTPInfo            SMTPInfo
 	FrontendInfo        FrontendInfo
 	BackendInfo         BackendInfo
+	Secrets             ClientSecrets
+	ExtraEnv            ExtraEnv
+	App                 AppSpecOptions
+	Labels              ResourceLabels
+}
+
+// ExtraEnv holds client-specific variables added on top of the generated environment.
+type ExtraEnv struct {
+	Backend  map[string]string `json:"backend,omitempty"`
+	Frontend map[string]string `json:"frontend,omitempty"`
+}
+
+type ClientSecrets struct {
+	JWTKey            string `json:"jwtKey"`
+	RevalidationToken string `json:"revalidationToken"`
+	DatabasePassword  string `json:"databasePassword"`
+	// The access key of the client's IAM user, only issued once its bucket exists.
+	S3AccessKeyID     string `json:"s3AccessKeyId,omitempty"`
+	S3SecretAccessKey string `json:"s3SecretAccessKey,omitempty"`
 }
 
 type BackendInfo struct {
-	URL              string
-	DatabasePassword string
+	URL string
 }
 
 type FrontendInfo struct {
@@ -23,11 +45,11 @@ type FrontendInfo struct {
 }
 
 type SMTPInfo struct {
-	Server          string
-	Port            string
-	Username        string
-	Password        string
-	DoNotReplyName  string
-	DoNotReplyEmail string
-	DevEmail        string
+	Server          string `json:"server"`
+	Port            string `json:"port"`
+	Username        string `json:"username"`
+	Password        string `json:"password,omitempty"`
+	DoNotReplyName  string `json:"doNotReplyName"`
+	DoNotReplyEmail string `json:"doNotReplyEmail"`
+	DevEmail        string `json:"devEmail"`
 }
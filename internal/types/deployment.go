@@ -10,12 +10,16 @@ type DeploymentEnvironment struct {
 
 type ResourceNames struct {
 	S3Bucket         string
+	S3URL            string
 	DatabaseMain     string
 	DatabaseHangfire string
+	DatabaseRole     string
 	DOApp            string
 	VercelProject    string
 	FrontendURL      string
 	BackendURL       string
+	// IAMUser owns the client's bucket credentials; it is empty when S3_CLIENT_CREDENTIALS is off.
+	IAMUser string
 }
 
 type FrontendEnvironment struct {
@@ -44,7 +48,6 @@ type DeploymentDefaults struct {
 type JWTDefaults struct {
 	Issuer            string
 	Audience          string
-	Key               string
 	ExpirationMinutes string
 }
 
@@ -52,7 +55,6 @@ type FrontendDefaults struct {
 	DefaultLanguage     string
 	ValidationTime      string
 	AmazonEnv           string
-	RevalidationToken   string
 	ForcedLoginTimeInMs string
 	BuildCommand        string
 	InstallCommand      string
@@ -60,7 +62,7 @@ type FrontendDefaults struct {
 }
 
 type GitRepositoryDefaults struct {
-	BackendRepo  string
-	FrontendRepo string
-	Branch       string
+	Backend  Repository
+	Frontend Repository
+	Branch   string
 }
@@ -6,3 +6,26 @@ type DigitalOceanEnvVars struct {
 	AppEnvs       map[string]godo.AppVariableDefinition
 	ComponentEnvs map[string]godo.AppVariableDefinition
 }
+
+type DigitalOceanApp struct {
+	ID  string
+	URL string
+}
+
+// AppSpecOptions are the App Platform settings of the backend service. Empty fields are
+// inherited from the global configuration, then from the built-in defaults.
+type AppSpecOptions struct {
+	Tier             string `json:"tier,omitempty"`
+	Region           string `json:"region,omitempty"`
+	InstanceSizeSlug string `json:"instanceSizeSlug,omitempty"`
+	InstanceCount    int64  `json:"instanceCount,omitempty"`
+	HTTPPort         int64  `json:"httpPort,omitempty"`
+	SourceDir        string `json:"sourceDir,omitempty"`
+	DockerfilePath   string `json:"dockerfilePath,omitempty"`
+}
+
+// AppSizeTier is a named instance size and count, such as "small" or "large".
+type AppSizeTier struct {
+	InstanceSizeSlug string
+	InstanceCount    int64
+}
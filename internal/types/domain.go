@@ -0,0 +1,28 @@
+package types
+
+type DNSRecordType string
+
+const (
+	DNSRecordTypeA     DNSRecordType = "A"
+	DNSRecordTypeCNAME DNSRecordType = "CNAME"
+	DNSRecordTypeTXT   DNSRecordType = "TXT"
+)
+
+// DNSRecord is a record a provider needs before it can serve a client's custom domain.
+type DNSRecord struct {
+	Provider string        `json:"provider"`
+	Name     string        `json:"name"`
+	Type     DNSRecordType `json:"type"`
+	Value    string        `json:"value"`
+	// Zone is the zone a DNS provider created the record in. It is empty for records that have
+	// to be created by hand.
+	Zone string `json:"zone,omitempty"`
+}
+
+// CustomDomains are the hostnames derived from a client's domain: the apex and its www redirect
+// are served by Vercel, the api subdomain by the DigitalOcean app.
+type CustomDomains struct {
+	Frontend    string `json:"frontend"`
+	FrontendWWW string `json:"frontendWww"`
+	Backend     string `json:"backend"`
+}
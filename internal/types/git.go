@@ -0,0 +1,18 @@
+package types
+
+type GitProvider string
+
+const (
+	GitProviderBitbucket GitProvider = "bitbucket"
+	GitProviderGitHub    GitProvider = "github"
+	GitProviderGitLab    GitProvider = "gitlab"
+)
+
+// Repository identifies a git repository. Name is the path on the provider (owner/repo) and ID
+// the provider's own identifier, which Vercel uses to create deployments: the repository UUID on
+// Bitbucket, the numeric repository ID on GitHub and the numeric project ID on GitLab.
+type Repository struct {
+	Provider GitProvider
+	Name     string
+	ID       string
+}
@@ -0,0 +1,78 @@
+package types
+
+// Label keys of the resources easy-cli creates. AWS tags and Postgres comments carry all of
+// them. Vercel projects are not labeled.
+const (
+	LabelClient        = "easy-cli:client"
+	LabelSanitizedName = "easy-cli:sanitized-name"
+	LabelEnvironment   = "easy-cli:environment"
+	LabelCreatedBy     = "easy-cli:created-by"
+	LabelVersion       = "easy-cli:version"
+)
+
+// DigitalOcean apps have no tags, so their labels are app-level environment variables.
+const (
+	LabelEnvClient        = "EASY_CLI_CLIENT"
+	LabelEnvSanitizedName = "EASY_CLI_SANITIZED_NAME"
+	LabelEnvEnvironment   = "EASY_CLI_ENVIRONMENT"
+	LabelEnvCreatedBy     = "EASY_CLI_CREATED_BY"
+	LabelEnvVersion       = "EASY_CLI_VERSION"
+)
+
+// ResourceLabels tie a resource to the client it was created for.
+type ResourceLabels struct {
+	Client        string `json:"client"`
+	SanitizedName string `json:"sanitizedName"`
+	Environment   string `json:"environment"`
+	CreatedBy     string `json:"createdBy"`
+	Version       string `json:"version"`
+}
+
+func (l ResourceLabels) IsZero() bool {
+	return l.SanitizedName == ""
+}
+
+func (l ResourceLabels) Map() map[string]string {
+	return map[string]string{
+		LabelClient:        l.Client,
+		LabelSanitizedName: l.SanitizedName,
+		LabelEnvironment:   l.Environment,
+		LabelCreatedBy:     l.CreatedBy,
+		LabelVersion:       l.Version,
+	}
+}
+
+// LabelsFromMap reads the labels back from tags. It returns false for resources without them.
+func LabelsFromMap(values map[string]string) (ResourceLabels, bool) {
+	labels := ResourceLabels{
+		Client:        values[LabelClient],
+		SanitizedName: values[LabelSanitizedName],
+		Environment:   values[LabelEnvironment],
+		CreatedBy:     values[LabelCreatedBy],
+		Version:       values[LabelVersion],
+	}
+	return labels, !labels.IsZero()
+}
+
+func (l ResourceLabels) EnvVars() map[string]string {
+	return map[string]string{
+		LabelEnvClient:        l.Client,
+		LabelEnvSanitizedName: l.SanitizedName,
+		LabelEnvEnvironment:   l.Environment,
+		LabelEnvCreatedBy:     l.CreatedBy,
+		LabelEnvVersion:       l.Version,
+	}
+}
+
+// LabelsFromEnvVars reads the labels back from app variables. It returns false for apps without
+// them.
+func LabelsFromEnvVars(values map[string]string) (ResourceLabels, bool) {
+	labels := ResourceLabels{
+		Client:        values[LabelEnvClient],
+		SanitizedName: values[LabelEnvSanitizedName],
+		Environment:   values[LabelEnvEnvironment],
+		CreatedBy:     values[LabelEnvCreatedBy],
+		Version:       values[LabelEnvVersion],
+	}
+	return labels, !labels.IsZero()
+}
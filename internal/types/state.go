@@ -0,0 +1,76 @@
+package types
+
+import "time"
+
+const DeploymentStateVersion = 1
+
+type StepName string
+
+const (
+	StepS3Bucket         StepName = "s3_bucket"
+	StepS3Credentials    StepName = "s3_credentials"
+	StepS3Seed           StepName = "s3_seed"
+	StepDatabases        StepName = "databases"
+	StepDatabaseRole     StepName = "database_role"
+	StepDOApp            StepName = "do_app"
+	StepVercelProject    StepName = "vercel_project"
+	StepVercelEnv        StepName = "vercel_env"
+	StepVercelDeployment StepName = "vercel_deployment"
+	StepDOEnv            StepName = "do_env"
+	StepDomains          StepName = "domains"
+	StepDomainVerify     StepName = "domain_verification"
+)
+
+type StepStatus string
+
+const (
+	StepStatusPending    StepStatus = "pending"
+	StepStatusInProgress StepStatus = "in_progress"
+	StepStatusCompleted  StepStatus = "completed"
+	StepStatusFailed     StepStatus = "failed"
+	StepStatusRolledBack StepStatus = "rolled_back"
+	StepStatusDestroyed  StepStatus = "destroyed"
+)
+
+type DeploymentState struct {
+	Version         int           `json:"version"`
+	ClientName      string        `json:"clientName"`
+	SanitizedName   string        `json:"sanitizedName"`
+	ResourceNames   ResourceNames `json:"resourceNames"`
+	DOAppID         string        `json:"doAppId,omitempty"`
+	VercelProjectID string        `json:"vercelProjectId,omitempty"`
+	BackendURL      string        `json:"backendUrl,omitempty"`
+	FrontendURL     string        `json:"frontendUrl,omitempty"`
+	Domain          string        `json:"domain,omitempty"`
+	DNSRecords      []DNSRecord   `json:"dnsRecords,omitempty"`
+	// DatabaseTemplate is the template set the client databases were cloned from.
+	DatabaseTemplate string `json:"databaseTemplate,omitempty"`
+	// S3Region is the region of the client bucket; states without one use AWS_REGION.
+	S3Region string `json:"s3Region,omitempty"`
+	// S3Seed is where the client bucket was seeded from; "none" or empty when it was not.
+	S3Seed         string                 `json:"s3Seed,omitempty"`
+	Labels         ResourceLabels         `json:"labels"`
+	BackendBranch  string                 `json:"backendBranch"`
+	FrontendBranch string                 `json:"frontendBranch"`
+	Secrets        ClientSecrets          `json:"secrets"`
+	Steps          map[StepName]StepState `json:"steps"`
+	CreatedAt      time.Time              `json:"createdAt"`
+	UpdatedAt      time.Time              `json:"updatedAt"`
+	// Inputs is nil in states written before the install settings were recorded.
+	Inputs *ClientInputs `json:"inputs,omitempty"`
+}
+
+// ClientInputs are the non-secret settings a client was installed with, from its flags or
+// manifest, so its environment can be regenerated without them. The SMTP password is left out.
+type ClientInputs struct {
+	SMTP     SMTPInfo       `json:"smtp"`
+	ExtraEnv ExtraEnv       `json:"extraEnv"`
+	App      AppSpecOptions `json:"app"`
+}
+
+type StepState struct {
+	Status      StepStatus `json:"status"`
+	StartedAt   time.Time  `json:"startedAt"`
+	CompletedAt time.Time  `json:"completedAt"`
+	Error       string     `json:"error,omitempty"`
+}
@@ -0,0 +1,49 @@
+package types
+
+// BucketOptions configures a client bucket beyond default encryption and public read on
+// public/. A zero number of days disables the matching lifecycle rule.
+type BucketOptions struct {
+	CORS       CORSOptions `json:"cors"`
+	Versioning bool        `json:"versioning"`
+	// TempPrefix holds browser uploads that have not been attached to anything yet.
+	TempPrefix                string `json:"tempPrefix"`
+	TempExpirationDays        int32  `json:"tempExpirationDays"`
+	NoncurrentExpirationDays  int32  `json:"noncurrentExpirationDays"`
+	AbortIncompleteUploadDays int32  `json:"abortIncompleteUploadDays"`
+	// Tags are merged into the tags the bucket already has.
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// CORSOptions lets the frontend upload to the bucket straight from the browser.
+type CORSOptions struct {
+	AllowedOrigins []string `json:"allowedOrigins"`
+	AllowedMethods []string `json:"allowedMethods"`
+	AllowedHeaders []string `json:"allowedHeaders"`
+	ExposeHeaders  []string `json:"exposeHeaders"`
+	MaxAgeSeconds  int32    `json:"maxAgeSeconds"`
+}
+
+// SeedSource holds the files copied into new client buckets, such as default logos and email
+// templates: a local directory, or a bucket and prefix copied server-side.
+type SeedSource struct {
+	Dir    string `json:"dir,omitempty"`
+	Bucket string `json:"bucket,omitempty"`
+	Prefix string `json:"prefix,omitempty"`
+}
+
+func (s SeedSource) IsZero() bool {
+	return s.Dir == "" && s.Bucket == ""
+}
+
+func (s SeedSource) String() string {
+	if s.Bucket != "" {
+		return "s3://" + s.Bucket + "/" + s.Prefix
+	}
+	return s.Dir
+}
+
+// AccessKey is an IAM access key. The secret is only returned when the key is created.
+type AccessKey struct {
+	ID     string
+	Secret string
+}
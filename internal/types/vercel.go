@@ -17,6 +17,15 @@ type VercelEnvVariable struct {
 	GitBranch string `json:"gitBranch,omitempty"`
 }
 
+// VercelProjectEnv is an environment variable as returned by the Vercel API.
+type VercelProjectEnv struct {
+	ID     string   `json:"id"`
+	Key    string   `json:"key"`
+	Value  string   `json:"value"`
+	Target []string `json:"target"`
+	Type   string   `json:"type"`
+}
+
 type VercelGitRepo struct {
 	Repo string `json:"repo"`
 	Type string `json:"type"`
@@ -29,9 +38,46 @@ type VercelDeploymentRequest struct {
 	Target    string          `json:"target,omitempty"`
 }
 
+// VercelGitSource identifies the commit to deploy. Which identifiers are set depends on the
+// provider: repoUuid for Bitbucket, repoId or org and repo for GitHub, projectId for GitLab.
 type VercelGitSource struct {
-	Type     string `json:"type"`
-	Repo     string `json:"repo"`
-	RepoUuid string `json:"repoUuid"`
-	Ref      string `json:"ref"`
+	Type      string `json:"type"`
+	Repo      string `json:"repo,omitempty"`
+	RepoUuid  string `json:"repoUuid,omitempty"`
+	RepoID    string `json:"repoId,omitempty"`
+	Org       string `json:"org,omitempty"`
+	ProjectID string `json:"projectId,omitempty"`
+	Ref       string `json:"ref"`
+}
+
+type VercelProject struct {
+	ID   string
+	Name string
+	URL  string
+}
+
+type VercelDomain struct {
+	Name               string `json:"name"`
+	ApexName           string `json:"apexName"`
+	ProjectID          string `json:"projectId"`
+	Redirect           string `json:"redirect,omitempty"`
+	RedirectStatusCode int    `json:"redirectStatusCode,omitempty"`
+	Verified           bool   `json:"verified"`
+	// Verification lists the challenges to publish when the domain is used by another account.
+	Verification []VercelDomainVerification `json:"verification,omitempty"`
+}
+
+type VercelDomainVerification struct {
+	Type   string `json:"type"`
+	Domain string `json:"domain"`
+	Value  string `json:"value"`
+	Reason string `json:"reason"`
+}
+
+type VercelDeployment struct {
+	UID     string `json:"uid"`
+	URL     string `json:"url"`
+	State   string `json:"state"`
+	Target  string `json:"target"`
+	Created int64  `json:"created"`
 }
@@ -12,6 +12,7 @@ import (
 var (
 	clientNameRegex = regexp.MustCompile(`^[a-zA-Z0-9\s\-_]+$`)
 	portRegex       = regexp.MustCompile(`^\d+$`)
+	domainRegex     = regexp.MustCompile(`^(?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
 )
 
 func ValidateClient(client types.Client) error {
@@ -23,6 +24,12 @@ func ValidateClient(client types.Client) error {
 		return fmt.Errorf("invalid SMTP configuration: %w", err)
 	}
 
+	if client.Domain != "" {
+		if err := validateDomain(client.Domain); err != nil {
+			return fmt.Errorf("invalid domain: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -42,6 +49,23 @@ func validateClientName(name string) error {
 	return nil
 }
 
+func validateDomain(domain string) error {
+	// The www and api subdomains are derived from the domain, so they must fit too.
+	if len(domain) > 249 {
+		return fmt.Errorf("domain cannot exceed 249 characters")
+	}
+
+	if !domainRegex.MatchString(domain) {
+		return fmt.Errorf("%q is not a valid lowercase domain name", domain)
+	}
+
+	if strings.HasPrefix(domain, "www.") || strings.HasPrefix(domain, "api.") {
+		return fmt.Errorf("use the domain without the www or api subdomain, they are derived from it")
+	}
+
+	return nil
+}
+
 func validateSMTPInfo(smtp types.SMTPInfo) error {
 	if strings.TrimSpace(smtp.Server) == "" {
 		return fmt.Errorf("SMTP server cannot be empty")
@@ -0,0 +1,192 @@
+package vercel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/CaioDGallo/easy-cli/internal/types"
+)
+
+// Records Vercel documents for pointing a domain at its edge network.
+const (
+	ApexARecord  = "76.76.21.21"
+	CNAMERecord  = "cname.vercel-dns.com"
+	redirectCode = http.StatusPermanentRedirect
+)
+
+// AddProjectDomain attaches a domain to the project. When redirectTo is set, the domain
+// permanently redirects to it. Adding a domain the project already has returns the existing one.
+func (p *ProjectService) AddProjectDomain(ctx context.Context, projectID, domain, redirectTo string) (*types.VercelDomain, error) {
+	requestBody := map[string]interface{}{"name": domain}
+	if redirectTo != "" {
+		requestBody["redirect"] = redirectTo
+		requestBody["redirectStatusCode"] = redirectCode
+	}
+
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	req, err := p.generateRequest(ctx, "POST", fmt.Sprintf("/v10/projects/%s/domains", projectID), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate request: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add Vercel project domain: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusConflict {
+		existing, getErr := p.GetProjectDomainByName(ctx, projectID, domain)
+		if getErr == nil && existing != nil {
+			return existing, nil
+		}
+	}
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("Vercel API error adding domain %s (status %d): %s", domain, resp.StatusCode, string(body))
+	}
+
+	var added types.VercelDomain
+	if err := json.Unmarshal(body, &added); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &added, nil
+}
+
+// GetProjectDomainByName returns one of the project's domains, or nil if the project does not
+// have it.
+func (p *ProjectService) GetProjectDomainByName(ctx context.Context, projectID, domain string) (*types.VercelDomain, error) {
+	req, err := p.generateRequest(ctx, "GET", fmt.Sprintf("/v9/projects/%s/domains/%s", projectID, domain), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate request: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Vercel project domain: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("Vercel API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var projectDomain types.VercelDomain
+	if err := json.Unmarshal(body, &projectDomain); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &projectDomain, nil
+}
+
+// ProjectDomainReady asks Vercel to verify the domain and reports whether it is verified and its
+// DNS records point at Vercel.
+func (p *ProjectService) ProjectDomainReady(ctx context.Context, projectID, domain string) (bool, error) {
+	req, err := p.generateRequest(ctx, "POST", fmt.Sprintf("/v9/projects/%s/domains/%s/verify", projectID, domain), nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to generate request: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to verify Vercel project domain: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	// Vercel answers with a client error while the verification record is missing.
+	if resp.StatusCode >= 500 {
+		return false, fmt.Errorf("Vercel API error (status %d): %s", resp.StatusCode, string(body))
+	}
+	if resp.StatusCode >= 400 {
+		return false, nil
+	}
+
+	var verified types.VercelDomain
+	if err := json.Unmarshal(body, &verified); err != nil {
+		return false, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if !verified.Verified {
+		return false, nil
+	}
+
+	req, err = p.generateRequest(ctx, "GET", fmt.Sprintf("/v6/domains/%s/config", domain), nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to generate request: %w", err)
+	}
+
+	resp, err = p.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to get Vercel domain configuration: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode >= 400 {
+		return false, fmt.Errorf("Vercel API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var domainConfig struct {
+		Misconfigured bool `json:"misconfigured"`
+	}
+	if err := json.Unmarshal(body, &domainConfig); err != nil {
+		return false, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return !domainConfig.Misconfigured, nil
+}
+
+// DomainRecords returns the DNS records Vercel needs to serve the domains: an A record for an
+// apex domain, a CNAME for a subdomain, and any verification challenges.
+func DomainRecords(domains []types.VercelDomain) []types.DNSRecord {
+	var records []types.DNSRecord
+	for _, domain := range domains {
+		record := types.DNSRecord{Provider: "vercel", Name: domain.Name, Type: types.DNSRecordTypeCNAME, Value: CNAMERecord}
+		if domain.Name == domain.ApexName {
+			record.Type = types.DNSRecordTypeA
+			record.Value = ApexARecord
+		}
+		records = append(records, record)
+
+		for _, verification := range domain.Verification {
+			records = append(records, types.DNSRecord{
+				Provider: "vercel",
+				Name:     verification.Domain,
+				Type:     types.DNSRecordType(verification.Type),
+				Value:    verification.Value,
+			})
+		}
+	}
+	return records
+}
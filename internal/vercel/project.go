@@ -7,8 +7,11 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/CaioDGallo/easy-cli/internal/config"
+	"github.com/CaioDGallo/easy-cli/internal/gitprovider"
 	"github.com/CaioDGallo/easy-cli/internal/interfaces"
 	"github.com/CaioDGallo/easy-cli/internal/logger"
 	"github.com/CaioDGallo/easy-cli/internal/resources"
@@ -30,52 +33,78 @@ func NewProjectService(cfg config.VercelConfig) *ProjectService {
 	}
 }
 
-func (p *ProjectService) CreateProject(ctx context.Context, sanitizedClientName string, envVars []types.VercelEnvVariable, cfg *config.Config) (string, error) {
-	defaults := resources.GetDeploymentDefaults(cfg)
-
-	createProjectBody := &types.CreateVercelProjectBody{
-		Name:            sanitizedClientName,
-		BuildCommand:    defaults.Frontend.BuildCommand,
-		InstallCommand:  defaults.Frontend.InstallCommand,
-		FrameworkPreset: defaults.Frontend.FrameworkPreset,
-		GitRepository: types.VercelGitRepo{
-			Repo: defaults.GitRepository.FrontendRepo,
-			Type: "bitbucket",
-		},
-		EnvironmentVariables: envVars,
+func (p *ProjectService) CreateProject(ctx context.Context, sanitizedClientName string, envVars []types.VercelEnvVariable, cfg *config.Config) (types.VercelProject, error) {
+	createProjectBody, err := BuildProjectBody(sanitizedClientName, envVars, cfg)
+	if err != nil {
+		return types.VercelProject{}, err
 	}
 
 	jsonData, err := json.Marshal(createProjectBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request body: %w", err)
+		return types.VercelProject{}, fmt.Errorf("failed to marshal request body: %w", err)
 	}
 
 	req, err := p.generateRequest(ctx, "POST", "/v11/projects", bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", fmt.Errorf("failed to generate request: %w", err)
+		return types.VercelProject{}, fmt.Errorf("failed to generate request: %w", err)
 	}
 
 	resp, err := p.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to create Vercel project: %w", err)
+		return types.VercelProject{}, fmt.Errorf("failed to create Vercel project: %w", err)
 	}
 	defer resp.Body.Close()
 
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return types.VercelProject{}, fmt.Errorf("failed to read response body: %w", err)
+	}
+
 	if resp.StatusCode >= 400 {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("Vercel API error (status %d): %s", resp.StatusCode, string(body))
+		return types.VercelProject{}, fmt.Errorf("Vercel API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var projectResponse struct {
+		ID string `json:"id"`
+	}
+
+	if err := json.Unmarshal(body, &projectResponse); err != nil {
+		return types.VercelProject{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
 	domain, err := p.GetProjectDomain(ctx, sanitizedClientName)
 	if err != nil {
-		return "", fmt.Errorf("failed to get project domain: %w", err)
+		return types.VercelProject{ID: projectResponse.ID}, fmt.Errorf("failed to get project domain: %w", err)
 	}
 
-	return domain, nil
+	return types.VercelProject{ID: projectResponse.ID, URL: domain}, nil
+}
+
+// BuildProjectBody returns the request body CreateProject sends to the Vercel API.
+func BuildProjectBody(sanitizedClientName string, envVars []types.VercelEnvVariable, cfg *config.Config) (*types.CreateVercelProjectBody, error) {
+	defaults := resources.GetDeploymentDefaults(cfg)
+
+	gitProvider, err := gitprovider.For(defaults.GitRepository.Frontend)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.CreateVercelProjectBody{
+		Name:                 sanitizedClientName,
+		BuildCommand:         defaults.Frontend.BuildCommand,
+		InstallCommand:       defaults.Frontend.InstallCommand,
+		FrameworkPreset:      defaults.Frontend.FrameworkPreset,
+		GitRepository:        gitProvider.VercelGitRepo(defaults.GitRepository.Frontend),
+		EnvironmentVariables: envVars,
+	}, nil
 }
 
 func (p *ProjectService) generateRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
-	vercelAPIURL := fmt.Sprintf("https://api.vercel.com%s?teamId=%s", path, p.config.TeamID)
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	vercelAPIURL := fmt.Sprintf("https://api.vercel.com%s%steamId=%s", path, separator, p.config.TeamID)
 
 	req, err := http.NewRequestWithContext(ctx, method, vercelAPIURL, body)
 	if err != nil {
@@ -88,6 +117,89 @@ func (p *ProjectService) generateRequest(ctx context.Context, method, path strin
 	return req, nil
 }
 
+func (p *ProjectService) ProjectExists(ctx context.Context, projectIDOrName string) (bool, error) {
+	project, err := p.GetProject(ctx, projectIDOrName)
+	if err != nil {
+		return false, err
+	}
+	return project != nil, nil
+}
+
+// GetProject returns the project's ID and name, or nil if the project does not exist.
+func (p *ProjectService) GetProject(ctx context.Context, projectIDOrName string) (*types.VercelProject, error) {
+	req, err := p.generateRequest(ctx, "GET", fmt.Sprintf("/v9/projects/%s", projectIDOrName), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate request: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Vercel project: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("Vercel API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var projectResponse struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+
+	if err := json.Unmarshal(body, &projectResponse); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &types.VercelProject{ID: projectResponse.ID, Name: projectResponse.Name}, nil
+}
+
+// GetLatestDeployment returns the most recent deployment of the project, or nil if it has none.
+func (p *ProjectService) GetLatestDeployment(ctx context.Context, projectID string) (*types.VercelDeployment, error) {
+	req, err := p.generateRequest(ctx, "GET", fmt.Sprintf("/v6/deployments?projectId=%s&limit=1", url.QueryEscape(projectID)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate request: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Vercel deployments: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("Vercel API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var deploymentsResponse struct {
+		Deployments []types.VercelDeployment `json:"deployments"`
+	}
+
+	if err := json.Unmarshal(body, &deploymentsResponse); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(deploymentsResponse.Deployments) == 0 {
+		return nil, nil
+	}
+
+	return &deploymentsResponse.Deployments[0], nil
+}
+
 func (p *ProjectService) DeleteProject(ctx context.Context, projectName string) error {
 	log := logger.WithFields(logrus.Fields{
 		"project": projectName,
@@ -136,16 +248,16 @@ func (p *ProjectService) CreateDeployment(ctx context.Context, client types.Clie
 
 	defaults := resources.GetDeploymentDefaults(cfg)
 
+	gitProvider, err := gitprovider.For(defaults.GitRepository.Frontend)
+	if err != nil {
+		return err
+	}
+
 	deploymentRequest := &types.VercelDeploymentRequest{
-		GitSource: types.VercelGitSource{
-			Type:     "bitbucket",
-			Repo:     defaults.GitRepository.FrontendRepo,
-			RepoUuid: p.config.FrontendRepoUuid,
-			Ref:      client.FrontendBranch,
-		},
-		Project: client.SanitizedClientName,
-		Name:    fmt.Sprintf("%s-deployment", client.SanitizedClientName),
-		Target:  "production",
+		GitSource: gitProvider.VercelGitSource(defaults.GitRepository.Frontend, client.FrontendBranch),
+		Project:   client.SanitizedClientName,
+		Name:      fmt.Sprintf("%s-deployment", client.SanitizedClientName),
+		Target:    "production",
 	}
 
 	jsonData, err := json.Marshal(deploymentRequest)
@@ -178,47 +290,17 @@ func (p *ProjectService) CreateDeployment(ctx context.Context, client types.Clie
 }
 
 func (p *ProjectService) GetProjectDomain(ctx context.Context, projectName string) (string, error) {
-	req, err := p.generateRequest(ctx, "GET", fmt.Sprintf("/v9/projects/%s/domains", projectName), nil)
-	if err != nil {
-		return "", fmt.Errorf("failed to generate request: %w", err)
-	}
-
-	resp, err := p.client.Do(req)
+	domains, err := p.ListProjectDomains(ctx, projectName)
 	if err != nil {
-		return "", fmt.Errorf("failed to get Vercel project domains: %w", err)
+		return "", err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("Vercel API error (status %d): %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	var domainsResponse struct {
-		Domains []struct {
-			Name               string `json:"name"`
-			ApexName           string `json:"apexName"`
-			ProjectID          string `json:"projectId"`
-			Redirect           string `json:"redirect,omitempty"`
-			RedirectStatusCode int    `json:"redirectStatusCode,omitempty"`
-		} `json:"domains"`
-	}
-
-	if err := json.Unmarshal(body, &domainsResponse); err != nil {
-		return "", fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-
-	if len(domainsResponse.Domains) == 0 {
+	if len(domains) == 0 {
 		return "", fmt.Errorf("no domains found for project")
 	}
 
 	var primaryDomain string
-	for _, domain := range domainsResponse.Domains {
+	for _, domain := range domains {
 		if domain.Redirect == "" {
 			primaryDomain = domain.Name
 			break
@@ -226,51 +308,54 @@ func (p *ProjectService) GetProjectDomain(ctx context.Context, projectName strin
 	}
 
 	if primaryDomain == "" {
-		primaryDomain = domainsResponse.Domains[0].Name
+		primaryDomain = domains[0].Name
 	}
 
 	return fmt.Sprintf("https://%s", primaryDomain), nil
 }
 
-func (p *ProjectService) UpdateProjectEnvironmentVariables(ctx context.Context, projectName string, envVars []types.VercelEnvVariable) error {
-	req, err := p.generateRequest(ctx, "GET", fmt.Sprintf("/v10/projects/%s/env", projectName), nil)
+func (p *ProjectService) ListProjectDomains(ctx context.Context, projectName string) ([]types.VercelDomain, error) {
+	req, err := p.generateRequest(ctx, "GET", fmt.Sprintf("/v9/projects/%s/domains", projectName), nil)
 	if err != nil {
-		return fmt.Errorf("failed to generate request: %w", err)
+		return nil, fmt.Errorf("failed to generate request: %w", err)
 	}
 
 	resp, err := p.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to get Vercel project env vars: %w", err)
+		return nil, fmt.Errorf("failed to get Vercel project domains: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("Vercel API error getting env vars (status %d): %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("Vercel API error (status %d): %s", resp.StatusCode, string(body))
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	var existingEnvVars struct {
-		Envs []struct {
-			ID     string   `json:"id"`
-			Key    string   `json:"key"`
-			Value  string   `json:"value"`
-			Target []string `json:"target"`
-			Type   string   `json:"type"`
-		} `json:"envs"`
+	var domainsResponse struct {
+		Domains []types.VercelDomain `json:"domains"`
 	}
 
-	if err := json.Unmarshal(body, &existingEnvVars); err != nil {
-		return fmt.Errorf("failed to unmarshal response: %w", err)
+	if err := json.Unmarshal(body, &domainsResponse); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return domainsResponse.Domains, nil
+}
+
+func (p *ProjectService) UpdateProjectEnvironmentVariables(ctx context.Context, projectName string, envVars []types.VercelEnvVariable) error {
+	existingEnvVars, err := p.ListProjectEnvironmentVariables(ctx, projectName)
+	if err != nil {
+		return err
 	}
 
 	for _, envVar := range envVars {
 		var existingID string
-		for _, existing := range existingEnvVars.Envs {
+		for _, existing := range existingEnvVars {
 			if existing.Key == envVar.Key {
 				existingID = existing.ID
 				break
@@ -330,3 +415,58 @@ func (p *ProjectService) UpdateProjectEnvironmentVariables(ctx context.Context,
 
 	return nil
 }
+
+// ListProjectEnvironmentVariables returns every environment variable of the project. Encrypted
+// values are returned decrypted; sensitive values are never returned by Vercel.
+func (p *ProjectService) ListProjectEnvironmentVariables(ctx context.Context, projectName string) ([]types.VercelProjectEnv, error) {
+	req, err := p.generateRequest(ctx, "GET", fmt.Sprintf("/v10/projects/%s/env?decrypt=true", projectName), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate request: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Vercel project env vars: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("Vercel API error getting env vars (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var existingEnvVars struct {
+		Envs []types.VercelProjectEnv `json:"envs"`
+	}
+
+	if err := json.Unmarshal(body, &existingEnvVars); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return existingEnvVars.Envs, nil
+}
+
+func (p *ProjectService) DeleteProjectEnvironmentVariable(ctx context.Context, projectName, envID string) error {
+	req, err := p.generateRequest(ctx, "DELETE", fmt.Sprintf("/v9/projects/%s/env/%s", projectName, envID), nil)
+	if err != nil {
+		return fmt.Errorf("failed to generate delete request: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete env var: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 && resp.StatusCode != 404 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("Vercel API error deleting env var (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
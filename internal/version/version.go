@@ -0,0 +1,18 @@
+package version
+
+import "runtime/debug"
+
+// Version is set at build time with -ldflags "-X github.com/CaioDGallo/easy-cli/internal/version.Version=v1.2.3".
+var Version = ""
+
+// String returns the version of the running binary. Builds without the linker flag fall back to
+// the module version go install records, then to "dev".
+func String() string {
+	if Version != "" {
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}